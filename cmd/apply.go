@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yjmrobert/itamae/itamae"
+)
+
+var applyYes bool
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <profile>",
+	Short: "Converge this machine to a profile",
+	Long: `Install, change and remove plugins until this machine matches a profile.
+
+apply compares the profile with the machine the way 'itamae diff' does and
+shows one plan before asking for confirmation:
+
+  install   plugins the profile lists that are not installed
+  change    plugins installed at another version than the profile pins
+  remove    installed plugins the profile lists under 'absent'
+
+Installed plugins the profile doesn't mention are left alone. Running apply
+again on a converged machine changes nothing.
+
+  name: team
+  plugins: [git, gh, kubectl]
+  versions:
+    kubectl: v1.30.2
+  absent: [helm]
+
+With --yes, the plan is applied without confirmation and inputs are filled
+from --input, the inputs.toml next to the profile, remembered answers and
+default commands, as with 'itamae install --omakase'.
+
+Examples:
+  itamae apply team.yaml
+  itamae apply team.yaml --yes --input GIT_USER_EMAIL=me@example.com`,
+	Args: cobra.ExactArgs(1),
+	Run:  exitWith(runApply),
+}
+
+func init() {
+	applyCmd.Flags().BoolVarP(&applyYes, "yes", "y", false, "Apply the plan without confirmation or prompts")
+	applyCmd.Flags().DurationVar(&aptMaxAge, "apt-max-age", itamae.DefaultAptMaxAge, "Refresh APT package lists older than this")
+	applyCmd.Flags().StringArrayVar(&inputFlags, "input", nil, "Answer a required input as NAME=VALUE (repeatable)")
+	applyCmd.Flags().StringVar(&secretStore, "secret-store", os.Getenv("ITAMAE_SECRET_STORE"), "Remember secret inputs in the keyring or a passphrase-encrypted file (keyring|file)")
+	rootCmd.AddCommand(applyCmd)
+}
+
+func runApply(cmd *cobra.Command, args []string) int {
+	path := args[0]
+	profile, err := itamae.LoadProfile(path)
+	if err != nil {
+		itamae.Logger.Errorf("Error loading profile: %v\n", err)
+		return 1
+	}
+	inputs, err := itamae.ParseInputFlags(inputFlags)
+	if err != nil {
+		itamae.Logger.Errorf("%v\n", err)
+		return 1
+	}
+	store, err := itamae.OpenSecretStore(secretStore)
+	if err != nil {
+		itamae.Logger.Errorf("%v\n", err)
+		return 1
+	}
+	defaults, err := loadInputDefaults(path)
+	if err != nil {
+		itamae.Logger.Errorf("%v\n", err)
+		return 1
+	}
+
+	plugins, cleanup, err := itamae.LoadAllPlugins()
+	if err != nil {
+		itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+		return 1
+	}
+	defer cleanup()
+
+	plan, err := itamae.PlanApply(plugins, profile)
+	if err != nil {
+		itamae.Logger.Errorf("%v\n", err)
+		return 1
+	}
+	if plan.Empty() {
+		fmt.Printf("%s This machine already matches profile %s\n", successStyle.Render("✓"), profile.Name)
+		return 0
+	}
+
+	err = itamae.RunApplyTUI(plan, itamae.InstallOptions{
+		AptMaxAge:      aptMaxAge,
+		NonInteractive: applyYes,
+		Inputs:         inputs,
+		SecretStore:    store,
+		InputDefaults:  defaults,
+		InputsFile:     itamae.InputsFile(),
+	})
+	if err != nil {
+		itamae.Logger.Errorf("%v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/yjmrobert/itamae/itamae"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the offline installation cache",
+	Long: `Manage the cache used by 'itamae install --offline'.
+
+The cache holds the .deb files of APT plugins and the artifacts declared by
+binary plugins with the ARTIFACT metadata key.`,
+}
+
+var cacheFillCmd = &cobra.Command{
+	Use:   "fill [plugin-id...]",
+	Short: "Download plugins into the offline cache",
+	Long: `Download the .deb files and binary artifacts for the given plugins.
+
+Without arguments, prompts for a category the same way 'itamae install' does.
+Fill the cache from a machine running the same release as the targets. Every
+dependency of an APT plugin is downloaded, even those installed here.
+
+Examples:
+  itamae cache fill                        # Choose a category interactively
+  itamae cache fill git gh yq              # Cache specific plugins
+  itamae cache fill --profile team.yaml    # Cache a profile's plugins and versions
+  itamae cache fill --cache /media/usb/itamae
+  itamae install --offline --cache /media/usb/itamae`,
+	Run: runCacheFill,
+}
+
+func init() {
+	cacheFillCmd.Flags().StringVarP(&profilePath, "profile", "p", "", "Cache the plugins listed in a profile file")
+	cacheFillCmd.Flags().StringVar(&cacheDir, "cache", itamae.DefaultCacheDir(), "Offline cache directory")
+	cacheFillCmd.Flags().DurationVar(&aptMaxAge, "apt-max-age", itamae.DefaultAptMaxAge, "Refresh APT package lists older than this")
+	cacheCmd.AddCommand(cacheFillCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+func runCacheFill(cmd *cobra.Command, args []string) {
+	var plugins []itamae.ToolPlugin
+
+	if profilePath != "" || len(args) > 0 {
+		all, cleanup, err := itamae.LoadAllPlugins()
+		if err != nil {
+			itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+			return
+		}
+		defer cleanup()
+
+		if profilePath != "" {
+			profile, err := itamae.LoadProfile(profilePath)
+			if err != nil {
+				itamae.Logger.Errorf("Error loading profile: %v\n", err)
+				return
+			}
+			plugins, err = profile.Resolve(all)
+		} else {
+			plugins, err = itamae.FindPlugins(all, args)
+		}
+		if err != nil {
+			itamae.Logger.Errorf("Error selecting plugins: %v\n", err)
+			return
+		}
+	} else {
+		category, err := itamae.SelectCategory()
+		if err != nil {
+			itamae.Logger.Errorf("Error selecting category: %v\n", err)
+			return
+		}
+
+		all, cleanup, err := itamae.LoadPlugins(category)
+		if err != nil {
+			itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+			return
+		}
+		defer cleanup()
+
+		plugins = itamae.SelectPlugins(all, category)
+	}
+
+	if len(plugins) == 0 {
+		itamae.Logger.Info("No plugins selected. Exiting.")
+		return
+	}
+
+	if err := itamae.RunCacheFill(plugins, cacheDir, aptMaxAge); err != nil {
+		itamae.Logger.Errorf("Error filling cache: %v\n", err)
+	}
+}
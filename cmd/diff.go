@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/yjmrobert/itamae/itamae"
+)
+
+var (
+	diffJSON bool
+	diffFix  bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show how this machine differs from a profile",
+	Long: `Compare a profile with this machine and report:
+
+  missing   plugins the profile lists that are not installed
+  extra     plugins installed by itamae that the profile doesn't list,
+            and installed plugins it marks absent
+  version   plugins installed at another version than the profile pins
+  input     plugins installed with another input value than the
+            inputs.toml next to the profile
+
+Plugins count as installed when their check command succeeds. Plugins itamae
+installed, and the inputs it used, are recorded in
+~/.local/state/itamae/state.json ($ITAMAE_STATE_FILE overrides it); tools
+installed by other means are never reported as extra.
+
+Like diff(1), exits with 1 when there are differences and 2 on errors, so
+it can gate CI. With --fix, installs the missing plugins, then reports what
+is left; a plugin that fails to install is an error. --input and
+--apt-max-age work as for 'itamae install'.
+
+Examples:
+  itamae diff --profile team.yaml
+  itamae diff --profile team.yaml --json
+  itamae diff --profile team.yaml --fix
+  itamae diff --profile team.yaml --fix --input GIT_USER_EMAIL=me@example.com`,
+	Args: cobra.NoArgs,
+	Run:  exitWith(runDiff),
+}
+
+func init() {
+	diffCmd.Flags().StringVarP(&profilePath, "profile", "p", "", "Profile to compare with")
+	diffCmd.Flags().BoolVar(&diffJSON, "json", false, "Output the differences as JSON")
+	diffCmd.Flags().BoolVar(&diffFix, "fix", false, "Install the missing plugins")
+	diffCmd.Flags().StringArrayVar(&inputFlags, "input", nil, "With --fix, answer a required input as NAME=VALUE (repeatable)")
+	diffCmd.Flags().DurationVar(&aptMaxAge, "apt-max-age", itamae.DefaultAptMaxAge, "With --fix, refresh APT package lists older than this")
+	diffCmd.Flags().StringVar(&secretStore, "secret-store", os.Getenv("ITAMAE_SECRET_STORE"), "With --fix, read and remember secret inputs in the keyring or a passphrase-encrypted file (keyring|file)")
+	diffCmd.MarkFlagRequired("profile")
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) int {
+	profile, err := itamae.LoadProfile(profilePath)
+	if err != nil {
+		itamae.Logger.Errorf("Error loading profile: %v\n", err)
+		return 2
+	}
+	teamInputs, err := itamae.LoadInputDefaults(itamae.TeamInputsPath(profilePath))
+	if err != nil {
+		itamae.Logger.Errorf("%v\n", err)
+		return 2
+	}
+
+	plugins, cleanup, err := itamae.LoadAllPlugins()
+	if err != nil {
+		itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+		return 2
+	}
+	defer cleanup()
+
+	drifts, err := itamae.Diff(plugins, profile, teamInputs)
+	if err != nil {
+		itamae.Logger.Errorf("%v\n", err)
+		return 2
+	}
+
+	if diffFix {
+		missing, err := itamae.MissingPlugins(plugins, profile, drifts)
+		if err != nil {
+			itamae.Logger.Errorf("%v\n", err)
+			return 2
+		}
+		if len(missing) > 0 {
+			if err := fixMissing(missing); err != nil {
+				itamae.Logger.Errorf("%v\n", err)
+				return 2
+			}
+			if drifts, err = itamae.Diff(plugins, profile, teamInputs); err != nil {
+				itamae.Logger.Errorf("%v\n", err)
+				return 2
+			}
+		}
+	}
+
+	if diffJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(drifts); err != nil {
+			itamae.Logger.Errorf("Error encoding report: %v\n", err)
+			return 2
+		}
+	} else {
+		printDrifts(profile, drifts)
+	}
+
+	if len(drifts) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// fixMissing installs the missing plugins the way 'itamae install --profile'
+// would, and returns an error if any of them failed.
+func fixMissing(missing []itamae.ToolPlugin) error {
+	inputs, err := itamae.ParseInputFlags(inputFlags)
+	if err != nil {
+		return err
+	}
+	store, err := itamae.OpenSecretStore(secretStore)
+	if err != nil {
+		return err
+	}
+	defaults, err := loadInputDefaults(profilePath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Installing %d missing plugin(s)\n", len(missing))
+	return itamae.RunInstallTUI(missing, itamae.InstallOptions{
+		AptMaxAge:     aptMaxAge,
+		Inputs:        inputs,
+		SecretStore:   store,
+		InputDefaults: defaults,
+		InputsFile:    itamae.InputsFile(),
+	})
+}
+
+func printDrifts(profile itamae.Profile, drifts []itamae.Drift) {
+	if len(drifts) == 0 {
+		fmt.Printf("%s This machine matches profile %s\n", successStyle.Render("✓"), profile.Name)
+		return
+	}
+
+	fmt.Println(headerStyle.Render(fmt.Sprintf("🔍 %d difference(s) from profile %s:", len(drifts), profile.Name)))
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PLUGIN\tDRIFT\tWANT\tGOT")
+	missing := false
+	for _, d := range drifts {
+		id := d.ID
+		if d.Input != "" {
+			id += " (" + d.Input + ")"
+		}
+		want, got := orDash(d.Want), orDash(d.Got)
+		switch d.Kind {
+		case itamae.DriftMissing:
+			missing = true
+			if d.Want == "" {
+				want = "installed"
+			}
+			got = "-"
+		case itamae.DriftExtra:
+			if d.Want == "" {
+				want = "-"
+			}
+			if d.Got == "" {
+				got = "installed"
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", id, renderDriftKind(d.Kind), want, got)
+	}
+	w.Flush()
+
+	fmt.Println()
+	if missing {
+		fmt.Printf("%s Install the missing plugins with: itamae diff --profile %s --fix\n", dimStyle.Render("Tip:"), profilePath)
+	}
+}
+
+func renderDriftKind(kind string) string {
+	switch kind {
+	case itamae.DriftMissing:
+		return errorStyle.Render(kind)
+	case itamae.DriftExtra:
+		return dimStyle.Render(kind)
+	default:
+		return warningStyle.Render(kind)
+	}
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yjmrobert/itamae/itamae"
+)
+
+var (
+	exportFormat string
+	exportOutput string
+	exportBase   string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export [plugin-id...]",
+	Short: "Render a plugin set as a Dockerfile, cloud-init config or shell script",
+	Long: `Render the installation of a set of plugins as a file that can be run
+without itamae, e.g. to bake a toolset into a dev container image.
+
+The output embeds the plugin scripts and runs the same phases as
+'itamae install', in the same order: repository setup, one batched APT
+install, post-install tasks, then each remaining plugin's install script.
+Everything runs as root; scripts calling sudo get a stand-in that runs the
+command directly.
+
+Required inputs become Dockerfile ARGs (or variables of the shell script)
+defaulting to the --input values and the team defaults in the inputs.toml
+next to the profile. Secret inputs are never written: a Dockerfile reads
+them from BuildKit secrets, the other formats from the environment.
+
+Examples:
+  itamae export --profile team.yaml > Dockerfile
+  itamae export --format cloud-init --profile team.yaml -o user-data
+  itamae export --format shell git gh yq --input GIT_USER_NAME="Ada Lovelace"
+  itamae export --omakase --base debian:bookworm`,
+	Run: exitWith(runExport),
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", itamae.ExportDockerfile, "Output format ("+strings.Join(itamae.ExportFormats, "|")+")")
+	exportCmd.Flags().StringVarP(&profilePath, "profile", "p", "", "Export the plugins listed in a profile file")
+	exportCmd.Flags().BoolVar(&omakase, "omakase", false, "Export the curated omakase set")
+	exportCmd.Flags().StringArrayVar(&inputFlags, "input", nil, "Default for a required input as NAME=VALUE (repeatable)")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write to a file instead of stdout")
+	exportCmd.Flags().StringVar(&exportBase, "base", itamae.DefaultExportBaseImage, "Base image of the Dockerfile")
+	exportCmd.MarkFlagsMutuallyExclusive("omakase", "profile")
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExport(cmd *cobra.Command, args []string) int {
+	if (profilePath != "" || omakase) && len(args) > 0 {
+		itamae.Logger.Errorf("Pass plugin IDs or --profile/--omakase, not both\n")
+		return 1
+	}
+	if profilePath == "" && !omakase && len(args) == 0 {
+		itamae.Logger.Errorf("Nothing to export: pass plugin IDs, --profile or --omakase\n")
+		return 1
+	}
+
+	inputs, err := itamae.ParseInputFlags(inputFlags)
+	if err != nil {
+		itamae.Logger.Errorf("%v\n", err)
+		return 1
+	}
+	defaults, err := itamae.LoadInputDefaults(itamae.TeamInputsPath(profilePath))
+	if err != nil {
+		itamae.Logger.Errorf("%v\n", err)
+		return 1
+	}
+
+	all, cleanup, err := itamae.LoadAllPlugins()
+	if err != nil {
+		itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+		return 1
+	}
+	defer cleanup()
+
+	opts := itamae.ExportOptions{Format: exportFormat, BaseImage: exportBase, Inputs: inputs, InputDefaults: defaults}
+	var selected []itamae.ToolPlugin
+	switch {
+	case profilePath != "":
+		profile, err := itamae.LoadProfile(profilePath)
+		if err != nil {
+			itamae.Logger.Errorf("Error loading profile: %v\n", err)
+			return 1
+		}
+		selected, err = profile.Resolve(all)
+		if err != nil {
+			itamae.Logger.Errorf("Error resolving profile: %v\n", err)
+			return 1
+		}
+		opts.Name = profile.Name
+	case omakase:
+		selected = itamae.OmakasePlugins(all)
+		opts.Name = itamae.CategoryOmakase
+	default:
+		selected, err = itamae.FindPlugins(all, args)
+		if err != nil {
+			itamae.Logger.Errorf("Error selecting plugins: %v\n", err)
+			return 1
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	if exportOutput != "" {
+		f, err := os.Create(exportOutput)
+		if err != nil {
+			itamae.Logger.Errorf("Error creating %s: %v\n", exportOutput, err)
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if err := itamae.Export(out, selected, opts); err != nil {
+		itamae.Logger.Errorf("Error exporting: %v\n", err)
+		return 1
+	}
+	if exportOutput != "" {
+		fmt.Printf("%s Exported %d plugins to %s\n", successStyle.Render("✓"), len(selected), pathStyle.Render(exportOutput))
+	}
+	return 0
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yjmrobert/itamae/itamae"
+)
+
+var (
+	importOutput string
+	importName   string
+	importPin    bool
+	importForce  bool
+	importInputs bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Snapshot this machine's tooling into a profile",
+	Long: `Write a profile listing every plugin installed on this machine, to
+replay elsewhere with 'itamae install --profile'.
+
+Each plugin's check command decides whether it is installed. With
+--with-inputs, required inputs of the installed plugins are also filled from
+their default commands (e.g. your git config) and written to the inputs.toml
+next to the profile, which 'itamae install --profile' uses as defaults.
+They are left out by default because they often hold personal values such
+as your name and email, which don't belong in a team file. Secret inputs
+are never written.
+
+Examples:
+  itamae import
+  itamae import -o team/dev.yaml --name dev
+  itamae import --pin-versions -o workstation.yaml
+  itamae import --with-inputs -o ~/my-setup/profile.yaml`,
+	Args: cobra.NoArgs,
+	Run:  exitWith(runImport),
+}
+
+func init() {
+	importCmd.Flags().StringVarP(&importOutput, "output", "o", "profile.yaml", "Profile file to write")
+	importCmd.Flags().StringVar(&importName, "name", "", "Profile name (defaults to the file name)")
+	importCmd.Flags().BoolVar(&importPin, "pin-versions", false, "Pin each plugin to the version installed here")
+	importCmd.Flags().BoolVar(&importInputs, "with-inputs", false, "Also write the detected input values, which may be personal, to inputs.toml")
+	importCmd.Flags().BoolVar(&importForce, "force", false, "Overwrite an existing profile or inputs file")
+	rootCmd.AddCommand(importCmd)
+}
+
+func runImport(cmd *cobra.Command, args []string) int {
+	inputsPath := itamae.ProfileInputsPath(importOutput)
+	if !importForce {
+		paths := []string{importOutput}
+		if importInputs {
+			paths = append(paths, inputsPath)
+		}
+		for _, path := range paths {
+			if _, err := os.Stat(path); err == nil {
+				itamae.Logger.Errorf("%s already exists; use --force to overwrite it\n", path)
+				return 1
+			}
+		}
+	}
+
+	name := importName
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(importOutput), filepath.Ext(importOutput))
+	}
+
+	plugins, cleanup, err := itamae.LoadAllPlugins()
+	if err != nil {
+		itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+		return 1
+	}
+	defer cleanup()
+
+	fmt.Printf("Checking %d plugins...\n", len(plugins))
+	result := itamae.Import(plugins, itamae.ImportOptions{Name: name, PinVersions: importPin, Inputs: importInputs})
+	if len(result.Profile.Plugins) == 0 {
+		fmt.Println("No installed plugins found. Nothing to import.")
+		return 0
+	}
+
+	if err := result.Save(importOutput); err != nil {
+		itamae.Logger.Errorf("%v\n", err)
+		return 1
+	}
+
+	fmt.Printf("%s Wrote %d plugins to %s\n", successStyle.Render("✓"), len(result.Profile.Plugins), pathStyle.Render(importOutput))
+	for _, id := range result.Profile.Plugins {
+		if version, ok := result.Profile.Versions[id]; ok {
+			fmt.Printf("  %s %s\n", id, dimStyle.Render(version))
+		} else {
+			fmt.Printf("  %s\n", id)
+		}
+	}
+
+	if len(result.Inputs) > 0 {
+		names := make([]string, 0, len(result.Inputs))
+		for name := range result.Inputs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Printf("%s Wrote %d inputs to %s\n", successStyle.Render("✓"), len(names), pathStyle.Render(inputsPath))
+		for _, name := range names {
+			fmt.Printf("  %s = %s\n", name, dimStyle.Render(result.Inputs[name]))
+		}
+	}
+
+	fmt.Printf("\nReplay with: itamae install --profile %s\n", importOutput)
+	return 0
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yjmrobert/itamae/itamae"
+)
+
+var (
+	infoScript bool
+	infoJSON   bool
+)
+
+var infoCmd = &cobra.Command{
+	Use:   "info <plugin-id>",
+	Short: "Show the metadata of a plugin",
+	Long: `Show everything Itamae knows about a plugin: its metadata, required
+inputs and the commands used to pre-fill them.
+
+Examples:
+  itamae info gh              # Metadata
+  itamae info gh --script     # Metadata followed by the raw script
+  itamae info gh --json       # Machine-readable output`,
+	Args: cobra.ExactArgs(1),
+	Run:  exitWith(runInfo),
+}
+
+func init() {
+	infoCmd.Flags().BoolVar(&infoScript, "script", false, "Print the raw plugin script")
+	infoCmd.Flags().BoolVar(&infoJSON, "json", false, "Output the metadata as JSON")
+	rootCmd.AddCommand(infoCmd)
+}
+
+func runInfo(cmd *cobra.Command, args []string) int {
+	all, cleanup, err := itamae.LoadAllPlugins()
+	if err != nil {
+		itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+		return 1
+	}
+	defer cleanup()
+
+	found, err := itamae.FindPlugins(all, args)
+	if err != nil {
+		itamae.Logger.Errorf("%v\n", err)
+		return 1
+	}
+	plugin := found[0]
+	info := itamae.NewPluginInfo(plugin)
+
+	if infoJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(info); err != nil {
+			itamae.Logger.Errorf("Error encoding plugin info: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Println(headerStyle.Render(fmt.Sprintf("📦 %s (%s)", info.Name, info.ID)))
+	fmt.Println()
+	printField("Description", info.Description)
+	printField("Category", info.Category)
+	printField("Tags", strings.Join(info.Tags, ", "))
+	if info.Omakase {
+		printField("Omakase", "yes (curated default set)")
+	}
+	printField("Install method", info.InstallMethod)
+	if !info.RequiresRoot {
+		printField("Requires root", "no (installable with --user)")
+	}
+	printField("Package name", info.PackageName)
+	printField("Version", info.Version)
+	printField("APT repository", info.AptRepo)
+	printField("Repo setup", info.RepoSetup)
+	printField("Post-install", info.PostInstall)
+	for _, name := range info.Binaries {
+		printField("Installs", "/usr/local/bin/"+name)
+	}
+
+	if len(info.RequiredInputs) > 0 {
+		fmt.Println()
+		fmt.Println(infoStyle.Render("Required inputs:"))
+		for _, input := range info.RequiredInputs {
+			fmt.Printf("   • %s (%s): %s\n", input.Name, input.Type, input.Prompt)
+			if input.Help != "" {
+				fmt.Println("     " + dimStyle.Render(input.Help))
+			}
+			if input.DefaultCmd != "" {
+				fmt.Printf("     %s %s\n", dimStyle.Render("default:"), input.DefaultCmd)
+			}
+		}
+	}
+
+	if infoScript {
+		script, err := itamae.ScriptContent(plugin)
+		if err != nil {
+			itamae.Logger.Errorf("%v\n", err)
+			return 1
+		}
+		fmt.Println()
+		fmt.Println(infoStyle.Render("Script:"))
+		fmt.Println(script)
+	}
+	return 0
+}
+
+// printField prints a labelled metadata value, skipping empty ones.
+func printField(label, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Printf("%s %s\n", infoStyle.Render(fmt.Sprintf("%-16s", label+":")), value)
+}
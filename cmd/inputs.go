@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/yjmrobert/itamae/itamae"
+)
+
+var inputsFile string
+
+var inputsCmd = &cobra.Command{
+	Use:   "inputs",
+	Short: "Manage remembered input answers",
+	Long: `Manage the answers itamae remembers for plugin inputs such as
+GIT_USER_NAME.
+
+Answers given during 'itamae install' are saved to ~/.config/itamae/inputs.toml
+(or $ITAMAE_INPUTS_FILE) and pre-fill the prompts next time. Secret inputs
+are never saved here; see 'itamae install --secret-store'.
+
+A team can commit an inputs.toml next to its profiles: 'itamae install
+--profile team/dev.yaml' uses team/inputs.toml as defaults, below your own
+answers. Edit it with --file.
+
+Examples:
+  itamae inputs list
+  itamae inputs set GIT_USER_EMAIL ada@example.com
+  itamae inputs unset GIT_USER_EMAIL
+  itamae inputs list --file team/inputs.toml`,
+}
+
+var inputsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show plugin inputs and their remembered answers",
+	Args:  cobra.NoArgs,
+	Run:   exitWith(runInputsList),
+}
+
+var inputsSetCmd = &cobra.Command{
+	Use:   "set <NAME> <value>",
+	Short: "Remember an answer for an input",
+	Args:  cobra.ExactArgs(2),
+	Run:   exitWith(runInputsSet),
+}
+
+var inputsUnsetCmd = &cobra.Command{
+	Use:   "unset <NAME>...",
+	Short: "Forget remembered answers",
+	Args:  cobra.MinimumNArgs(1),
+	Run:   runInputsUnset,
+}
+
+func init() {
+	inputsCmd.PersistentFlags().StringVar(&inputsFile, "file", itamae.InputsFile(), "Answers file to read or edit")
+	inputsCmd.AddCommand(inputsListCmd)
+	inputsCmd.AddCommand(inputsSetCmd)
+	inputsCmd.AddCommand(inputsUnsetCmd)
+	rootCmd.AddCommand(inputsCmd)
+}
+
+// loadInputDefaults returns the answers that pre-fill input prompts: the team
+// defaults next to the profile, overridden by the user's own answers.
+func loadInputDefaults(profilePath string) (map[string]string, error) {
+	return itamae.LoadInputDefaults(itamae.TeamInputsPath(profilePath), itamae.InputsFile())
+}
+
+func runInputsList(cmd *cobra.Command, args []string) int {
+	plugins, cleanup, err := itamae.LoadAllPlugins()
+	if err != nil {
+		itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+		return 1
+	}
+	defer cleanup()
+
+	answers, err := itamae.LoadInputAnswers(inputsFile)
+	if err != nil {
+		itamae.Logger.Errorf("%v\n", err)
+		return 1
+	}
+
+	fmt.Println(dimStyle.Render("Answers from " + inputsFile))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tVALUE\tUSED BY")
+
+	known := map[string]bool{}
+	for _, usage := range itamae.KnownInputs(plugins) {
+		known[usage.Input.Name] = true
+		value := orDash(answers[usage.Input.Name])
+		if usage.Input.Type == itamae.InputSecret {
+			value = dimStyle.Render("(secret)")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", usage.Input.Name, usage.Input.TypeSpec(), value, strings.Join(usage.Plugins, ", "))
+	}
+
+	// Answers for inputs no loaded plugin declares, e.g. from a removed user plugin
+	unused := []string{}
+	for name := range answers {
+		if !known[name] {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+	for _, name := range unused {
+		fmt.Fprintf(w, "%s\t-\t%s\t%s\n", name, answers[name], dimStyle.Render("(unused)"))
+	}
+	w.Flush()
+	return 0
+}
+
+func runInputsSet(cmd *cobra.Command, args []string) int {
+	plugins, cleanup, err := itamae.LoadAllPlugins()
+	if err != nil {
+		itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+		return 1
+	}
+	defer cleanup()
+
+	if err := itamae.SetInputAnswer(inputsFile, plugins, args[0], args[1]); err != nil {
+		itamae.Logger.Errorf("%v\n", err)
+		return 1
+	}
+	fmt.Printf("%s Saved %s to %s\n", successStyle.Render("✓"), args[0], pathStyle.Render(inputsFile))
+	return 0
+}
+
+func runInputsUnset(cmd *cobra.Command, args []string) {
+	if err := itamae.UnsetInputAnswers(inputsFile, args); err != nil {
+		itamae.Logger.Errorf("%v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s Removed %s from %s\n", successStyle.Render("✓"), strings.Join(args, ", "), pathStyle.Render(inputsFile))
+}
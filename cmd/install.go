@@ -1,32 +1,199 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
 	"github.com/yjmrobert/itamae/itamae"
 
 	"github.com/spf13/cobra"
 )
 
+var (
+	aptMaxAge   time.Duration
+	offline     bool
+	cacheDir    string
+	profilePath string
+	omakase     bool
+	inputFlags  []string
+	secretStore string
+	userMode    bool
+)
+
 var installCmd = &cobra.Command{
 	Use:   "install",
 	Short: "Install a custom set of software.",
+	Long: `Install a custom set of software.
+
+Without flags, prompts for a category and the tools to install. The "All"
+category lists every plugin grouped by category, with core and essentials
+pre-selected. With --profile, installs exactly the plugins listed in a
+profile file, using the versions it pins. With --omakase, installs the
+curated set of plugins marked OMAKASE without any prompts.
+
+With --user, nothing is run with sudo: only plugins that install into your
+home directory are offered, and the ones left out are listed with the reason.
+
+Use --input NAME=VALUE to answer a plugin's required input ahead of time;
+the value is validated against the input's type and pattern, and the input
+is not prompted for. Answers are remembered and pre-fill the prompts next
+time (see 'itamae inputs'); an inputs.toml next to the profile supplies team
+defaults.
+
+Secret inputs are masked when prompted and redacted from logs. With
+--secret-store keyring (the desktop Secret Service) or --secret-store file
+(an age file in ~/.config/itamae, encrypted with a passphrase that is asked
+for once per run or read from ITAMAE_SECRETS_PASSPHRASE), they are
+remembered and not asked for again. ITAMAE_SECRET_STORE sets the default.
+
+Examples:
+  itamae install
+  itamae install --omakase
+  itamae install --omakase --input GIT_USER_EMAIL=me@example.com
+  itamae install --profile team.yaml
+  itamae install --user
+  itamae install --offline --cache /media/usb/itamae`,
 	Run: func(cmd *cobra.Command, args []string) {
-		// First, prompt user to select category
-		category, err := itamae.SelectCategory()
+		inputs, err := itamae.ParseInputFlags(inputFlags)
 		if err != nil {
-			itamae.Logger.Errorf("Error selecting category: %v\n", err)
+			itamae.Logger.Errorf("%v\n", err)
 			return
 		}
 
-		plugins, cleanup, err := itamae.LoadPlugins(category)
+		store, err := itamae.OpenSecretStore(secretStore)
 		if err != nil {
-			itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+			itamae.Logger.Errorf("%v\n", err)
 			return
 		}
-		defer cleanup()
-		itamae.RunInstallTUI(plugins, category)
+		defaults, err := loadInputDefaults(profilePath)
+		if err != nil {
+			itamae.Logger.Errorf("%v\n", err)
+			return
+		}
+
+		var selected []itamae.ToolPlugin
+		nonInteractive := false
+
+		if omakase {
+			plugins, cleanup, err := itamae.LoadAllPlugins()
+			if err != nil {
+				itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+				return
+			}
+			defer cleanup()
+
+			selected = itamae.OmakasePlugins(plugins)
+			nonInteractive = true
+		} else if profilePath != "" {
+			profile, err := itamae.LoadProfile(profilePath)
+			if err != nil {
+				itamae.Logger.Errorf("Error loading profile: %v\n", err)
+				return
+			}
+
+			plugins, cleanup, err := itamae.LoadAllPlugins()
+			if err != nil {
+				itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+				return
+			}
+			defer cleanup()
+
+			selected, err = profile.Resolve(plugins)
+			if err != nil {
+				itamae.Logger.Errorf("Error resolving profile: %v\n", err)
+				return
+			}
+			fmt.Printf("Installing %d packages from profile %s\n", len(selected), profile.Name)
+		} else {
+			// First, prompt user to select category
+			category, err := itamae.SelectCategory()
+			if err != nil {
+				itamae.Logger.Errorf("Error selecting category: %v\n", err)
+				return
+			}
+
+			plugins, cleanup, err := itamae.LoadPlugins(category)
+			if err != nil {
+				itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+				return
+			}
+			defer cleanup()
+			if userMode {
+				plugins = userSpaceOnly(plugins)
+			}
+
+			selected = itamae.SelectPlugins(plugins, category)
+			if len(selected) == 0 {
+				fmt.Println("No plugins selected. Exiting.")
+				return
+			}
+			if category == "core" || category == "essentials" {
+				fmt.Printf("Installing %d %s packages\n", len(selected), category)
+			}
+			nonInteractive = category == itamae.CategoryOmakase
+		}
+
+		if userMode && (omakase || profilePath != "") {
+			selected = userSpaceOnly(selected)
+		}
+		if len(selected) == 0 {
+			fmt.Println("No plugins to install. Exiting.")
+			return
+		}
+
+		err = itamae.RunInstallTUI(selected, itamae.InstallOptions{
+			AptMaxAge:      aptMaxAge,
+			Offline:        offline,
+			CacheDir:       cacheDir,
+			NonInteractive: nonInteractive,
+			Inputs:         inputs,
+			SecretStore:    store,
+			InputDefaults:  defaults,
+			InputsFile:     itamae.InputsFile(),
+			UserMode:       userMode,
+		})
+		if err != nil {
+			itamae.Logger.Errorf("%v\n", err)
+		}
 	},
 }
 
 func init() {
+	installCmd.Flags().DurationVar(&aptMaxAge, "apt-max-age", itamae.DefaultAptMaxAge, "Refresh APT package lists older than this")
+	installCmd.Flags().BoolVar(&offline, "offline", false, "Install only from the offline cache")
+	installCmd.Flags().StringVar(&cacheDir, "cache", itamae.DefaultCacheDir(), "Offline cache directory")
+	installCmd.Flags().StringVarP(&profilePath, "profile", "p", "", "Install the plugins listed in a profile file")
+	installCmd.Flags().BoolVar(&omakase, "omakase", false, "Install the curated omakase set without prompts")
+	installCmd.Flags().StringArrayVar(&inputFlags, "input", nil, "Answer a required input as NAME=VALUE (repeatable)")
+	installCmd.Flags().StringVar(&secretStore, "secret-store", os.Getenv("ITAMAE_SECRET_STORE"), "Remember secret inputs in the keyring or a passphrase-encrypted file (keyring|file)")
+	installCmd.Flags().BoolVar(&userMode, "user", false, "Install only plugins that don't need root, without sudo")
+	installCmd.MarkFlagsMutuallyExclusive("omakase", "profile")
 	rootCmd.AddCommand(installCmd)
 }
+
+// userSpaceOnly drops the plugins that need root, explaining which were left
+// out and why.
+func userSpaceOnly(plugins []itamae.ToolPlugin) []itamae.ToolPlugin {
+	kept, excluded := itamae.UserSpacePlugins(plugins)
+	if len(excluded) == 0 {
+		return kept
+	}
+
+	reasons := []string{}
+	byReason := map[string][]string{}
+	for _, e := range excluded {
+		if _, ok := byReason[e.Reason]; !ok {
+			reasons = append(reasons, e.Reason)
+		}
+		byReason[e.Reason] = append(byReason[e.Reason], e.Plugin.ID)
+	}
+
+	fmt.Printf("%s Skipping %d plugin(s) that need root in --user mode:\n", warningStyle.Render("⚠"), len(excluded))
+	for _, reason := range reasons {
+		fmt.Printf("  %s: %s\n", reason, dimStyle.Render(strings.Join(byReason[reason], ", ")))
+	}
+	fmt.Println()
+	return kept
+}
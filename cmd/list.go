@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/yjmrobert/itamae/itamae"
+)
+
+var (
+	listCategory  string
+	listMethod    string
+	listOmakase   bool
+	listInstalled bool
+	listJSON      bool
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available plugins",
+	Long: `List the plugins in the catalog.
+
+Examples:
+  itamae list                        # Every plugin
+  itamae list --category core        # Only core plugins
+  itamae list --method binary        # Only binary installers
+  itamae list --omakase              # Only the curated omakase set
+  itamae list --installed            # Only plugins installed on this system
+  itamae list --json                 # Machine-readable output`,
+	Args: cobra.NoArgs,
+	Run:  exitWith(runList),
+}
+
+func init() {
+	listCmd.Flags().StringVar(&listCategory, "category", "", "Only list plugins in this category (core, essentials, unverified, user)")
+	listCmd.Flags().StringVar(&listMethod, "method", "", "Only list plugins with this install method (apt, binary, manual)")
+	listCmd.Flags().BoolVar(&listOmakase, "omakase", false, "Only list the curated omakase set")
+	listCmd.Flags().BoolVar(&listInstalled, "installed", false, "Only list installed plugins")
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "Output the list as JSON")
+	rootCmd.AddCommand(listCmd)
+}
+
+func runList(cmd *cobra.Command, args []string) int {
+	all, cleanup, err := itamae.LoadAllPlugins()
+	if err != nil {
+		itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+		return 1
+	}
+	defer cleanup()
+
+	plugins := itamae.FilterPlugins(all, itamae.PluginFilter{
+		Category:  listCategory,
+		Method:    listMethod,
+		Omakase:   listOmakase,
+		Installed: listInstalled,
+	})
+
+	if listJSON {
+		infos := []itamae.PluginInfo{}
+		for _, p := range plugins {
+			infos = append(infos, itamae.NewPluginInfo(p))
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(infos); err != nil {
+			itamae.Logger.Errorf("Error encoding plugin list: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if len(plugins) == 0 {
+		fmt.Printf("%s No plugins match\n", warningStyle.Render("⚠"))
+		return 0
+	}
+
+	fmt.Println(headerStyle.Render(fmt.Sprintf("📋 %d plugin(s):", len(plugins))))
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCATEGORY\tMETHOD\tOMAKASE\tDESCRIPTION")
+	for _, p := range plugins {
+		curated := ""
+		if p.Omakase {
+			curated = "★"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", p.ID, p.Category, p.InstallMethod, curated, p.Description)
+	}
+	w.Flush()
+
+	fmt.Println()
+	fmt.Printf("%s Run 'itamae info <id>' for details\n", dimStyle.Render("Tip:"))
+	return 0
+}
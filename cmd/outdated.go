@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/yjmrobert/itamae/itamae"
+)
+
+var outdatedJSON bool
+
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated [plugin-id...]",
+	Short: "Show installed tools with newer versions available",
+	Long: `Show the installed and latest available version of each installed plugin.
+
+APT plugins report the candidate version from 'apt-cache policy'. Other plugins
+report their script's 'version' output and the result of their LATEST_CMD.
+
+Examples:
+  itamae outdated                # Table of all installed plugins
+  itamae outdated kubectl rust   # Only specific plugins
+  itamae outdated --json         # Machine-readable output`,
+	Run: exitWith(runOutdated),
+}
+
+func init() {
+	outdatedCmd.Flags().BoolVar(&outdatedJSON, "json", false, "Output the report as JSON")
+	rootCmd.AddCommand(outdatedCmd)
+}
+
+func runOutdated(cmd *cobra.Command, args []string) int {
+	all, cleanup, err := itamae.LoadAllPlugins()
+	if err != nil {
+		itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+		return 1
+	}
+	defer cleanup()
+
+	var plugins []itamae.ToolPlugin
+	if len(args) > 0 {
+		plugins, err = itamae.FindPlugins(all, args)
+		if err != nil {
+			itamae.Logger.Errorf("Error selecting plugins: %v\n", err)
+			return 1
+		}
+	} else {
+		plugins = itamae.InstalledPlugins(all)
+	}
+
+	reports := itamae.CheckOutdated(plugins)
+
+	if outdatedJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(reports); err != nil {
+			itamae.Logger.Errorf("Error encoding report: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if len(reports) == 0 {
+		fmt.Printf("%s No installed plugins found\n", warningStyle.Render("⚠"))
+		return 0
+	}
+
+	fmt.Println(headerStyle.Render(fmt.Sprintf("📋 Versions of %d installed plugin(s):", len(reports))))
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PLUGIN\tMETHOD\tINSTALLED\tLATEST\tPINNED\tSTATUS")
+	outdated := 0
+	for _, r := range reports {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.ID, r.Method, orDash(r.Installed), orDash(r.Latest), orDash(r.Pinned), renderVersionStatus(r.Status))
+		if r.Status == itamae.VersionOutdated {
+			outdated++
+		}
+	}
+	w.Flush()
+
+	fmt.Println()
+	if outdated > 0 {
+		fmt.Printf("%s Upgrade with: itamae upgrade\n", dimStyle.Render("Tip:"))
+	}
+	return 0
+}
+
+func renderVersionStatus(status string) string {
+	switch status {
+	case itamae.VersionUpToDate:
+		return successStyle.Render(status)
+	case itamae.VersionOutdated:
+		return warningStyle.Render(status)
+	default:
+		return dimStyle.Render(status)
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/yjmrobert/itamae/itamae"
+)
+
+var (
+	lintShellcheck bool
+
+	newMethod      string
+	newName        string
+	newDescription string
+	newSetupRepo   bool
+	newPostInstall bool
+	newDir         string
+	newForce       bool
+
+	migrateKeepHeader bool
+	migrateForce      bool
+)
+
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Tools for plugin authors",
+}
+
+var pluginLintCmd = &cobra.Command{
+	Use:   "lint [path]",
+	Short: "Check plugin scripts for mistakes",
+	Long: `Check plugin scripts before they reach an install run.
+
+Lint parses the metadata strictly, checks the router against the declared
+functions (install, remove and check are required; REPO_SETUP and
+POST_INSTALL need their functions and router cases), and runs 'bash -n'.
+With --shellcheck, shellcheck is run as well when it is installed.
+
+The path may be a script or a directory of scripts. Without a path, the
+plugins built into itamae are checked. Exits non-zero on errors.
+
+Examples:
+  itamae plugin lint
+  itamae plugin lint ./my-tool.sh
+  itamae plugin lint itamae/scripts --shellcheck`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runPluginLint,
+}
+
+var pluginNewCmd = &cobra.Command{
+	Use:   "new <plugin-id>",
+	Short: "Generate a new plugin script",
+	Long: `Generate a plugin script with the METADATA header and the standard
+install/remove/check router, ready to fill in.
+
+Scripts are written to the user plugin directory (~/.config/itamae/plugins,
+or $ITAMAE_PLUGIN_DIR), where itamae picks them up as the "user" category.
+A pluginAssertions entry for main_test.go is printed for contributors.
+
+Examples:
+  itamae plugin new mytool --method apt
+  itamae plugin new mytool --method apt --setup-repo --post-install
+  itamae plugin new mytool --method binary --dir itamae/scripts/unverified`,
+	Args: cobra.ExactArgs(1),
+	Run:  runPluginNew,
+}
+
+var pluginMigrateCmd = &cobra.Command{
+	Use:   "migrate <path>...",
+	Short: "Convert comment headers into YAML manifests",
+	Long: `Convert the METADATA comment header of plugin scripts into sidecar
+manifests (<id>.yaml next to the script). The metadata keys are removed from
+the header unless --keep-header is given. Paths may be scripts or directories.
+
+Examples:
+  itamae plugin migrate ~/.config/itamae/plugins/mytool.sh
+  itamae plugin migrate ~/.config/itamae/plugins
+  itamae plugin migrate itamae/scripts/unverified/zellij.sh --keep-header`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runPluginMigrate,
+}
+
+func init() {
+	pluginMigrateCmd.Flags().BoolVar(&migrateKeepHeader, "keep-header", false, "Leave the comment header in the script")
+	pluginMigrateCmd.Flags().BoolVar(&migrateForce, "force", false, "Overwrite existing manifests")
+	pluginCmd.AddCommand(pluginMigrateCmd)
+
+	pluginNewCmd.Flags().StringVar(&newMethod, "method", "apt", "Install method (apt, binary, manual)")
+	pluginNewCmd.Flags().StringVar(&newName, "name", "", "Display name (defaults to the plugin ID)")
+	pluginNewCmd.Flags().StringVar(&newDescription, "description", "", "Short description")
+	pluginNewCmd.Flags().BoolVar(&newSetupRepo, "setup-repo", false, "Add a setup_repo skeleton (apt only)")
+	pluginNewCmd.Flags().BoolVar(&newPostInstall, "post-install", false, "Add a post_install skeleton")
+	pluginNewCmd.Flags().StringVar(&newDir, "dir", itamae.UserPluginDir(), "Directory to write the script to")
+	pluginNewCmd.Flags().BoolVar(&newForce, "force", false, "Overwrite an existing script")
+	pluginCmd.AddCommand(pluginNewCmd)
+
+	pluginLintCmd.Flags().BoolVar(&lintShellcheck, "shellcheck", false, "Also run shellcheck if it is installed")
+	pluginCmd.AddCommand(pluginLintCmd)
+	rootCmd.AddCommand(pluginCmd)
+}
+
+func runPluginLint(cmd *cobra.Command, args []string) {
+	opts := itamae.LintOptions{Shellcheck: lintShellcheck}
+
+	var issues []itamae.LintIssue
+	var err error
+	if len(args) > 0 {
+		issues, err = itamae.LintPath(args[0], opts)
+	} else {
+		issues, err = itamae.LintEmbedded(opts)
+	}
+	if err != nil {
+		itamae.Logger.Errorf("%v\n", err)
+		os.Exit(1)
+	}
+
+	errors := 0
+	for _, issue := range issues {
+		if issue.Severity == itamae.LintError {
+			errors++
+			fmt.Println(errorStyle.Render("✗ ") + issue.String())
+		} else {
+			fmt.Println(warningStyle.Render("⚠ ") + issue.String())
+		}
+	}
+
+	if len(issues) == 0 {
+		fmt.Printf("%s No problems found\n", successStyle.Render("✓"))
+		return
+	}
+
+	fmt.Printf("\n%d error(s), %d warning(s)\n", errors, len(issues)-errors)
+	if itamae.HasLintErrors(issues) {
+		os.Exit(1)
+	}
+}
+
+func runPluginNew(cmd *cobra.Command, args []string) {
+	opts := itamae.ScaffoldOptions{
+		ID:          args[0],
+		Method:      newMethod,
+		Name:        newName,
+		Description: newDescription,
+		SetupRepo:   newSetupRepo,
+		PostInstall: newPostInstall,
+	}
+
+	path, err := itamae.WritePluginScript(newDir, opts, newForce)
+	if err != nil {
+		itamae.Logger.Errorf("%v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s Created %s\n", successStyle.Render("✓"), pathStyle.Render(path))
+	fmt.Println()
+	fmt.Println(dimStyle.Render("Test assertion for pluginAssertions in itamae/main_test.go:"))
+	fmt.Printf("\t%s\n", itamae.AssertionStub(opts))
+	fmt.Println()
+	fmt.Printf("%s Fill in the TODOs, then run 'itamae plugin lint %s'\n", dimStyle.Render("Next:"), path)
+}
+
+func runPluginMigrate(cmd *cobra.Command, args []string) {
+	scripts := []string{}
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			itamae.Logger.Errorf("%v\n", err)
+			os.Exit(1)
+		}
+		if !info.IsDir() {
+			scripts = append(scripts, arg)
+			continue
+		}
+		matches, _ := filepath.Glob(filepath.Join(arg, "*.sh"))
+		scripts = append(scripts, matches...)
+	}
+
+	opts := itamae.MigrateOptions{KeepHeader: migrateKeepHeader, Force: migrateForce}
+	failed := 0
+	for _, script := range scripts {
+		manifest, err := itamae.MigrateScript(script, opts)
+		if err != nil {
+			failed++
+			fmt.Printf("%s %s: %v\n", errorStyle.Render("✗"), script, err)
+			continue
+		}
+		fmt.Printf("%s %s → %s\n", successStyle.Render("✓"), script, pathStyle.Render(manifest))
+	}
+
+	if failed > 0 {
+		fmt.Printf("\n%d of %d script(s) could not be migrated\n", failed, len(scripts))
+		os.Exit(1)
+	}
+}
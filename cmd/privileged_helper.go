@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yjmrobert/itamae/itamae"
+)
+
+var privilegedHelperCmd = &cobra.Command{
+	Use:    "privileged-helper",
+	Short:  "Perform whitelisted root operations for an itamae run",
+	Hidden: true,
+	Long: `Internal: itamae starts this under sudo once per run and sends it
+requests over stdin. Only a fixed set of operations is accepted (apt update,
+install and remove; writing APT sources and keyrings; installing into
+/usr/local/bin), and each one is logged to /var/log/itamae/audit.log.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if os.Geteuid() != 0 {
+			itamae.Logger.Errorf("privileged-helper must run as root\n")
+			os.Exit(1)
+		}
+		if err := itamae.ServePrivileged(os.Stdin, os.Stdout); err != nil {
+			itamae.Logger.Errorf("%v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(privilegedHelperCmd)
+}
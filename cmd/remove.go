@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"github.com/yjmrobert/itamae/itamae"
+
+	"github.com/spf13/cobra"
+)
+
+var removeCmd = &cobra.Command{
+	Use:   "remove <plugin-id>...",
+	Short: "Remove installed software.",
+	Long: `Remove one or more tools by plugin ID.
+
+APT repositories declared by the removed plugins are deleted as well,
+unless another installed plugin still uses them.
+
+Examples:
+  itamae remove gh
+  itamae remove java maven`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		plugins, cleanup, err := itamae.LoadAllPlugins()
+		if err != nil {
+			itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+			return
+		}
+		defer cleanup()
+
+		if err := itamae.RunRemove(plugins, args); err != nil {
+			itamae.Logger.Errorf("Error removing plugins: %v\n", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(removeCmd)
+}
@@ -21,6 +21,16 @@ func init() {
 	rootCmd.PersistentFlags().BoolP("version", "v", false, "Print version information")
 }
 
+// exitWith adapts a command that returns an exit code, so that its deferred
+// cleanups, like removing the extracted plugins, run before the process exits.
+func exitWith(run func(cmd *cobra.Command, args []string) int) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		if code := run(cmd, args); code != 0 {
+			os.Exit(code)
+		}
+	}
+}
+
 func Execute() {
 	// Check for version flag in os.Args before cobra processes it
 	for _, arg := range os.Args[1:] {
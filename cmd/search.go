@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/yjmrobert/itamae/itamae"
+)
+
+var searchJSON bool
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Fuzzy search plugins by name, description and tags",
+	Long: `Fuzzy search the plugin catalog. The query is matched against each
+plugin's ID, name, description and tags; results are ranked best first.
+
+Examples:
+  itamae search kube         # kubectl, kubecolor, helm, ...
+  itamae search "json cli"   # Multiple words are matched as one query
+  itamae search shell --json`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  exitWith(runSearch),
+}
+
+func init() {
+	searchCmd.Flags().BoolVar(&searchJSON, "json", false, "Output the results as JSON")
+	rootCmd.AddCommand(searchCmd)
+}
+
+func runSearch(cmd *cobra.Command, args []string) int {
+	all, cleanup, err := itamae.LoadAllPlugins()
+	if err != nil {
+		itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+		return 1
+	}
+	defer cleanup()
+
+	query := strings.Join(args, " ")
+	results := itamae.SearchPlugins(all, query)
+
+	if searchJSON {
+		infos := []itamae.PluginInfo{}
+		for _, r := range results {
+			infos = append(infos, itamae.NewPluginInfo(r.Plugin))
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(infos); err != nil {
+			itamae.Logger.Errorf("Error encoding search results: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("%s No plugins match %q\n", warningStyle.Render("⚠"), query)
+		return 0
+	}
+
+	fmt.Println(headerStyle.Render(fmt.Sprintf("🔍 %d plugin(s) matching %q:", len(results), query)))
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCATEGORY\tTAGS\tDESCRIPTION")
+	for _, r := range results {
+		p := r.Plugin
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.ID, p.Category, strings.Join(p.Tags, ","), p.Description)
+	}
+	w.Flush()
+	return 0
+}
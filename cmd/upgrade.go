@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yjmrobert/itamae/itamae"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade [plugin-id...]",
+	Short: "Upgrade installed software.",
+	Long: `Upgrade installed tools to their latest (or pinned) versions.
+
+Without arguments, upgrades every installed plugin. APT packages are upgraded
+in a single batch; other plugins run their script's upgrade command, or are
+reinstalled if they don't have one. The summary shows versions before and
+after the upgrade. With --user, only plugins that live in your home
+directory are upgraded, without sudo.
+
+Examples:
+  itamae upgrade                 # Upgrade everything that is installed
+  itamae upgrade kubectl rust    # Upgrade specific plugins
+  itamae upgrade --user          # Upgrade what doesn't need root`,
+	Run: func(cmd *cobra.Command, args []string) {
+		all, cleanup, err := itamae.LoadAllPlugins()
+		if err != nil {
+			itamae.Logger.Errorf("Error loading plugins: %v\n", err)
+			return
+		}
+		defer cleanup()
+
+		var plugins []itamae.ToolPlugin
+		if len(args) > 0 {
+			plugins, err = itamae.FindPlugins(all, args)
+			if err != nil {
+				itamae.Logger.Errorf("Error selecting plugins: %v\n", err)
+				return
+			}
+		} else {
+			fmt.Println("🔍 Checking installed plugins...")
+			plugins = itamae.InstalledPlugins(all)
+		}
+
+		if userMode {
+			plugins = userSpaceOnly(plugins)
+		}
+		if len(plugins) == 0 {
+			fmt.Println("Nothing to upgrade.")
+			return
+		}
+
+		store, err := itamae.OpenSecretStore(secretStore)
+		if err != nil {
+			itamae.Logger.Errorf("%v\n", err)
+			return
+		}
+		defaults, err := loadInputDefaults("")
+		if err != nil {
+			itamae.Logger.Errorf("%v\n", err)
+			return
+		}
+
+		err = itamae.RunUpgradeTUI(plugins, itamae.InstallOptions{
+			AptMaxAge:     aptMaxAge,
+			SecretStore:   store,
+			InputDefaults: defaults,
+			InputsFile:    itamae.InputsFile(),
+			UserMode:      userMode,
+		})
+		if err != nil {
+			itamae.Logger.Errorf("%v\n", err)
+		}
+	},
+}
+
+func init() {
+	upgradeCmd.Flags().DurationVar(&aptMaxAge, "apt-max-age", itamae.DefaultAptMaxAge, "Refresh APT package lists older than this")
+	upgradeCmd.Flags().StringVar(&secretStore, "secret-store", os.Getenv("ITAMAE_SECRET_STORE"), "Read and remember secret inputs in the keyring or a passphrase-encrypted file (keyring|file)")
+	upgradeCmd.Flags().BoolVar(&userMode, "user", false, "Upgrade only plugins that don't need root, without sudo")
+	rootCmd.AddCommand(upgradeCmd)
+}
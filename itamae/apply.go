@@ -0,0 +1,189 @@
+package itamae
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ApplyPlan is what 'itamae apply' changes to converge the machine to a profile.
+type ApplyPlan struct {
+	Install     []ToolPlugin      // Listed in the profile but not installed
+	Upgrade     []ToolPlugin      // Installed at another version than the profile pins
+	Remove      []ToolPlugin      // Marked absent in the profile and installed
+	RemoveRepos []AptRepo         // Declarative repositories only the removed plugins use
+	Unchanged   int               // Listed plugins already as the profile wants them
+	Installed   map[string]string // Plugin ID -> version installed now, for Upgrade
+	ordered     []ToolPlugin      // Install and Upgrade, in profile order
+}
+
+// PlanApply compares the profile with the machine, the way Diff does, and
+// returns the changes that converge the machine to it. Plugins installed but
+// not listed are left alone unless the profile marks them absent.
+func PlanApply(plugins []ToolPlugin, profile Profile) (ApplyPlan, error) {
+	desired, err := profile.Resolve(plugins)
+	if err != nil {
+		return ApplyPlan{}, err
+	}
+	drifts, err := Diff(plugins, profile, nil)
+	if err != nil {
+		return ApplyPlan{}, err
+	}
+
+	plan := ApplyPlan{Installed: map[string]string{}}
+	kinds := map[string]string{}
+	for _, d := range drifts {
+		switch {
+		case d.Kind == DriftMissing:
+			kinds[d.ID] = d.Kind
+		case d.Kind == DriftVersion:
+			kinds[d.ID] = d.Kind
+			plan.Installed[d.ID] = d.Got
+		case d.Kind == DriftExtra && d.Want == "absent":
+			kinds[d.ID] = d.Kind
+		}
+	}
+
+	for _, p := range desired {
+		switch kinds[p.ID] {
+		case DriftMissing:
+			plan.Install = append(plan.Install, p)
+			plan.ordered = append(plan.ordered, p)
+		case DriftVersion:
+			plan.Upgrade = append(plan.Upgrade, p)
+			plan.ordered = append(plan.ordered, p)
+		default:
+			plan.Unchanged++
+		}
+	}
+
+	absent, err := profile.ResolveAbsent(plugins)
+	if err != nil {
+		return ApplyPlan{}, err
+	}
+	removing := map[string]bool{}
+	for _, p := range absent {
+		if kinds[p.ID] == DriftExtra {
+			plan.Remove = append(plan.Remove, p)
+			removing[p.ID] = true
+		}
+	}
+
+	// Keep repositories that plugins staying on the machine still use
+	for _, repo := range collectAptRepos(plan.Remove) {
+		if len(pluginsUsingRepo(plan.ordered, repo)) > 0 || len(installedRepoUsers(plugins, removing, repo)) > 0 {
+			continue
+		}
+		plan.RemoveRepos = append(plan.RemoveRepos, repo)
+	}
+	return plan, nil
+}
+
+// Empty reports whether the machine already matches the profile.
+func (a ApplyPlan) Empty() bool {
+	return len(a.Install) == 0 && len(a.Upgrade) == 0 && len(a.Remove) == 0
+}
+
+// Print shows the plan.
+func (a ApplyPlan) Print() {
+	fmt.Println("\n" + strings.Repeat("═", 60))
+	fmt.Println("📋 APPLY PLAN")
+	fmt.Println(strings.Repeat("═", 60))
+
+	if len(a.Install) > 0 {
+		fmt.Println("\n➕ Install:")
+		for _, p := range a.Install {
+			fmt.Printf("   • %s (%s)\n", p.Name, orLatest(p.Version))
+		}
+	}
+	if len(a.Upgrade) > 0 {
+		fmt.Println("\n⬆️  Change version:")
+		for _, p := range a.Upgrade {
+			fmt.Printf("   • %s: %s → %s\n", p.Name, a.Installed[p.ID], p.Version)
+		}
+	}
+	if len(a.Remove) > 0 {
+		fmt.Println("\n➖ Remove:")
+		for _, p := range a.Remove {
+			fmt.Printf("   • %s\n", p.Name)
+		}
+		for _, repo := range a.RemoveRepos {
+			fmt.Printf("   • APT repository %s\n", repo.URL)
+		}
+	}
+	if a.Unchanged > 0 {
+		fmt.Printf("\n✔️  %d plugin(s) already match the profile\n", a.Unchanged)
+	}
+
+	fmt.Println("\n" + strings.Repeat("═", 60))
+}
+
+// split separates the plugins installed as by 'itamae install' from the other
+// changes. APT version changes may be downgrades, which the install batch
+// refuses; other plugins change version by installing again with the pin.
+func (a ApplyPlan) split() ([]ToolPlugin, applyChanges) {
+	install := []ToolPlugin{}
+	changes := applyChanges{Remove: a.Remove, RemoveRepos: a.RemoveRepos}
+	for _, p := range a.ordered {
+		if _, changing := a.Installed[p.ID]; changing && p.InstallMethod == "apt" && p.PackageName != "" {
+			changes.Versions = append(changes.Versions, p)
+		} else {
+			install = append(install, p)
+		}
+	}
+	return install, changes
+}
+
+func orLatest(version string) string {
+	if version == "" {
+		return "latest"
+	}
+	return version
+}
+
+// RunApplyTUI carries out the plan with the TUI interface: the install phases
+// of 'itamae install' for the missing plugins and pinned versions, followed
+// by a removal phase. The plan is shown first; it is carried out after
+// confirmation, or right away with opts.NonInteractive. It returns an error
+// if the run could not start or any change failed.
+func RunApplyTUI(plan ApplyPlan, opts InstallOptions) error {
+	// Initialize debug logging
+	if err := InitDebugLog(); err != nil {
+		fmt.Printf("Warning: Could not initialize debug log: %v\n", err)
+	}
+	defer CloseDebugLog()
+
+	DebugLog("RunApplyTUI started: %d to install, %d to upgrade, %d to remove", len(plan.Install), len(plan.Upgrade), len(plan.Remove))
+	plan.Print()
+
+	// Request sudo access upfront
+	if err := ensureSudoAccess(); err != nil {
+		DebugLog("ERROR: Failed to obtain sudo access: %v", err)
+		return fmt.Errorf("failed to obtain sudo access, apply cancelled: %w", err)
+	}
+	keepAlive := keepSudoAlive(sudoKeepAliveInterval)
+	defer keepAlive.Stop()
+	defer stopPrivilegedHelper()
+
+	var requiredInputs map[string]string
+	var err error
+	if opts.NonInteractive {
+		requiredInputs, err = defaultInputs(plan.ordered, opts)
+	} else {
+		requiredInputs, err = gatherInputs(plan.ordered, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !opts.NonInteractive && !confirmAction("Apply these changes?", "This will install, change and remove the tools listed above.") {
+		fmt.Println("\nApply cancelled.")
+		return nil
+	}
+
+	install, changes := plan.split()
+	return runProgram(append(append([]ToolPlugin{}, plan.ordered...), plan.Remove...), keepAlive, func(p *tea.Program) error {
+		return processInstallTUI(p, install, changes, requiredInputs, opts)
+	})
+}
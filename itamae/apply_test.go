@@ -0,0 +1,112 @@
+package itamae
+
+import "testing"
+
+func TestPlanApply(t *testing.T) {
+	useTempInstallState(t)
+	dir := t.TempDir()
+	shared := AptRepo{URL: "https://apt.example.com", Suite: "stable", Components: []string{"main"}}
+	only := AptRepo{URL: "https://only.example.com", Suite: "stable", Components: []string{"main"}}
+	all := []ToolPlugin{
+		writeCheckPlugin(t, dir, "missing", false, ""),
+		writeCheckPlugin(t, dir, "pinned", true, "v1.29.0"),
+		writeCheckPlugin(t, dir, "current", true, "2.0.0"),
+		writeCheckPlugin(t, dir, "unwanted", true, "1.0.0"),
+		writeCheckPlugin(t, dir, "gone", false, ""),
+		writeCheckPlugin(t, dir, "untracked", true, "3.0.0"),
+		writeCheckPlugin(t, dir, "neighbour", true, "1.0.0"),
+		writeCheckPlugin(t, dir, "loner", true, "1.0.0"),
+	}
+	all[3].AptRepo = shared
+	all[6].AptRepo = shared
+	all[7].AptRepo = only
+
+	profile := Profile{
+		Name:     "team",
+		Plugins:  []string{"current", "pinned", "missing"},
+		Versions: map[string]string{"pinned": "v1.30.2", "missing": "1.0.0"},
+		Absent:   []string{"unwanted", "gone", "loner"},
+	}
+	plan, err := PlanApply(all, profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan.Install) != 1 || plan.Install[0].ID != "missing" || plan.Install[0].Version != "1.0.0" {
+		t.Errorf("expected the missing plugin with its pinned version, got %v", plan.Install)
+	}
+	if len(plan.Upgrade) != 1 || plan.Upgrade[0].ID != "pinned" || plan.Installed["pinned"] != "v1.29.0" {
+		t.Errorf("expected the outdated plugin to be upgraded, got %v %v", plan.Upgrade, plan.Installed)
+	}
+	if len(plan.Remove) != 2 || plan.Remove[0].ID != "unwanted" || plan.Remove[1].ID != "loner" {
+		t.Errorf("expected the installed absent plugins to be removed, got %v", plan.Remove)
+	}
+	if len(plan.RemoveRepos) != 1 || plan.RemoveRepos[0].Key() != only.Key() {
+		t.Errorf("expected only the repository no remaining plugin uses to be removed, got %v", plan.RemoveRepos)
+	}
+	if plan.Unchanged != 1 || plan.Empty() {
+		t.Errorf("expected one unchanged plugin and a non-empty plan, got %+v", plan)
+	}
+	if len(plan.ordered) != 2 || plan.ordered[0].ID != "pinned" || plan.ordered[1].ID != "missing" {
+		t.Errorf("expected installs and upgrades in profile order, got %v", plan.ordered)
+	}
+
+	drifts, err := Diff(all, profile, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extras := 0
+	for _, d := range drifts {
+		if d.Kind == DriftExtra {
+			extras++
+			if d.Want != "absent" {
+				t.Errorf("expected only absent plugins to be extra, got %+v", d)
+			}
+		}
+	}
+	if extras != 2 {
+		t.Errorf("expected 2 absent plugins reported as extra, got %+v", drifts)
+	}
+}
+
+func TestApplyPlanSplitsAptVersionChanges(t *testing.T) {
+	newTool := ToolPlugin{ID: "new", InstallMethod: "apt", PackageName: "new"}
+	aptPin := ToolPlugin{ID: "kubectl", InstallMethod: "apt", PackageName: "kubectl", Version: "1.29.0-1.1"}
+	scriptPin := ToolPlugin{ID: "go", InstallMethod: "script", Version: "1.22.0"}
+	gone := ToolPlugin{ID: "helm", InstallMethod: "apt", PackageName: "helm"}
+	plan := ApplyPlan{
+		Install:   []ToolPlugin{newTool},
+		Upgrade:   []ToolPlugin{aptPin, scriptPin},
+		Remove:    []ToolPlugin{gone},
+		Installed: map[string]string{"kubectl": "1.30.2-1.1", "go": "1.23.0"},
+		ordered:   []ToolPlugin{aptPin, newTool, scriptPin},
+	}
+
+	install, changes := plan.split()
+	if len(install) != 2 || install[0].ID != "new" || install[1].ID != "go" {
+		t.Errorf("expected new plugins and script version changes in the install run, got %v", install)
+	}
+	if len(changes.Versions) != 1 || changes.Versions[0].ID != "kubectl" {
+		t.Errorf("expected the APT version change outside the install batch, got %v", changes.Versions)
+	}
+	if len(changes.Remove) != 1 || changes.Remove[0].ID != "helm" {
+		t.Errorf("expected removals to be carried over, got %v", changes.Remove)
+	}
+}
+
+func TestPlanApplyConverged(t *testing.T) {
+	useTempInstallState(t)
+	dir := t.TempDir()
+	all := []ToolPlugin{
+		writeCheckPlugin(t, dir, "current", true, "2.0.0"),
+		writeCheckPlugin(t, dir, "gone", false, ""),
+	}
+
+	plan, err := PlanApply(all, Profile{Name: "team", Plugins: []string{"current"}, Absent: []string{"gone"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Empty() || plan.Unchanged != 1 {
+		t.Errorf("expected nothing to do, got %+v", plan)
+	}
+}
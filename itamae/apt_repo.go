@@ -0,0 +1,303 @@
+package itamae
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Locations where Itamae writes declarative repository configuration
+var (
+	aptSourcesDir = "/etc/apt/sources.list.d"
+	aptKeyringDir = "/etc/apt/keyrings"
+)
+
+// AptRepo describes a third-party APT repository declared through the
+// APT_REPO and APT_KEY_URL metadata keys.
+type AptRepo struct {
+	URL           string   // Base URI of the repository, may contain the {codename} placeholder
+	Suite         string   // Suite, may contain the {codename} placeholder
+	Components    []string // Components, e.g. "main"
+	Architectures []string // Architectures to use the repository for, may be the {arch} placeholder; empty means all
+	KeyURL        string   // URL of the signing key (armored or binary)
+}
+
+// parseAptRepo parses an APT_REPO value of the form
+// "[arch=<arch,...>] <url> <suite> <component...>".
+func parseAptRepo(value string) (AptRepo, error) {
+	fields := strings.Fields(value)
+
+	var archs []string
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "[") {
+		option, ok := strings.CutPrefix(strings.Trim(fields[0], "[]"), "arch=")
+		if !ok || !strings.HasSuffix(fields[0], "]") || option == "" {
+			return AptRepo{}, fmt.Errorf("APT_REPO only supports the [arch=<arch,...>] option, got %q", fields[0])
+		}
+		archs = strings.Split(option, ",")
+		fields = fields[1:]
+	}
+
+	if len(fields) < 3 {
+		return AptRepo{}, fmt.Errorf("APT_REPO must be '[arch=<arch>] <url> <suite> <component>', got %q", value)
+	}
+
+	if _, err := url.ParseRequestURI(fields[0]); err != nil {
+		return AptRepo{}, fmt.Errorf("invalid APT_REPO url %q: %w", fields[0], err)
+	}
+
+	return AptRepo{
+		URL:           fields[0],
+		Suite:         fields[1],
+		Components:    fields[2:],
+		Architectures: archs,
+	}, nil
+}
+
+// IsSet reports whether a repository has been declared.
+func (r AptRepo) IsSet() bool {
+	return r.URL != ""
+}
+
+// Key identifies the repository for de-duplication across plugins.
+func (r AptRepo) Key() string {
+	key := strings.Join(append([]string{r.URL, r.Suite}, r.Components...), " ")
+	if len(r.Architectures) > 0 {
+		key = "[arch=" + strings.Join(r.Architectures, ",") + "] " + key
+	}
+	return key
+}
+
+// Slug returns the file name stem used for the sources and keyring files. It
+// covers everything Key does, so repositories sharing a URL get their own files.
+func (r AptRepo) Slug() string {
+	parts := append([]string{r.location(), r.Suite}, r.Components...)
+	return slugify(strings.Join(append(parts, r.Architectures...), " "))
+}
+
+// location returns the host and path of the repository URL.
+func (r AptRepo) location() string {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return r.URL
+	}
+	return u.Host + u.Path
+}
+
+// slugify lowercases s and turns every run of other characters into one dash.
+func slugify(s string) string {
+	var b strings.Builder
+	lastDash := false
+	for _, c := range strings.ToLower(s) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			b.WriteRune(c)
+			lastDash = false
+		} else if !lastDash {
+			b.WriteRune('-')
+			lastDash = true
+		}
+	}
+
+	return "itamae-" + strings.Trim(b.String(), "-")
+}
+
+// SourcesPath returns the deb822 sources file path for this repository.
+func (r AptRepo) SourcesPath() string {
+	return filepath.Join(aptSourcesDir, r.Slug()+".sources")
+}
+
+// KeyringPath returns the keyring path. Armored keys use the .asc extension
+// so apt knows to de-armor them.
+func (r AptRepo) KeyringPath(armored bool) string {
+	ext := ".gpg"
+	if armored {
+		ext = ".asc"
+	}
+	return filepath.Join(aptKeyringDir, r.Slug()+ext)
+}
+
+// renderDeb822 renders the repository as a deb822 .sources stanza.
+func renderDeb822(r AptRepo, keyringPath string) string {
+	var b strings.Builder
+	b.WriteString("# Managed by itamae - do not edit\n")
+	b.WriteString("Types: deb\n")
+	fmt.Fprintf(&b, "URIs: %s\n", resolveCodename(r.URL))
+	fmt.Fprintf(&b, "Suites: %s\n", resolveCodename(r.Suite))
+	fmt.Fprintf(&b, "Components: %s\n", strings.Join(r.Components, " "))
+	if len(r.Architectures) > 0 {
+		fmt.Fprintf(&b, "Architectures: %s\n", resolveArch(strings.Join(r.Architectures, " ")))
+	}
+	if keyringPath != "" {
+		fmt.Fprintf(&b, "Signed-By: %s\n", keyringPath)
+	}
+	return b.String()
+}
+
+// resolveCodename expands the {codename} placeholder to the distribution codename.
+func resolveCodename(s string) string {
+	if !strings.Contains(s, "{codename}") {
+		return s
+	}
+	return strings.ReplaceAll(s, "{codename}", distroCodename())
+}
+
+// resolveArch expands the {arch} placeholder to the machine's dpkg architecture.
+func resolveArch(s string) string {
+	if !strings.Contains(s, "{arch}") {
+		return s
+	}
+	return strings.ReplaceAll(s, "{arch}", dpkgArchitecture())
+}
+
+// dpkgArchitecture returns the machine's dpkg architecture, e.g. "amd64".
+// Tests replace it.
+var dpkgArchitecture = func() string {
+	output, err := exec.Command("dpkg", "--print-architecture").Output()
+	if err != nil {
+		return runtime.GOARCH
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// osReleasePath is the os-release file describing the distribution.
+var osReleasePath = "/etc/os-release"
+
+// osRelease reads the key/value pairs from the os-release file.
+func osRelease() map[string]string {
+	values := map[string]string{}
+
+	file, err := os.Open(osReleasePath)
+	if err != nil {
+		return values
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok {
+			values[key] = strings.Trim(value, `"`)
+		}
+	}
+	return values
+}
+
+// distroCodename reads the distribution codename from /etc/os-release.
+func distroCodename() string {
+	values := osRelease()
+	if codename := values["VERSION_CODENAME"]; codename != "" {
+		return codename
+	}
+	return values["UBUNTU_CODENAME"]
+}
+
+// distroIDs returns the distribution ID followed by the IDs it is like,
+// e.g. ["ubuntu", "debian"] or ["pop", "ubuntu", "debian"].
+func distroIDs() []string {
+	values := osRelease()
+	ids := []string{}
+	if values["ID"] != "" {
+		ids = append(ids, values["ID"])
+	}
+	return append(ids, strings.Fields(values["ID_LIKE"])...)
+}
+
+// collectAptRepos returns the unique declarative repositories used by the plugins,
+// in the order they are first declared.
+func collectAptRepos(plugins []ToolPlugin) []AptRepo {
+	seen := make(map[string]bool)
+	repos := []AptRepo{}
+	for _, p := range plugins {
+		if !p.AptRepo.IsSet() || seen[p.AptRepo.Key()] {
+			continue
+		}
+		seen[p.AptRepo.Key()] = true
+		repos = append(repos, p.AptRepo)
+	}
+	return repos
+}
+
+// pluginsUsingRepo returns the names of plugins that declare the given repository.
+func pluginsUsingRepo(plugins []ToolPlugin, repo AptRepo) []string {
+	names := []string{}
+	for _, p := range plugins {
+		if p.AptRepo.IsSet() && p.AptRepo.Key() == repo.Key() {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// fetchKey downloads a repository signing key.
+func fetchKey(keyURL string) ([]byte, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(keyURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download key %s: %w", keyURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download key %s: %s", keyURL, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// writeAptRepo writes the keyring and deb822 sources file for a repository.
+// It returns true if any file on disk was created or changed.
+func writeAptRepo(repo AptRepo) (bool, error) {
+	changed := false
+
+	keyringPath := ""
+	if repo.KeyURL != "" {
+		key, err := fetchKey(repo.KeyURL)
+		if err != nil {
+			return false, err
+		}
+		armored := bytes.HasPrefix(bytes.TrimSpace(key), []byte("-----BEGIN PGP"))
+		keyringPath = repo.KeyringPath(armored)
+
+		wrote, err := installRootFile(keyringPath, key)
+		if err != nil {
+			return false, err
+		}
+		changed = changed || wrote
+	}
+
+	wrote, err := installRootFile(repo.SourcesPath(), []byte(renderDeb822(repo, keyringPath)))
+	if err != nil {
+		return false, err
+	}
+
+	return changed || wrote, nil
+}
+
+// removeAptRepo deletes the sources and keyring files written for a repository.
+func removeAptRepo(repo AptRepo) error {
+	for _, path := range []string{repo.SourcesPath(), repo.KeyringPath(true), repo.KeyringPath(false)} {
+		if _, err := elevate(privilegedRequest{Op: opRemoveAptFile, Path: path}); err != nil {
+			return fmt.Errorf("failed to remove repository %s: %w", repo.URL, err)
+		}
+	}
+	return nil
+}
+
+// installRootFile writes content to a root-owned APT path with mode 0644
+// through the privileged helper. The file is left untouched if it already has
+// the same content.
+func installRootFile(dest string, content []byte) (bool, error) {
+	resp, err := elevate(privilegedRequest{Op: opWriteAptFile, Path: dest, Content: content})
+	if err != nil {
+		return false, fmt.Errorf("failed to install %s: %w", dest, err)
+	}
+	return resp.Changed, nil
+}
@@ -0,0 +1,140 @@
+package itamae
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseAptRepo(t *testing.T) {
+	repo, err := parseAptRepo("https://cli.github.com/packages stable main")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.URL != "https://cli.github.com/packages" || repo.Suite != "stable" || len(repo.Components) != 1 || repo.Components[0] != "main" {
+		t.Errorf("unexpected repo: %+v", repo)
+	}
+
+	if repo.Slug() != "itamae-cli-github-com-packages-stable-main" {
+		t.Errorf("unexpected slug: %s", repo.Slug())
+	}
+
+	if _, err := parseAptRepo("https://cli.github.com/packages stable"); err == nil {
+		t.Error("expected error for missing component")
+	}
+
+	repo, err = parseAptRepo("[arch=amd64,arm64] https://cli.github.com/packages stable main")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo.URL != "https://cli.github.com/packages" || strings.Join(repo.Architectures, ",") != "amd64,arm64" {
+		t.Errorf("unexpected repo: %+v", repo)
+	}
+	if _, err := parseAptRepo("[signed-by=/tmp/key.gpg] https://cli.github.com/packages stable main"); err == nil {
+		t.Error("expected error for an option other than arch")
+	}
+}
+
+func TestAptRepoSlugCoversKey(t *testing.T) {
+	stable := AptRepo{URL: "https://example.com/deb", Suite: "stable", Components: []string{"main"}}
+	testing := AptRepo{URL: "https://example.com/deb", Suite: "testing", Components: []string{"main"}}
+	amd64 := AptRepo{URL: "https://example.com/deb", Suite: "stable", Components: []string{"main"}, Architectures: []string{"amd64"}}
+
+	slugs := map[string]bool{stable.Slug(): true, testing.Slug(): true, amd64.Slug(): true}
+	if len(slugs) != 3 {
+		t.Errorf("expected repositories sharing a URL to get their own files, got %v", slugs)
+	}
+}
+
+func TestRenderDeb822(t *testing.T) {
+	repo := AptRepo{URL: "https://example.com/deb", Suite: "stable", Components: []string{"main", "contrib"}}
+	content := renderDeb822(repo, repo.KeyringPath(true))
+
+	for _, want := range []string{
+		"Types: deb\n",
+		"URIs: https://example.com/deb\n",
+		"Suites: stable\n",
+		"Components: main contrib\n",
+		"Signed-By: /etc/apt/keyrings/itamae-example-com-deb-stable-main-contrib.asc\n",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected sources file to contain %q, got:\n%s", want, content)
+		}
+	}
+}
+
+func TestRenderDeb822Architectures(t *testing.T) {
+	original := dpkgArchitecture
+	dpkgArchitecture = func() string { return "arm64" }
+	t.Cleanup(func() { dpkgArchitecture = original })
+
+	repo, err := parseAptRepo("[arch={arch}] https://cli.github.com/packages stable main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content := renderDeb822(repo, ""); !strings.Contains(content, "Architectures: arm64\n") {
+		t.Errorf("expected the machine architecture, got:\n%s", content)
+	}
+	if content := renderDeb822(AptRepo{URL: "https://example.com/deb", Suite: "stable", Components: []string{"main"}}, ""); strings.Contains(content, "Architectures:") {
+		t.Errorf("expected no architecture restriction, got:\n%s", content)
+	}
+}
+
+func TestRenderDeb822Codename(t *testing.T) {
+	original := osReleasePath
+	osReleasePath = filepath.Join(t.TempDir(), "os-release")
+	t.Cleanup(func() { osReleasePath = original })
+	if err := os.WriteFile(osReleasePath, []byte("ID=ubuntu\nVERSION_CODENAME=noble\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := parseAptRepo("https://example.com/ubuntu-{codename}-prod {codename} main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := renderDeb822(repo, "")
+	if !strings.Contains(content, "URIs: https://example.com/ubuntu-noble-prod\n") || !strings.Contains(content, "Suites: noble\n") {
+		t.Errorf("expected the codename in the URI and suite, got:\n%s", content)
+	}
+}
+
+func TestCollectAptReposDeduplicates(t *testing.T) {
+	shared := AptRepo{URL: "https://example.com/deb", Suite: "stable", Components: []string{"main"}}
+	mockPlugins := []ToolPlugin{
+		{ID: "a", Name: "A", InstallMethod: "apt", AptRepo: shared},
+		{ID: "b", Name: "B", InstallMethod: "apt", AptRepo: shared},
+		{ID: "c", Name: "C", InstallMethod: "apt", RepoSetup: "setup_repo"},
+	}
+
+	repos := collectAptRepos(mockPlugins)
+	if len(repos) != 1 {
+		t.Fatalf("expected 1 repository, got %d", len(repos))
+	}
+
+	users := pluginsUsingRepo(mockPlugins, repos[0])
+	if strings.Join(users, ",") != "A,B" {
+		t.Errorf("expected repository to be used by A and B, got %v", users)
+	}
+}
+
+func TestDeclarativeRepoMetadata(t *testing.T) {
+	plugin, err := parseMetadata(`#!/bin/bash
+# NAME: GitHub CLI
+# DESCRIPTION: The official GitHub command-line tool.
+# INSTALL_METHOD: apt
+# PACKAGE_NAME: gh
+# APT_REPO: https://cli.github.com/packages stable main
+# APT_KEY_URL: https://cli.github.com/packages/githubcli-archive-keyring.gpg
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !plugin.AptRepo.IsSet() || plugin.AptRepo.KeyURL != "https://cli.github.com/packages/githubcli-archive-keyring.gpg" {
+		t.Errorf("unexpected repo: %+v", plugin.AptRepo)
+	}
+
+	if _, err := parseMetadata("# APT_KEY_URL: https://example.com/key.gpg\n"); err == nil {
+		t.Error("expected error for APT_KEY_URL without APT_REPO")
+	}
+}
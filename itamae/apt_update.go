@@ -0,0 +1,102 @@
+package itamae
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// DefaultAptMaxAge is how old package lists may get before they are refreshed.
+const DefaultAptMaxAge = 24 * time.Hour
+
+// Locations apt reads sources from and stores downloaded package lists in
+var (
+	aptListsDir       = "/var/lib/apt/lists"
+	aptSourcesList    = "/etc/apt/sources.list"
+	aptListsSkipNames = map[string]bool{"lock": true, "partial": true, "auxfiles": true}
+)
+
+// Files touched when package lists are refreshed. The lists themselves keep
+// the server's Last-Modified time, so their mtimes say nothing about when
+// they were fetched.
+var (
+	aptUpdateStamp = "/var/lib/apt/periodic/update-success-stamp"
+	aptPkgCache    = "/var/cache/apt/pkgcache.bin"
+)
+
+// newestModTime returns the most recent modification time of the regular files
+// in dir, ignoring the names in skip. ok is false if no files were found.
+func newestModTime(dir string, skip map[string]bool) (time.Time, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var newest time.Time
+	found := false
+	for _, entry := range entries {
+		if entry.IsDir() || skip[entry.Name()] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if !found || info.ModTime().After(newest) {
+			newest = info.ModTime()
+			found = true
+		}
+	}
+
+	return newest, found
+}
+
+// lastAptUpdate estimates when package lists were last refreshed: the newest
+// of the update-success stamp (written by the privileged helper and by
+// Ubuntu's update hooks), the lists directory and apt's package cache.
+func lastAptUpdate() time.Time {
+	var last time.Time
+	for _, path := range []string{aptUpdateStamp, aptListsDir, aptPkgCache} {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+	return last
+}
+
+// needsAptUpdate decides whether package lists must be refreshed before installing.
+// Lists are refreshed if they are missing, were last refreshed more than maxAge
+// ago, or before any APT source file changed (i.e. a repository was added since
+// the last update). The returned string explains the decision for logging.
+func needsAptUpdate(maxAge time.Duration) (bool, string) {
+	if maxAge <= 0 {
+		return true, "--apt-max-age is 0"
+	}
+
+	if _, ok := newestModTime(aptListsDir, aptListsSkipNames); !ok {
+		return true, "package lists are empty"
+	}
+	updated := lastAptUpdate()
+
+	sourcesTime, _ := newestModTime(aptSourcesDir, nil)
+	if info, err := os.Stat(aptSourcesList); err == nil && info.ModTime().After(sourcesTime) {
+		sourcesTime = info.ModTime()
+	}
+	if sourcesTime.After(updated) {
+		return true, "APT sources changed since the last update"
+	}
+
+	age := time.Since(updated).Round(time.Minute)
+	if age > maxAge {
+		return true, fmt.Sprintf("package lists are %s old", age)
+	}
+
+	return false, fmt.Sprintf("package lists are up to date (refreshed %s ago)", age)
+}
+
+// updatePackageLists refreshes package lists through the privileged helper
+// and returns the output of nala or apt-get.
+func updatePackageLists() (string, error) {
+	resp, err := elevate(privilegedRequest{Op: opAptUpdate})
+	return resp.Output, err
+}
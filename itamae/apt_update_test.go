@@ -0,0 +1,125 @@
+package itamae
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// withAptDirs points the APT locations at temp directories for the duration of a test.
+func withAptDirs(t *testing.T) (string, string) {
+	t.Helper()
+
+	listsDir := t.TempDir()
+	sourcesDir := t.TempDir()
+	stateDir := t.TempDir()
+
+	origLists, origSources, origList := aptListsDir, aptSourcesDir, aptSourcesList
+	origStamp, origCache := aptUpdateStamp, aptPkgCache
+	aptListsDir, aptSourcesDir, aptSourcesList = listsDir, sourcesDir, filepath.Join(sourcesDir, "missing.list")
+	aptUpdateStamp, aptPkgCache = filepath.Join(stateDir, "update-success-stamp"), filepath.Join(stateDir, "pkgcache.bin")
+	t.Cleanup(func() {
+		aptListsDir, aptSourcesDir, aptSourcesList = origLists, origSources, origList
+		aptUpdateStamp, aptPkgCache = origStamp, origCache
+	})
+
+	return listsDir, sourcesDir
+}
+
+// fetchLists writes a package list carrying the server's Last-Modified time,
+// as apt does, into listsDir as if it had been fetched at fetched.
+func fetchLists(t *testing.T, listsDir string, lastModified, fetched time.Time) {
+	t.Helper()
+	touch(t, filepath.Join(listsDir, "example_Packages"), lastModified)
+	if err := os.Chtimes(listsDir, fetched, fetched); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func touch(t *testing.T, path string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNeedsAptUpdate(t *testing.T) {
+	t.Run("empty lists", func(t *testing.T) {
+		listsDir, _ := withAptDirs(t)
+		touch(t, filepath.Join(listsDir, "lock"), time.Now())
+
+		if update, _ := needsAptUpdate(time.Hour); !update {
+			t.Error("expected update when package lists are empty")
+		}
+	})
+
+	t.Run("fresh lists", func(t *testing.T) {
+		listsDir, sourcesDir := withAptDirs(t)
+		touch(t, filepath.Join(sourcesDir, "itamae-example.sources"), time.Now().Add(-2*time.Hour))
+		fetchLists(t, listsDir, time.Now().Add(-time.Minute), time.Now().Add(-time.Minute))
+
+		if update, reason := needsAptUpdate(time.Hour); update {
+			t.Errorf("expected update to be skipped, got: %s", reason)
+		}
+	})
+
+	t.Run("old lists fetched recently", func(t *testing.T) {
+		listsDir, sourcesDir := withAptDirs(t)
+		touch(t, filepath.Join(sourcesDir, "itamae-example.sources"), time.Now().Add(-72*time.Hour))
+		fetchLists(t, listsDir, time.Now().Add(-48*time.Hour), time.Now().Add(-48*time.Hour))
+		touch(t, aptUpdateStamp, time.Now().Add(-time.Minute))
+
+		if update, reason := needsAptUpdate(24 * time.Hour); update {
+			t.Errorf("expected the update stamp to count as a refresh, got: %s", reason)
+		}
+	})
+
+	t.Run("zero max age", func(t *testing.T) {
+		listsDir, _ := withAptDirs(t)
+		touch(t, filepath.Join(listsDir, "example_Packages"), time.Now())
+
+		if update, _ := needsAptUpdate(0); !update {
+			t.Error("expected --apt-max-age 0 to always refresh")
+		}
+	})
+
+	t.Run("stale lists", func(t *testing.T) {
+		listsDir, _ := withAptDirs(t)
+		fetchLists(t, listsDir, time.Now().Add(-72*time.Hour), time.Now().Add(-48*time.Hour))
+		touch(t, aptUpdateStamp, time.Now().Add(-48*time.Hour))
+
+		if update, _ := needsAptUpdate(24 * time.Hour); !update {
+			t.Error("expected update when package lists are stale")
+		}
+	})
+
+	t.Run("new sources", func(t *testing.T) {
+		listsDir, sourcesDir := withAptDirs(t)
+		fetchLists(t, listsDir, time.Now().Add(-48*time.Hour), time.Now().Add(-time.Hour))
+		touch(t, filepath.Join(sourcesDir, "itamae-example.sources"), time.Now())
+
+		if update, _ := needsAptUpdate(24 * time.Hour); !update {
+			t.Error("expected update when sources are newer than package lists")
+		}
+	})
+}
+
+func TestAptUpdateWritesStamp(t *testing.T) {
+	withMockCommands(t)
+	root := useInProcessHelper(t)
+
+	if _, err := updatePackageLists(); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(root, "var", "lib", "apt", "periodic", "update-success-stamp"))
+	if err != nil {
+		t.Fatalf("expected the update stamp to be written: %v", err)
+	}
+	if time.Since(info.ModTime()) > time.Minute {
+		t.Errorf("expected a fresh stamp, got %v", info.ModTime())
+	}
+}
@@ -0,0 +1,455 @@
+package itamae
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const cacheManifestFile = "manifest.json"
+
+// Artifact is a file a binary plugin downloads during installation, declared
+// with the ARTIFACT metadata key so it can be cached for offline installs.
+type Artifact struct {
+	Name   string // File name inside the artifact directory
+	URL    string // Download URL
+	SHA256 string // Expected checksum (optional)
+}
+
+// CacheManifest records which cached files belong to which plugin.
+type CacheManifest struct {
+	Plugins map[string]CachedPlugin `json:"plugins"`
+}
+
+// CachedPlugin lists the cached files for a single plugin, relative to the cache directory.
+type CachedPlugin struct {
+	Debs      []string `json:"debs,omitempty"`
+	Artifacts []string `json:"artifacts,omitempty"`
+}
+
+// DefaultCacheDir returns the default offline cache location (~/.cache/itamae).
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "itamae-cache")
+	}
+	return filepath.Join(dir, "itamae")
+}
+
+// parseArtifact parses an ARTIFACT value of the form "<file> <url> [sha256:<hex>]".
+func parseArtifact(value string) (Artifact, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 && len(fields) != 3 {
+		return Artifact{}, fmt.Errorf("ARTIFACT must be '<file> <url> [sha256:<hex>]', got %q", value)
+	}
+
+	artifact := Artifact{Name: fields[0], URL: fields[1]}
+	if len(fields) == 3 {
+		sum, ok := strings.CutPrefix(fields[2], "sha256:")
+		if !ok {
+			return Artifact{}, fmt.Errorf("ARTIFACT checksum must be 'sha256:<hex>', got %q", fields[2])
+		}
+		artifact.SHA256 = sum
+	}
+	return artifact, artifact.validate()
+}
+
+// validate checks the artifact's file name and checksum format.
+func (a Artifact) validate() error {
+	if a.Name == "" || a.URL == "" {
+		return fmt.Errorf("artifact needs a file name and a url")
+	}
+	if strings.ContainsRune(a.Name, '/') {
+		return fmt.Errorf("ARTIFACT file name %q must not contain '/'", a.Name)
+	}
+	if a.SHA256 != "" {
+		if _, err := hex.DecodeString(a.SHA256); err != nil || len(a.SHA256) != sha256.Size*2 {
+			return fmt.Errorf("ARTIFACT checksum for %s is not a sha256 hex digest", a.Name)
+		}
+	}
+	return nil
+}
+
+// verifyChecksum compares a downloaded file against the artifact's checksum.
+func (a Artifact) verifyChecksum(path string) error {
+	if a.SHA256 == "" {
+		return nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(sum, a.SHA256) {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", a.Name, a.SHA256, sum)
+	}
+	return nil
+}
+
+func debCacheDir(cacheDir string) string {
+	return filepath.Join(cacheDir, "debs")
+}
+
+// artifactDir returns the directory holding a plugin's cached artifacts.
+func artifactDir(cacheDir, pluginID string) string {
+	return filepath.Join(cacheDir, "artifacts", pluginID)
+}
+
+// loadCacheManifest reads the manifest from a cache directory. A missing
+// manifest yields an empty one.
+func loadCacheManifest(cacheDir string) (CacheManifest, error) {
+	manifest := CacheManifest{Plugins: map[string]CachedPlugin{}}
+
+	data, err := os.ReadFile(filepath.Join(cacheDir, cacheManifestFile))
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return manifest, fmt.Errorf("failed to read cache manifest: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("failed to parse cache manifest: %w", err)
+	}
+	if manifest.Plugins == nil {
+		manifest.Plugins = map[string]CachedPlugin{}
+	}
+
+	return manifest, nil
+}
+
+func (m CacheManifest) save(cacheDir string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cache manifest: %w", err)
+	}
+	return os.WriteFile(filepath.Join(cacheDir, cacheManifestFile), data, 0644)
+}
+
+// offlineSupport returns an error explaining why the plugin can't be installed
+// from the cache, or nil if it can.
+func (m CacheManifest) offlineSupport(plugin ToolPlugin) error {
+	entry, cached := m.Plugins[plugin.ID]
+
+	switch {
+	case plugin.InstallMethod == "apt":
+		if !cached || len(entry.Debs) == 0 {
+			return fmt.Errorf("no cached .deb files (run 'itamae cache fill %s')", plugin.ID)
+		}
+	case len(plugin.Artifacts) == 0:
+		return fmt.Errorf("installer downloads at install time and declares no ARTIFACT, so it can't run offline")
+	case !cached || len(entry.Artifacts) < len(plugin.Artifacts):
+		return fmt.Errorf("artifacts not cached (run 'itamae cache fill %s')", plugin.ID)
+	}
+
+	return nil
+}
+
+// debPaths returns the absolute paths of the cached .deb files for the plugins.
+func (m CacheManifest) debPaths(cacheDir string, plugins []ToolPlugin) []string {
+	seen := make(map[string]bool)
+	paths := []string{}
+	for _, p := range plugins {
+		for _, deb := range m.Plugins[p.ID].Debs {
+			if !seen[deb] {
+				seen[deb] = true
+				paths = append(paths, filepath.Join(cacheDir, deb))
+			}
+		}
+	}
+	return paths
+}
+
+// aptDebClosure returns the file names of the .deb files a package spec and
+// all of its dependencies download to, as if nothing were installed, so that
+// the cache holds everything a fresh machine of the same release needs.
+func aptDebClosure(spec string) ([]string, error) {
+	archives, err := os.MkdirTemp("", "itamae-closure-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(archives)
+	if err := os.Mkdir(filepath.Join(archives, "partial"), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	output, err := exec.Command("apt-get", "install", "--print-uris", "-qq",
+		"-o", "Dir::State::status=/dev/null", "-o", "Dir::Cache::archives="+archives, spec).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the dependencies of %s: %v\n%s", spec, err, output)
+	}
+	return parseAptURIs(string(output)), nil
+}
+
+// parseAptURIs reads the file names from 'apt-get --print-uris' output, whose
+// lines look like "'<url>' <file> <size> <hash>".
+func parseAptURIs(output string) []string {
+	debs := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && strings.HasPrefix(fields[0], "'") && strings.HasSuffix(fields[1], ".deb") {
+			debs = append(debs, fields[1])
+		}
+	}
+	sort.Strings(debs)
+	return debs
+}
+
+// debSpec turns a .deb file name from parseAptURIs, name_version_arch.deb
+// with the version's ':' escaped as %3a, into a name=version spec.
+func debSpec(file string) string {
+	name, rest, _ := strings.Cut(strings.TrimSuffix(file, ".deb"), "_")
+	version, _, _ := strings.Cut(rest, "_")
+	if unescaped, err := url.PathUnescape(version); err == nil {
+		version = unescaped
+	}
+	return name + "=" + version
+}
+
+// downloadDebs fetches the .deb files that aren't in dir yet with
+// 'apt-get download', which runs as the user and writes into the current
+// directory, so root never touches the cache.
+func downloadDebs(dir string, files []string) error {
+	specs := []string{}
+	for _, file := range files {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			specs = append(specs, debSpec(file))
+		}
+	}
+	if len(specs) == 0 {
+		return nil
+	}
+
+	cmd := exec.Command("apt-get", append([]string{"download", "-q"}, specs...)...)
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// withoutInstalledDebs drops cached .deb files whose package is already
+// installed, so an older cached dependency never asks apt for a downgrade.
+func withoutInstalledDebs(paths []string) []string {
+	kept := []string{}
+	for _, path := range paths {
+		name, _, _ := strings.Cut(filepath.Base(path), "_")
+		if installedAptVersion(name) == "" {
+			kept = append(kept, path)
+		}
+	}
+	return kept
+}
+
+// downloadFile fetches url into dest.
+func downloadFile(url, dest string) error {
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	return nil
+}
+
+// RunCacheFill downloads the .deb files and binary artifacts needed to install
+// the plugins into cacheDir, so that 'itamae install --offline' can use them.
+// Package lists older than aptMaxAge are refreshed first.
+func RunCacheFill(plugins []ToolPlugin, cacheDir string, aptMaxAge time.Duration) error {
+	fmt.Printf("\n📥 Filling offline cache in %s\n", cacheDir)
+
+	debDir := debCacheDir(cacheDir)
+	if err := os.MkdirAll(debDir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	manifest, err := loadCacheManifest(cacheDir)
+	if err != nil {
+		return err
+	}
+
+	aptPlugins := []ToolPlugin{}
+	otherPlugins := []ToolPlugin{}
+	for _, p := range plugins {
+		if p.InstallMethod == "apt" {
+			aptPlugins = append(aptPlugins, p)
+		} else {
+			otherPlugins = append(otherPlugins, p)
+		}
+	}
+
+	cached := []string{}
+	failed := []string{}
+
+	if len(aptPlugins) > 0 {
+		if err := ensureSudoAccess(); err != nil {
+			return fmt.Errorf("failed to obtain sudo access: %w", err)
+		}
+		keepAlive := keepSudoAlive(sudoKeepAliveInterval)
+		keepAlive.Notify(func(err error) { Logger.Warnf("%v", err) })
+		defer keepAlive.Stop()
+		defer stopPrivilegedHelper()
+
+		// Repositories and package lists are needed to resolve the .deb files
+		for _, repo := range collectAptRepos(aptPlugins) {
+			fmt.Printf("   • Configuring repository %s\n", repo.URL)
+			if _, err := writeAptRepo(repo); err != nil {
+				return err
+			}
+		}
+		for _, p := range aptPlugins {
+			if !p.AptRepo.IsSet() && p.RepoSetup != "" {
+				fmt.Printf("   • Setting up repository for %s\n", p.Name)
+				if err := executeScript(p, "setup_repo", nil); err != nil {
+					return fmt.Errorf("repository setup failed for %s: %w", p.Name, err)
+				}
+			}
+		}
+		if update, reason := needsAptUpdate(aptMaxAge); update {
+			fmt.Printf("\n📦 Updating package lists (%s)...\n", reason)
+			output, err := updatePackageLists()
+			fmt.Print(output)
+			if err != nil {
+				return fmt.Errorf("package list update failed: %w", err)
+			}
+		}
+
+		// Resolve each plugin's dependencies separately so shared files are
+		// recorded under every plugin that needs them
+		for _, p := range aptPlugins {
+			if p.PackageName == "" {
+				continue
+			}
+			fmt.Printf("\n📦 Downloading %s (%s)...\n", p.Name, aptPackageSpec(p))
+
+			closure, err := aptDebClosure(aptPackageSpec(p))
+			if err != nil {
+				Logger.Errorf("❌ %v\n", err)
+				failed = append(failed, p.Name)
+				continue
+			}
+
+			if err := downloadDebs(debDir, closure); err != nil {
+				Logger.Errorf("❌ Error downloading %s: %v\n", p.Name, err)
+				failed = append(failed, p.Name)
+				continue
+			}
+
+			debs := []string{}
+			missing := []string{}
+			for _, name := range closure {
+				if _, err := os.Stat(filepath.Join(debDir, name)); err != nil {
+					missing = append(missing, name)
+					continue
+				}
+				debs = append(debs, filepath.Join("debs", name))
+			}
+			if len(debs) == 0 || len(missing) > 0 {
+				Logger.Errorf("❌ %s is missing from the cache: %s\n", p.Name, strings.Join(missing, ", "))
+				failed = append(failed, p.Name)
+				continue
+			}
+
+			manifest.Plugins[p.ID] = CachedPlugin{Debs: debs}
+			cached = append(cached, p.Name)
+		}
+	}
+
+	for _, p := range otherPlugins {
+		if len(p.Artifacts) == 0 {
+			fmt.Printf("\n⚠️  %s declares no ARTIFACT and will not be installable offline\n", p.Name)
+			failed = append(failed, p.Name)
+			continue
+		}
+
+		fmt.Printf("\n📥 Downloading %s artifacts...\n", p.Name)
+		dir := artifactDir(cacheDir, p.ID)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create artifact directory: %w", err)
+		}
+
+		entry := CachedPlugin{}
+		ok := true
+		for _, artifact := range p.Artifacts {
+			url, err := expandVersion(artifact.URL, p)
+			if err != nil {
+				Logger.Errorf("❌ %v\n", err)
+				ok = false
+				break
+			}
+			fmt.Printf("   • %s\n", url)
+			dest := filepath.Join(dir, artifact.Name)
+			if err := downloadFile(url, dest); err != nil {
+				Logger.Errorf("❌ %v\n", err)
+				ok = false
+				break
+			}
+			if err := artifact.verifyChecksum(dest); err != nil {
+				os.Remove(dest)
+				Logger.Errorf("❌ %v\n", err)
+				ok = false
+				break
+			}
+			entry.Artifacts = append(entry.Artifacts, filepath.Join("artifacts", p.ID, artifact.Name))
+		}
+
+		if !ok {
+			failed = append(failed, p.Name)
+			continue
+		}
+		manifest.Plugins[p.ID] = entry
+		cached = append(cached, p.Name)
+	}
+
+	if err := manifest.save(cacheDir); err != nil {
+		return err
+	}
+
+	fmt.Println("\n" + strings.Repeat("═", 60))
+	fmt.Println("📊 CACHE SUMMARY")
+	fmt.Println(strings.Repeat("═", 60))
+	if len(cached) > 0 {
+		fmt.Println("\n✅ Cached:")
+		for _, name := range cached {
+			fmt.Printf("   • %s\n", name)
+		}
+	}
+	if len(failed) > 0 {
+		fmt.Println("\n❌ Not available offline:")
+		for _, name := range failed {
+			fmt.Printf("   • %s\n", name)
+		}
+	}
+	fmt.Println("\n" + strings.Repeat("═", 60))
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package itamae
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCacheManifestOfflineSupport(t *testing.T) {
+	manifest := CacheManifest{Plugins: map[string]CachedPlugin{
+		"git": {Debs: []string{"debs/git_2.43.0_amd64.deb", "debs/git-man_2.43.0_all.deb"}},
+		"yq":  {Artifacts: []string{"artifacts/yq/yq"}},
+	}}
+
+	tests := []struct {
+		plugin  ToolPlugin
+		offline bool
+	}{
+		{ToolPlugin{ID: "git", InstallMethod: "apt", PackageName: "git"}, true},
+		{ToolPlugin{ID: "jq", InstallMethod: "apt", PackageName: "jq"}, false},
+		{ToolPlugin{ID: "yq", InstallMethod: "binary", Artifacts: []Artifact{{Name: "yq", URL: "https://example.com/yq"}}}, true},
+		{ToolPlugin{ID: "helm", InstallMethod: "binary"}, false},
+		{ToolPlugin{ID: "ghostty", InstallMethod: "manual"}, false},
+	}
+
+	for _, tt := range tests {
+		err := manifest.offlineSupport(tt.plugin)
+		if tt.offline && err != nil {
+			t.Errorf("%s: expected offline support, got: %v", tt.plugin.ID, err)
+		}
+		if !tt.offline && err == nil {
+			t.Errorf("%s: expected offline install to be rejected", tt.plugin.ID)
+		}
+	}
+
+	paths := manifest.debPaths("/cache", []ToolPlugin{{ID: "git"}, {ID: "git"}})
+	if len(paths) != 2 || paths[0] != filepath.Join("/cache", "debs/git_2.43.0_amd64.deb") {
+		t.Errorf("unexpected deb paths: %v", paths)
+	}
+}
+
+func TestCacheManifestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	manifest, err := loadCacheManifest(dir)
+	if err != nil {
+		t.Fatalf("unexpected error for missing manifest: %v", err)
+	}
+
+	manifest.Plugins["tldr"] = CachedPlugin{Artifacts: []string{"artifacts/tldr/tldr"}}
+	if err := manifest.save(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadCacheManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Plugins["tldr"].Artifacts) != 1 {
+		t.Errorf("expected tldr artifact after reload, got %+v", loaded.Plugins)
+	}
+}
+
+func TestParseArtifact(t *testing.T) {
+	if _, err := parseArtifact("yq https://example.com/yq"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := parseArtifact("https://example.com/yq"); err == nil {
+		t.Error("expected error for missing file name")
+	}
+	if _, err := parseArtifact("bin/yq https://example.com/yq"); err == nil {
+		t.Error("expected error for file name with a path")
+	}
+}
+
+func TestParseAptURIs(t *testing.T) {
+	output := `'http://archive.ubuntu.com/ubuntu/pool/main/g/git/git_1%3a2.43.0-1ubuntu7_amd64.deb' git_1%3a2.43.0-1ubuntu7_amd64.deb 3679608 SHA512:ab12
+'http://archive.ubuntu.com/ubuntu/pool/main/l/liberror-perl/liberror-perl_0.17029-2_all.deb' liberror-perl_0.17029-2_all.deb 25616 SHA512:cd34
+W: some warning
+`
+	debs := parseAptURIs(output)
+	if len(debs) != 2 || debs[0] != "git_1%3a2.43.0-1ubuntu7_amd64.deb" || debs[1] != "liberror-perl_0.17029-2_all.deb" {
+		t.Errorf("expected the git closure, got %v", debs)
+	}
+}
+
+func TestDownloadDebs(t *testing.T) {
+	bin := t.TempDir()
+	logPath := filepath.Join(bin, "log")
+	aptGet := "#!/bin/bash\necho \"$PWD $*\" >> " + logPath + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "apt-get"), []byte(aptGet), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "liberror-perl_0.17029-2_all.deb"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := downloadDebs(dir, []string{"git_1%3a2.43.0-1ubuntu7_amd64.deb", "liberror-perl_0.17029-2_all.deb"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the missing file is fetched, as the user, into the cache
+	log, _ := os.ReadFile(logPath)
+	if string(log) != dir+" download -q git=1:2.43.0-1ubuntu7\n" {
+		t.Errorf("unexpected apt-get call: %q", log)
+	}
+}
+
+func TestWithoutInstalledDebs(t *testing.T) {
+	dir := t.TempDir()
+	dpkgQuery := `#!/bin/bash
+[ "${@: -1}" = "libc6" ] && echo 2.39-0ubuntu8 && exit 0
+exit 1
+`
+	if err := os.WriteFile(filepath.Join(dir, "dpkg-query"), []byte(dpkgQuery), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	paths := withoutInstalledDebs([]string{"/cache/debs/git_2.43.0_amd64.deb", "/cache/debs/libc6_2.39-0ubuntu1_amd64.deb"})
+	if len(paths) != 1 || paths[0] != "/cache/debs/git_2.43.0_amd64.deb" {
+		t.Errorf("expected installed packages to be skipped, got %v", paths)
+	}
+}
@@ -0,0 +1,108 @@
+package itamae
+
+import (
+	"fmt"
+	"os"
+)
+
+// PluginFilter narrows the plugin catalog for 'itamae list'.
+// Empty fields match every plugin.
+type PluginFilter struct {
+	Category  string
+	Method    string
+	Omakase   bool // Only the curated OMAKASE set
+	Installed bool // Only plugins whose check command succeeds
+}
+
+// PluginInfo is the serializable view of a plugin's metadata.
+type PluginInfo struct {
+	ID             string      `json:"id"`
+	Name           string      `json:"name"`
+	Description    string      `json:"description"`
+	Category       string      `json:"category"`
+	Tags           []string    `json:"tags,omitempty"`
+	Omakase        bool        `json:"omakase"`
+	RequiresRoot   bool        `json:"requires_root"`
+	InstallMethod  string      `json:"install_method"`
+	PackageName    string      `json:"package_name,omitempty"`
+	Version        string      `json:"version,omitempty"`
+	RepoSetup      string      `json:"repo_setup,omitempty"`
+	AptRepo        string      `json:"apt_repo,omitempty"`
+	PostInstall    string      `json:"post_install,omitempty"`
+	Binaries       []string    `json:"binaries,omitempty"`
+	RequiredInputs []InputInfo `json:"required_inputs,omitempty"`
+}
+
+// InputInfo is the serializable view of a required input.
+type InputInfo struct {
+	Name       string `json:"name"`
+	Prompt     string `json:"prompt"`
+	DefaultCmd string `json:"default_cmd,omitempty"`
+	Type       string `json:"type"`
+	Pattern    string `json:"pattern,omitempty"`
+	Help       string `json:"help,omitempty"`
+}
+
+// FilterPlugins returns the plugins matching the filter, keeping their order.
+func FilterPlugins(plugins []ToolPlugin, filter PluginFilter) []ToolPlugin {
+	matched := []ToolPlugin{}
+	for _, p := range plugins {
+		if filter.Category != "" && p.Category != filter.Category {
+			continue
+		}
+		if filter.Method != "" && p.InstallMethod != filter.Method {
+			continue
+		}
+		if filter.Omakase && !p.Omakase {
+			continue
+		}
+		matched = append(matched, p)
+	}
+
+	if filter.Installed {
+		return InstalledPlugins(matched)
+	}
+	return matched
+}
+
+// NewPluginInfo converts a plugin into its serializable metadata view.
+func NewPluginInfo(p ToolPlugin) PluginInfo {
+	info := PluginInfo{
+		ID:            p.ID,
+		Name:          p.Name,
+		Description:   p.Description,
+		Category:      p.Category,
+		Tags:          p.Tags,
+		Omakase:       p.Omakase,
+		RequiresRoot:  p.RequiresRoot || p.InstallMethod == "apt",
+		InstallMethod: p.InstallMethod,
+		PackageName:   p.PackageName,
+		Version:       p.Version,
+		RepoSetup:     p.RepoSetup,
+		PostInstall:   p.PostInstall,
+		Binaries:      p.Binaries,
+	}
+	if p.AptRepo.IsSet() {
+		info.AptRepo = p.AptRepo.Key()
+	}
+	for _, input := range p.RequiredInputs {
+		info.RequiredInputs = append(info.RequiredInputs, InputInfo{
+			Name:       input.Name,
+			Prompt:     input.Prompt,
+			DefaultCmd: input.DefaultCmd,
+			Type:       input.TypeSpec(),
+			Pattern:    input.Pattern,
+			Help:       input.Help,
+		})
+	}
+	return info
+}
+
+// ScriptContent returns the raw shell script of a loaded plugin.
+func ScriptContent(p ToolPlugin) (string, error) {
+	content, err := os.ReadFile(p.ScriptPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read script for %s: %w", p.ID, err)
+	}
+	return string(content), nil
+}
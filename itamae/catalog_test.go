@@ -0,0 +1,69 @@
+package itamae
+
+import "testing"
+
+func TestFilterPlugins(t *testing.T) {
+	catalog := []ToolPlugin{
+		{ID: "git", Category: "core", InstallMethod: "apt", Omakase: true},
+		{ID: "kubectl", Category: "core", InstallMethod: "binary"},
+		{ID: "zellij", Category: "unverified", InstallMethod: "binary", Omakase: true},
+	}
+
+	tests := []struct {
+		name   string
+		filter PluginFilter
+		want   []string
+	}{
+		{"no filter", PluginFilter{}, []string{"git", "kubectl", "zellij"}},
+		{"category", PluginFilter{Category: "core"}, []string{"git", "kubectl"}},
+		{"method", PluginFilter{Method: "binary"}, []string{"kubectl", "zellij"}},
+		{"both", PluginFilter{Category: "unverified", Method: "apt"}, []string{}},
+		{"omakase", PluginFilter{Omakase: true}, []string{"git", "zellij"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FilterPlugins(catalog, tt.filter)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %d plugins", tt.want, len(got))
+			}
+			for i, p := range got {
+				if p.ID != tt.want[i] {
+					t.Errorf("expected %s at %d, got %s", tt.want[i], i, p.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadPluginsSetsCategory(t *testing.T) {
+	for _, p := range plugins {
+		if p.Category != "core" {
+			t.Errorf("expected %s to have category core, got %q", p.ID, p.Category)
+		}
+	}
+}
+
+func TestOmakasePluginsSpanCategories(t *testing.T) {
+	all, cleanup, err := LoadAllPlugins()
+	if err != nil {
+		t.Fatalf("LoadAllPlugins failed: %v", err)
+	}
+	defer cleanup()
+
+	curated := OmakasePlugins(all)
+	if len(curated) == 0 {
+		t.Fatal("expected a curated omakase set")
+	}
+
+	categories := map[string]bool{}
+	for _, p := range curated {
+		if !p.Omakase {
+			t.Errorf("%s is not marked OMAKASE", p.ID)
+		}
+		categories[p.Category] = true
+	}
+	if len(categories) < 2 {
+		t.Errorf("expected the omakase set to span categories, got %v", categories)
+	}
+}
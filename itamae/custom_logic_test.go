@@ -2,6 +2,7 @@ package itamae
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -14,6 +15,7 @@ func TestProcessInstall(t *testing.T) {
 	newPath := mockDir + ":" + originalPath
 	os.Setenv("PATH", newPath)
 	defer os.Setenv("PATH", originalPath)
+	useInProcessHelper(t)
 
 	if err := os.WriteFile(logPath, []byte{}, 0644); err != nil {
 		t.Fatalf("Failed to clear log file: %v", err)
@@ -33,7 +35,7 @@ func TestProcessInstall(t *testing.T) {
 	defer os.Remove("/tmp/itamae-test-git.sh")
 	defer os.Remove("/tmp/itamae-test-script.sh")
 
-	processInstall(mockPlugins, requiredInputs)
+	processInstall(mockPlugins, requiredInputs, InstallOptions{AptMaxAge: DefaultAptMaxAge})
 
 	logBytes, err := os.ReadFile(logPath)
 	if err != nil {
@@ -41,7 +43,8 @@ func TestProcessInstall(t *testing.T) {
 	}
 	logContent := string(logBytes)
 
-	expectedAptLog := "sudo nala install -y git"
+	// APT runs through the privileged helper, not a sudo call per command
+	expectedAptLog := "nala install -y git"
 	if !strings.Contains(logContent, expectedAptLog) {
 		t.Errorf("Expected log to contain '%s', but got:\n%s", expectedAptLog, logContent)
 	}
@@ -51,3 +54,31 @@ func TestProcessInstall(t *testing.T) {
 		t.Errorf("Expected log to contain '%s', but got:\n%s", expectedBinaryLog, logContent)
 	}
 }
+
+func TestScriptInheritsEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "env")
+	script := filepath.Join(dir, "example.sh")
+	content := "#!/bin/bash\necho \"$HOME|$ITAMAE_TEST_INHERITED|$ITAMAE_VERSION|$(command -v bash)\" > " + out + "\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", dir)
+	t.Setenv("ITAMAE_TEST_INHERITED", "inherited")
+	t.Setenv("ITAMAE_VERSION", "from-shell")
+
+	// Scripts need PATH and HOME; itamae's own variables override the shell's
+	env := map[string]string{"ITAMAE_VERSION": "v1.30.2"}
+	if err := executeScript(ToolPlugin{ID: "example", ScriptPath: script}, "install", env); err != nil {
+		t.Fatalf("script failed: %v", err)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Split(strings.TrimSpace(string(got)), "|")
+	if len(fields) != 4 || fields[0] != dir || fields[1] != "inherited" || fields[2] != "v1.30.2" || fields[3] == "" {
+		t.Errorf("expected the inherited environment with itamae's variables on top, got %q", got)
+	}
+}
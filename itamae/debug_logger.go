@@ -42,10 +42,10 @@ func InitDebugLog() error {
 	}
 
 	// Write header
-	DebugLog("=== Itamae Installation Log ===")
-	DebugLog("Started at: %s", time.Now().Format(time.RFC3339))
-	DebugLog("Log file: %s", debugLogPath)
-	DebugLog("================================\n")
+	writeDebugLog("=== Itamae Installation Log ===")
+	writeDebugLog("Started at: %s", time.Now().Format(time.RFC3339))
+	writeDebugLog("Log file: %s", debugLogPath)
+	writeDebugLog("================================\n")
 
 	return nil
 }
@@ -58,9 +58,13 @@ func DebugLog(format string, args ...interface{}) {
 	if debugLog == nil {
 		return // Not initialized
 	}
+	writeDebugLog(format, args...)
+}
 
+// writeDebugLog writes to the debug log file; callers hold debugLogMux.
+func writeDebugLog(format string, args ...interface{}) {
 	timestamp := time.Now().Format("15:04:05.000")
-	message := fmt.Sprintf(format, args...)
+	message := Redact(fmt.Sprintf(format, args...))
 	line := fmt.Sprintf("[%s] %s\n", timestamp, message)
 
 	debugLog.WriteString(line)
@@ -73,9 +77,9 @@ func CloseDebugLog() {
 	defer debugLogMux.Unlock()
 
 	if debugLog != nil {
-		DebugLog("\n=== Installation Complete ===")
-		DebugLog("Ended at: %s", time.Now().Format(time.RFC3339))
-		DebugLog("=============================")
+		writeDebugLog("\n=== Installation Complete ===")
+		writeDebugLog("Ended at: %s", time.Now().Format(time.RFC3339))
+		writeDebugLog("=============================")
 
 		debugLog.Close()
 
@@ -0,0 +1,126 @@
+package itamae
+
+import (
+	"sort"
+)
+
+// Drift kinds reported by Diff
+const (
+	DriftMissing = "missing" // Listed in the profile but not installed
+	DriftExtra   = "extra"   // Installed by itamae but not listed in the profile, or marked absent
+	DriftVersion = "version" // Installed at another version than the profile pins
+	DriftInput   = "input"   // Installed with another input value than the profile's defaults
+)
+
+// Drift is one difference between a profile and the machine.
+type Drift struct {
+	Kind  string `json:"kind"`
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Input string `json:"input,omitempty"` // Input name, for input drift
+	Want  string `json:"want,omitempty"`
+	Got   string `json:"got,omitempty"`
+}
+
+// Diff compares the profile's plugins, pinned versions and input defaults
+// (inputs, usually the inputs.toml next to the profile) with the machine.
+// Installed plugins are found with their check commands; the install state
+// store tells which other plugins itamae installed, and with which inputs.
+// Versions that cannot be detected are not reported as drift.
+func Diff(plugins []ToolPlugin, profile Profile, inputs map[string]string) ([]Drift, error) {
+	desired, err := profile.Resolve(plugins)
+	if err != nil {
+		return nil, err
+	}
+	state, err := LoadInstallState()
+	if err != nil {
+		return nil, err
+	}
+
+	drifts := []Drift{}
+	listed := make(map[string]bool, len(desired))
+	for _, p := range desired {
+		listed[p.ID] = true
+		if !isInstalled(p) {
+			drifts = append(drifts, Drift{Kind: DriftMissing, ID: p.ID, Name: p.Name, Want: p.Version})
+			continue
+		}
+
+		recorded, tracked := state.Plugins[p.ID]
+		if p.Version != "" {
+			got := installedVersion(p)
+			if got == "" {
+				got = recorded.Version
+			}
+			// A pin is exact, so a newer version is drift too
+			if got != "" && !sameVersion(got, p.Version) {
+				drifts = append(drifts, Drift{Kind: DriftVersion, ID: p.ID, Name: p.Name, Want: p.Version, Got: got})
+			}
+		}
+
+		if !tracked {
+			continue
+		}
+		for _, input := range p.RequiredInputs {
+			want, ok := inputs[input.Name]
+			got, recordedInput := recorded.Inputs[input.Name]
+			if !ok || !recordedInput || input.kind() == InputSecret || input.Validate(want) != nil {
+				continue
+			}
+			if want = input.normalize(want); want != got {
+				drifts = append(drifts, Drift{Kind: DriftInput, ID: p.ID, Name: p.Name, Input: input.Name, Want: want, Got: got})
+			}
+		}
+	}
+
+	// Plugins marked absent are extra however they were installed
+	absent, err := profile.ResolveAbsent(plugins)
+	if err != nil {
+		return nil, err
+	}
+	extras := []Drift{}
+	for _, p := range absent {
+		listed[p.ID] = true
+		if isInstalled(p) {
+			extras = append(extras, Drift{Kind: DriftExtra, ID: p.ID, Name: p.Name, Want: "absent", Got: installedVersion(p)})
+		}
+	}
+
+	byID := make(map[string]ToolPlugin, len(plugins))
+	for _, p := range plugins {
+		byID[p.ID] = p
+	}
+	for id := range state.Plugins {
+		p, known := byID[id]
+		if listed[id] || !known || !isInstalled(p) {
+			continue
+		}
+		extras = append(extras, Drift{Kind: DriftExtra, ID: id, Name: p.Name, Got: installedVersion(p)})
+	}
+	sort.Slice(extras, func(i, j int) bool { return extras[i].ID < extras[j].ID })
+
+	return append(drifts, extras...), nil
+}
+
+// MissingPlugins returns the plugins of the drift report that are missing,
+// with the profile's pinned versions applied.
+func MissingPlugins(plugins []ToolPlugin, profile Profile, drifts []Drift) ([]ToolPlugin, error) {
+	desired, err := profile.Resolve(plugins)
+	if err != nil {
+		return nil, err
+	}
+	missing := make(map[string]bool)
+	for _, d := range drifts {
+		if d.Kind == DriftMissing {
+			missing[d.ID] = true
+		}
+	}
+
+	selected := []ToolPlugin{}
+	for _, p := range desired {
+		if missing[p.ID] {
+			selected = append(selected, p)
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,98 @@
+package itamae
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func useTempInstallState(t *testing.T) {
+	t.Helper()
+	original := installStatePath
+	installStatePath = filepath.Join(t.TempDir(), "state.json")
+	t.Cleanup(func() { installStatePath = original })
+}
+
+func TestInstallStateRecordsAndForgets(t *testing.T) {
+	useTempInstallState(t)
+	tool := writeCheckPlugin(t, t.TempDir(), "tool", true, "1.2.3",
+		Input{Name: "USER_NAME", Type: InputString},
+		Input{Name: "API_TOKEN", Type: InputSecret},
+	)
+
+	if err := recordInstalled([]ToolPlugin{tool}, map[string]string{"USER_NAME": "Ada", "API_TOKEN": "s3cret", "OTHER": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	state, err := LoadInstallState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := state.Plugins["tool"]
+	if entry.Version != "1.2.3" || len(entry.Inputs) != 1 || entry.Inputs["USER_NAME"] != "Ada" {
+		t.Errorf("expected the version and non-secret inputs to be recorded, got %+v", entry)
+	}
+
+	// Upgrades keep the recorded inputs
+	if err := recordInstalled([]ToolPlugin{tool}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ = LoadInstallState(); state.Plugins["tool"].Inputs["USER_NAME"] != "Ada" {
+		t.Errorf("expected inputs to survive an upgrade, got %+v", state.Plugins["tool"])
+	}
+
+	if err := recordRemoved([]ToolPlugin{tool}); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ = LoadInstallState(); len(state.Plugins) != 0 {
+		t.Errorf("expected the plugin to be forgotten, got %+v", state.Plugins)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	useTempInstallState(t)
+	dir := t.TempDir()
+	all := []ToolPlugin{
+		writeCheckPlugin(t, dir, "pinned", true, "v1.29.0"),
+		writeCheckPlugin(t, dir, "matching", true, "1.30.2"),
+		writeCheckPlugin(t, dir, "absent", false, ""),
+		writeCheckPlugin(t, dir, "configured", true, "", Input{Name: "USER_EMAIL", Type: InputEmail}),
+		writeCheckPlugin(t, dir, "leftover", true, "0.9.0"),
+		writeCheckPlugin(t, dir, "preinstalled", true, "7.0"),
+		writeCheckPlugin(t, dir, "uninstalled", false, ""),
+		writeCheckPlugin(t, dir, "newer", true, "1.31.0"),
+	}
+	tracked := []ToolPlugin{all[3], all[4], all[6]}
+	if err := recordInstalled(tracked, map[string]string{"USER_EMAIL": "ada@old.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	profile := Profile{
+		Name:     "team",
+		Plugins:  []string{"pinned", "matching", "newer", "absent", "configured"},
+		Versions: map[string]string{"pinned": "v1.30.2", "matching": "v1.30.2", "newer": "1.30.2", "absent": "2.0"},
+	}
+	drifts, err := Diff(all, profile, map[string]string{"USER_EMAIL": "ada@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Drift{
+		{Kind: DriftVersion, ID: "pinned", Name: "pinned", Want: "v1.30.2", Got: "v1.29.0"},
+		{Kind: DriftVersion, ID: "newer", Name: "newer", Want: "1.30.2", Got: "1.31.0"},
+		{Kind: DriftMissing, ID: "absent", Name: "absent", Want: "2.0"},
+		{Kind: DriftInput, ID: "configured", Name: "configured", Input: "USER_EMAIL", Want: "ada@example.com", Got: "ada@old.example.com"},
+		{Kind: DriftExtra, ID: "leftover", Name: "leftover", Got: "0.9.0"},
+	}
+	if len(drifts) != len(want) {
+		t.Fatalf("expected %d drifts, got %+v", len(want), drifts)
+	}
+	for i := range want {
+		if drifts[i] != want[i] {
+			t.Errorf("drift %d: expected %+v, got %+v", i, want[i], drifts[i])
+		}
+	}
+
+	missing, err := MissingPlugins(all, profile, drifts)
+	if err != nil || len(missing) != 1 || missing[0].ID != "absent" || missing[0].Version != "2.0" {
+		t.Errorf("expected the missing plugin with its pinned version, got %v %v", missing, err)
+	}
+}
@@ -94,7 +94,7 @@ func ExecuteScriptAsyncCmd(plugin ToolPlugin, command string, env map[string]str
 			return PackageCompleteMsg{
 				PackageID: plugin.ID,
 				Success:   false,
-				Error:     strings.TrimSpace(errorMsg),
+				Error:     Redact(strings.TrimSpace(errorMsg)),
 			}
 		}
 
@@ -108,31 +108,15 @@ func ExecuteScriptAsyncCmd(plugin ToolPlugin, command string, env map[string]str
 }
 
 // ExecuteBatchAPTCmd executes a batch APT install command asynchronously
-func ExecuteBatchAPTCmd(packages []string, useNala bool) tea.Cmd {
+// through the privileged helper, which uses nala when it is installed.
+func ExecuteBatchAPTCmd(packages []string) tea.Cmd {
 	return func() tea.Msg {
-		var cmd *exec.Cmd
-
-		if useNala {
-			args := append([]string{"install", "-y"}, packages...)
-			cmd = exec.Command("sudo", append([]string{"nala"}, args...)...)
-		} else {
-			args := append([]string{"install", "-y"}, packages...)
-			cmd = exec.Command("sudo", append([]string{"apt-get"}, args...)...)
-		}
-
-		// Create output buffer
-		var outputBuf bytes.Buffer
-		cmd.Stdout = &outputBuf
-		cmd.Stderr = &outputBuf
-
-		// Run the command
-		err := cmd.Run()
-
+		resp, err := elevate(privilegedRequest{Op: opAptInstall, Packages: packages})
 		if err != nil {
 			return LogMsg{
 				Level:   "error",
 				Package: "",
-				Message: fmt.Sprintf("Batch APT install failed: %v\nOutput: %s", err, outputBuf.String()),
+				Message: fmt.Sprintf("Batch APT install failed: %v\nOutput: %s", err, resp.Output),
 			}
 		}
 
@@ -221,7 +205,7 @@ func StreamedExecuteScriptCmd(plugin ToolPlugin, command string, env map[string]
 					program.Send(LogMsg{
 						Level:   "info",
 						Package: plugin.ID,
-						Message: line,
+						Message: Redact(line),
 					})
 				}
 			}
@@ -236,7 +220,7 @@ func StreamedExecuteScriptCmd(plugin ToolPlugin, command string, env map[string]
 					program.Send(LogMsg{
 						Level:   "warning",
 						Package: plugin.ID,
-						Message: line,
+						Message: Redact(line),
 					})
 				}
 			}
@@ -0,0 +1,425 @@
+package itamae
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Export formats
+const (
+	ExportDockerfile = "dockerfile"
+	ExportCloudInit  = "cloud-init"
+	ExportShell      = "shell"
+)
+
+// ExportFormats lists the formats accepted by Export.
+var ExportFormats = []string{ExportDockerfile, ExportCloudInit, ExportShell}
+
+// DefaultExportBaseImage is the FROM image of an exported Dockerfile.
+const DefaultExportBaseImage = "ubuntu:24.04"
+
+// exportDir is where exported files place the plugin scripts and helpers.
+const exportDir = "/opt/itamae"
+
+// exportRunner runs a plugin script with the sudo shim first on PATH, since
+// exported steps run as root where sudo may not exist.
+const exportRunner = `#!/bin/sh
+# Runs an itamae plugin script: run <plugin-id> <function>
+PATH="` + exportDir + `/bin:$PATH" exec bash "` + exportDir + `/plugins/$1.sh" "$2"
+`
+
+// heredocDelimiter ends the files embedded in Dockerfiles and shell scripts.
+const heredocDelimiter = "ITAMAE_EOF"
+
+// ExportOptions controls how a plugin set is rendered by Export.
+type ExportOptions struct {
+	Format    string // One of ExportFormats
+	Name      string // Profile name, mentioned in the header
+	BaseImage string // FROM image of a Dockerfile ("" uses DefaultExportBaseImage)
+	// Inputs are values given with --input; they are validated against the
+	// plugins' REQUIRES declarations.
+	Inputs map[string]string
+	// InputDefaults are team defaults used for inputs not given with --input.
+	InputDefaults map[string]string
+}
+
+// exportFile is a file the exported build writes before running any step.
+type exportFile struct {
+	Path    string
+	Mode    os.FileMode
+	Content string
+}
+
+// exportStep is a group of shell commands run together, e.g. one Dockerfile RUN.
+type exportStep struct {
+	Comment  string
+	Commands []string
+	Secrets  []string // Secret inputs the commands need
+}
+
+// exportInput is a required input with the value it defaults to in the export.
+type exportInput struct {
+	Input
+	Value string
+}
+
+// export is the format-independent form of an exported installation.
+type export struct {
+	Header []string // Comment lines describing the export
+	Files  []exportFile
+	Inputs []exportInput
+	Steps  []exportStep
+}
+
+// Export writes the installation of the plugins as a Dockerfile, cloud-init
+// config or shell script. The phases and their order are the ones
+// 'itamae install' uses. Input values come from opts.Inputs and
+// opts.InputDefaults only; secrets are never written into the output.
+func Export(w io.Writer, plugins []ToolPlugin, opts ExportOptions) error {
+	if !slices.Contains(ExportFormats, opts.Format) {
+		return fmt.Errorf("unknown export format %q (use %s)", opts.Format, strings.Join(ExportFormats, ", "))
+	}
+	ex, err := buildExport(plugins, opts)
+	if err != nil {
+		return err
+	}
+
+	switch opts.Format {
+	case ExportDockerfile:
+		base := opts.BaseImage
+		if base == "" {
+			base = DefaultExportBaseImage
+		}
+		return renderDockerfile(w, ex, base)
+	case ExportCloudInit:
+		return renderCloudInit(w, ex)
+	default:
+		return renderShell(w, ex, true)
+	}
+}
+
+// buildExport lays out the files and steps installing the plugins.
+func buildExport(plugins []ToolPlugin, opts ExportOptions) (export, error) {
+	ex := export{Header: []string{"Generated by 'itamae export'; do not edit."}}
+	if opts.Name != "" {
+		ex.Header = append(ex.Header, "Profile: "+opts.Name)
+	}
+	ids := make([]string, 0, len(plugins))
+	for _, p := range plugins {
+		ids = append(ids, p.ID)
+	}
+	ex.Header = append(ex.Header, "Plugins: "+strings.Join(ids, ", "))
+
+	inputs := collectInputs(plugins)
+	provided, err := checkProvidedInputs(inputs, opts.Inputs)
+	if err != nil {
+		return export{}, err
+	}
+	for _, input := range inputs {
+		value, ok := provided[input.Name]
+		if input.kind() == InputSecret {
+			value = ""
+		} else if !ok {
+			if def, ok := opts.InputDefaults[input.Name]; ok && input.Validate(def) == nil {
+				value = input.normalize(def)
+			}
+		}
+		ex.Inputs = append(ex.Inputs, exportInput{Input: input, Value: value})
+	}
+
+	ex.Files = []exportFile{
+		{Path: exportDir + "/bin/sudo", Mode: 0755, Content: sudoShim},
+		{Path: exportDir + "/run", Mode: 0755, Content: exportRunner},
+	}
+	for _, p := range plugins {
+		script, err := os.ReadFile(p.ScriptPath)
+		if err != nil {
+			return export{}, fmt.Errorf("failed to read script for %s: %w", p.ID, err)
+		}
+		content := string(script)
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		ex.Files = append(ex.Files, exportFile{Path: exportScriptPath(p), Mode: 0755, Content: content})
+	}
+	for _, f := range ex.Files {
+		if strings.Contains("\n"+f.Content+"\n", "\n"+heredocDelimiter+"\n") {
+			return export{}, fmt.Errorf("%s contains the line %s and cannot be exported", f.Path, heredocDelimiter)
+		}
+	}
+
+	plan := planInstall(plugins)
+	ex.Steps = append(ex.Steps, exportStep{
+		Comment:  "Tools the repository setup and plugin scripts rely on",
+		Commands: []string{"apt-get update", "apt-get install -y ca-certificates curl"},
+	})
+	for _, repo := range plan.AptRepos {
+		ex.Steps = append(ex.Steps, exportStep{
+			Comment:  fmt.Sprintf("APT repository for %s", strings.Join(pluginsUsingRepo(plan.AptPlugins, repo), ", ")),
+			Commands: aptRepoCommands(repo),
+		})
+	}
+	for _, p := range plan.RepoPlugins {
+		ex.Steps = append(ex.Steps, pluginStep(p, "setup_repo", "Repository setup for "+p.Name))
+	}
+
+	if len(plan.AptPlugins) > 0 {
+		packages := []string{}
+		for _, p := range plan.AptPlugins {
+			if p.PackageName != "" {
+				packages = append(packages, shellQuote(aptPackageSpec(p)))
+			}
+		}
+		ex.Steps = append(ex.Steps, exportStep{
+			Comment:  fmt.Sprintf("Install %d APT packages", len(packages)),
+			Commands: []string{"apt-get update", "apt-get install -y " + strings.Join(packages, " ")},
+		})
+		for _, p := range plan.AptPlugins {
+			if p.PostInstall != "" {
+				ex.Steps = append(ex.Steps, pluginStep(p, "post_install", "Post-install tasks for "+p.Name))
+			}
+		}
+	}
+
+	for _, p := range plan.OtherPlugins {
+		ex.Steps = append(ex.Steps, pluginStep(p, "install", p.Name))
+	}
+	return ex, nil
+}
+
+// exportScriptPath is where a plugin's script is placed by an export.
+func exportScriptPath(p ToolPlugin) string {
+	return path.Join(exportDir, "plugins", p.ID+".sh")
+}
+
+// pluginStep runs one function of a plugin's script, installing any BINARY
+// files it stages into /usr/local/bin the way the privileged helper would.
+func pluginStep(p ToolPlugin, function, comment string) exportStep {
+	env := ""
+	if p.Version != "" {
+		env = "ITAMAE_VERSION=" + shellQuote(p.Version) + " "
+	}
+	run := fmt.Sprintf("%s%s/run %s %s", env, exportDir, p.ID, function)
+
+	step := exportStep{Comment: comment, Commands: []string{run}}
+	for _, input := range p.RequiredInputs {
+		if input.kind() == InputSecret {
+			step.Secrets = append(step.Secrets, input.Name)
+		}
+	}
+
+	if function == "install" && len(p.Binaries) > 0 {
+		step.Commands = []string{"stage=$(mktemp -d)", `ITAMAE_STAGE_DIR="$stage" ` + run}
+		for _, name := range p.Binaries {
+			step.Commands = append(step.Commands, fmt.Sprintf(`install -m 0755 "$stage/%s" %s`, name, path.Join(binDir, name)))
+		}
+		step.Commands = append(step.Commands, `rm -rf "$stage"`)
+	}
+	return step
+}
+
+// aptRepoCommands writes a declarative repository's keyring and deb822 sources
+// file. The key is downloaded and the {codename} and {arch} placeholders
+// resolved when the export runs, on the target system.
+func aptRepoCommands(repo AptRepo) []string {
+	commands := []string{}
+	target := repo
+	if strings.Contains(repo.URL+repo.Suite, "{codename}") {
+		commands = append(commands, `codename=$(. /etc/os-release && echo "${VERSION_CODENAME:-$UBUNTU_CODENAME}")`)
+		target.URL = strings.ReplaceAll(repo.URL, "{codename}", "$codename")
+		target.Suite = strings.ReplaceAll(repo.Suite, "{codename}", "$codename")
+	}
+	if slices.Contains(repo.Architectures, "{arch}") {
+		commands = append(commands, `arch=$(dpkg --print-architecture)`)
+		target.Architectures = []string{}
+		for _, arch := range repo.Architectures {
+			target.Architectures = append(target.Architectures, strings.ReplaceAll(arch, "{arch}", "$arch"))
+		}
+	}
+
+	keyring := ""
+	if repo.KeyURL != "" {
+		keyring = "$keyring"
+		commands = append(commands,
+			"key=$(mktemp)",
+			fmt.Sprintf(`curl -fsSL %s -o "$key"`, shellQuote(repo.KeyURL)),
+			fmt.Sprintf(`if grep -q "BEGIN PGP" "$key"; then keyring=%s; else keyring=%s; fi`, repo.KeyringPath(true), repo.KeyringPath(false)),
+			`install -D -m 0644 "$key" "$keyring"`,
+			`rm -f "$key"`,
+		)
+	}
+
+	// One argument per line, double quoted so $codename and $keyring expand
+	expand := strings.NewReplacer(`\$codename`, "$codename", `\$arch`, "$arch", `\$keyring`, "$keyring")
+	lines := []string{}
+	for _, line := range strings.Split(strings.TrimSuffix(renderDeb822(target, keyring), "\n"), "\n") {
+		lines = append(lines, expand.Replace(doubleQuote(line)))
+	}
+	commands = append(commands,
+		"mkdir -p "+aptSourcesDir,
+		fmt.Sprintf(`printf '%%s\n' %s > %s`, strings.Join(lines, " "), repo.SourcesPath()),
+	)
+	return commands
+}
+
+// shellQuote quotes a string for a POSIX shell when it isn't a plain word.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.,:/=+@%") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// doubleQuote quotes a string inside double quotes, escaping expansions. It
+// also suits Dockerfile ARG values.
+func doubleQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(s) + `"`
+}
+
+// secretInputs returns the names of the secret inputs, sorted.
+func (ex export) secretInputs() []string {
+	names := []string{}
+	for _, input := range ex.Inputs {
+		if input.kind() == InputSecret {
+			names = append(names, input.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func writeComments(b *strings.Builder, lines []string) {
+	for _, line := range lines {
+		fmt.Fprintf(b, "# %s\n", line)
+	}
+}
+
+// renderDockerfile renders the export as a Dockerfile. Files are written with
+// heredocs and secrets are passed as BuildKit secrets, so the syntax directive
+// is required.
+func renderDockerfile(w io.Writer, ex export, base string) error {
+	var b strings.Builder
+	b.WriteString("# syntax=docker/dockerfile:1\n")
+	writeComments(&b, ex.Header)
+	fmt.Fprintf(&b, "FROM %s\n\nENV DEBIAN_FRONTEND=noninteractive\n", base)
+
+	if len(ex.Inputs) > 0 {
+		b.WriteString("\n# Inputs; override with --build-arg NAME=value\n")
+		for _, input := range ex.Inputs {
+			if input.kind() != InputSecret {
+				fmt.Fprintf(&b, "ARG %s=%s\n", input.Name, doubleQuote(input.Value))
+			}
+		}
+		for _, name := range ex.secretInputs() {
+			fmt.Fprintf(&b, "# Secret %s: pass with --secret id=%s,env=%s\n", name, name, name)
+		}
+	}
+
+	for _, f := range ex.Files {
+		fmt.Fprintf(&b, "\nCOPY --chmod=%04o <<'%s' %s\n%s%s\n", f.Mode, heredocDelimiter, f.Path, f.Content, heredocDelimiter)
+	}
+
+	for _, step := range ex.Steps {
+		fmt.Fprintf(&b, "\n# %s\nRUN ", step.Comment)
+		for _, name := range step.Secrets {
+			fmt.Fprintf(&b, "--mount=type=secret,id=%s,env=%s ", name, name)
+		}
+		b.WriteString(strings.Join(step.Commands, " && \\\n    "))
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// renderShell renders the export as a bash script to run as root. With
+// writeFiles false, the files are expected to be in place already.
+func renderShell(w io.Writer, ex export, writeFiles bool) error {
+	var b strings.Builder
+	b.WriteString("#!/bin/bash\n")
+	writeComments(&b, ex.Header)
+	b.WriteString(`set -euo pipefail
+
+if [ "$(id -u)" -ne 0 ]; then
+    echo "This script installs system packages; run it as root." >&2
+    exit 1
+fi
+export DEBIAN_FRONTEND=noninteractive
+`)
+
+	if len(ex.Inputs) > 0 {
+		b.WriteString("\n# Inputs; set them in the environment to override\n")
+		for _, input := range ex.Inputs {
+			if input.kind() != InputSecret {
+				fmt.Fprintf(&b, "export %s=\"${%s:-%s}\"\n", input.Name, input.Name, strings.Trim(doubleQuote(input.Value), `"`))
+			}
+		}
+		for _, name := range ex.secretInputs() {
+			fmt.Fprintf(&b, "%s=\"${%s:-}\" # Secret; only passed to the plugins that need it\n", name, name)
+		}
+	}
+
+	if writeFiles {
+		for _, f := range ex.Files {
+			fmt.Fprintf(&b, "\nmkdir -p %s\ncat > %s <<'%s'\n%s%s\nchmod %04o %s\n", path.Dir(f.Path), f.Path, heredocDelimiter, f.Content, heredocDelimiter, f.Mode, f.Path)
+		}
+	}
+
+	for _, step := range ex.Steps {
+		fmt.Fprintf(&b, "\n# %s\n", step.Comment)
+		if len(step.Secrets) > 0 {
+			fmt.Fprintf(&b, "(\n    export %s\n    %s\n)\n", strings.Join(step.Secrets, " "), strings.Join(step.Commands, "\n    "))
+			continue
+		}
+		b.WriteString(strings.Join(step.Commands, "\n") + "\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// cloudConfig is the subset of the cloud-init user data an export uses.
+type cloudConfig struct {
+	WriteFiles []cloudFile `yaml:"write_files"`
+	RunCmd     [][]string  `yaml:"runcmd"`
+}
+
+type cloudFile struct {
+	Path        string `yaml:"path"`
+	Permissions string `yaml:"permissions"`
+	Content     string `yaml:"content"`
+}
+
+// renderCloudInit renders the export as cloud-init user data: write_files
+// places the scripts, and runcmd runs the steps as a shell script.
+func renderCloudInit(w io.Writer, ex export) error {
+	var script strings.Builder
+	if err := renderShell(&script, ex, false); err != nil {
+		return err
+	}
+
+	installScript := exportDir + "/install.sh"
+	config := cloudConfig{RunCmd: [][]string{{"bash", installScript}}}
+	for _, f := range append(ex.Files, exportFile{Path: installScript, Mode: 0755, Content: script.String()}) {
+		config.WriteFiles = append(config.WriteFiles, cloudFile{Path: f.Path, Permissions: fmt.Sprintf("%04o", f.Mode), Content: f.Content})
+	}
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to render cloud-init config: %w", err)
+	}
+
+	var b strings.Builder
+	b.WriteString("#cloud-config\n")
+	writeComments(&b, ex.Header)
+	b.Write(data)
+	_, err = io.WriteString(w, b.String())
+	return err
+}
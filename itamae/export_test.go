@@ -0,0 +1,146 @@
+package itamae
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func exportString(t *testing.T, selected []ToolPlugin, opts ExportOptions) string {
+	t.Helper()
+	var b bytes.Buffer
+	if err := Export(&b, selected, opts); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	return b.String()
+}
+
+func TestExportFollowsInstallOrder(t *testing.T) {
+	selected, err := FindPlugins(plugins, []string{"kubectl", "git", "gh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	selected[0].Version = "v1.30.2"
+
+	out := exportString(t, selected, ExportOptions{Format: ExportDockerfile, Name: "team"})
+
+	// Repositories, then one APT batch, then the other plugins
+	order := []string{
+		"FROM " + DefaultExportBaseImage,
+		"COPY --chmod=0755 <<'ITAMAE_EOF' /opt/itamae/plugins/kubectl.sh",
+		"# APT repository for GitHub CLI",
+		"apt-get install -y git gh",
+		`ITAMAE_STAGE_DIR="$stage" ITAMAE_VERSION=v1.30.2 /opt/itamae/run kubectl install`,
+		`install -m 0755 "$stage/kubectl" /usr/local/bin/kubectl`,
+	}
+	last := -1
+	for _, want := range order {
+		i := strings.Index(out, want)
+		if i < 0 {
+			t.Fatalf("expected %q in the Dockerfile:\n%s", want, out)
+		}
+		if i < last {
+			t.Errorf("expected %q after the previous steps", want)
+		}
+		last = i
+	}
+	if !strings.Contains(out, "# Profile: team") {
+		t.Error("expected the profile name in the header")
+	}
+	// gh's repository is restricted to the target's architecture
+	if !strings.Contains(out, "arch=$(dpkg --print-architecture)") || !strings.Contains(out, `"Architectures: $arch"`) {
+		t.Errorf("expected the architecture to be resolved on the target:\n%s", out)
+	}
+}
+
+func TestExportInputs(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "deploy-key.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/bash\necho \"$1\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	secretPlugin := ToolPlugin{
+		ID: "deploy-key", Name: "Deploy Key", InstallMethod: "manual", ScriptPath: script,
+		RequiredInputs: []Input{{Name: "DEPLOY_TOKEN", Type: InputSecret}},
+	}
+	git, err := FindPlugins(plugins, []string{"git"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	selected := append(git, secretPlugin)
+	opts := ExportOptions{
+		Inputs:        map[string]string{"GIT_USER_NAME": `Ada "The Countess" Lovelace`, "DEPLOY_TOKEN": "s3cret"},
+		InputDefaults: map[string]string{"GIT_USER_EMAIL": "ada@example.com"},
+	}
+
+	opts.Format = ExportDockerfile
+	dockerfile := exportString(t, selected, opts)
+	for _, want := range []string{
+		`ARG GIT_USER_NAME="Ada \"The Countess\" Lovelace"`,
+		`ARG GIT_USER_EMAIL="ada@example.com"`,
+		"RUN --mount=type=secret,id=DEPLOY_TOKEN,env=DEPLOY_TOKEN /opt/itamae/run deploy-key install",
+	} {
+		if !strings.Contains(dockerfile, want) {
+			t.Errorf("expected %q in the Dockerfile:\n%s", want, dockerfile)
+		}
+	}
+
+	opts.Format = ExportShell
+	shell := exportString(t, selected, opts)
+	if !strings.Contains(shell, "export DEPLOY_TOKEN\n    /opt/itamae/run deploy-key install") {
+		t.Errorf("expected the secret to be exported only for its plugin:\n%s", shell)
+	}
+	path := filepath.Join(t.TempDir(), "install.sh")
+	if err := os.WriteFile(path, []byte(shell), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := exec.Command("bash", "-n", path).CombinedOutput(); err != nil {
+		t.Errorf("exported shell script does not parse: %v\n%s", err, output)
+	}
+
+	for _, out := range []string{dockerfile, shell} {
+		if strings.Contains(out, "s3cret") {
+			t.Error("expected the secret value not to be exported")
+		}
+	}
+}
+
+func TestExportCloudInit(t *testing.T) {
+	selected, err := FindPlugins(plugins, []string{"gh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := exportString(t, selected, ExportOptions{Format: ExportCloudInit})
+	if !strings.HasPrefix(out, "#cloud-config\n") {
+		t.Fatalf("expected a cloud-config header, got:\n%s", out)
+	}
+
+	var config cloudConfig
+	if err := yaml.Unmarshal([]byte(out), &config); err != nil {
+		t.Fatalf("invalid cloud-init YAML: %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range config.WriteFiles {
+		files[f.Path] = f.Content
+	}
+	if !strings.Contains(files["/opt/itamae/plugins/gh.sh"], "# NAME: GitHub CLI") {
+		t.Error("expected the plugin script in write_files")
+	}
+	if !strings.Contains(files["/opt/itamae/install.sh"], "apt-get install -y gh") || strings.Contains(files["/opt/itamae/install.sh"], "ITAMAE_EOF") {
+		t.Errorf("expected an install script without embedded files, got:\n%s", files["/opt/itamae/install.sh"])
+	}
+	if len(config.RunCmd) != 1 || strings.Join(config.RunCmd[0], " ") != "bash /opt/itamae/install.sh" {
+		t.Errorf("unexpected runcmd %v", config.RunCmd)
+	}
+}
+
+func TestExportRejectsUnknownFormat(t *testing.T) {
+	var b bytes.Buffer
+	if err := Export(&b, nil, ExportOptions{Format: "ansible"}); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
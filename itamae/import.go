@@ -0,0 +1,97 @@
+package itamae
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ImportOptions controls what Import records about a machine.
+type ImportOptions struct {
+	Name        string // Profile name
+	PinVersions bool   // Pin each plugin to the version installed here
+	// Inputs detects input values with their default commands. They often
+	// hold personal values such as a git identity, so it is opt-in.
+	Inputs bool
+}
+
+// ImportResult is a snapshot of the plugins installed on a machine.
+type ImportResult struct {
+	Profile Profile
+	// Inputs holds the non-secret input values detected with the inputs'
+	// default commands, keyed by name.
+	Inputs map[string]string
+}
+
+// Import runs every plugin's check command and returns a profile listing the
+// installed ones. With opts.Inputs, it also returns the input values their
+// default commands detect. Values that don't pass the input's validation are
+// left out, as are secrets.
+func Import(plugins []ToolPlugin, opts ImportOptions) ImportResult {
+	result := ImportResult{
+		Profile: Profile{Name: opts.Name, Plugins: []string{}},
+		Inputs:  map[string]string{},
+	}
+
+	installed := InstalledPlugins(plugins)
+	for _, p := range installed {
+		result.Profile.Plugins = append(result.Profile.Plugins, p.ID)
+		if !opts.PinVersions {
+			continue
+		}
+		// Plugins that always install the latest version can't be pinned
+		if version := installedVersion(p); version != "" && honorsVersion(p) {
+			if result.Profile.Versions == nil {
+				result.Profile.Versions = map[string]string{}
+			}
+			result.Profile.Versions[p.ID] = version
+		}
+	}
+
+	if !opts.Inputs {
+		return result
+	}
+	for _, input := range collectInputs(installed) {
+		if input.kind() == InputSecret {
+			continue
+		}
+		value := getDefaultValue(input.DefaultCmd)
+		if value == "" {
+			continue
+		}
+		if err := input.Validate(value); err != nil {
+			DebugLog("Not importing %s: %v", input.Name, err)
+			continue
+		}
+		result.Inputs[input.Name] = input.normalize(value)
+	}
+	return result
+}
+
+// Save writes the profile to path and, when any inputs were detected, the
+// team defaults file next to it, so 'itamae install --profile path' replays
+// both.
+func (r ImportResult) Save(path string) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Imported by 'itamae import'. Install with: itamae install --profile %s\n", filepath.Base(path))
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(r.Profile); err != nil {
+		return fmt.Errorf("failed to encode profile: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	if len(r.Inputs) == 0 {
+		return nil
+	}
+	return SaveInputAnswers(ProfileInputsPath(path), r.Inputs)
+}
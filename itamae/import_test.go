@@ -0,0 +1,85 @@
+package itamae
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeCheckPlugin writes a plugin whose check command succeeds when installed
+// is true and whose version command prints version. Its install command
+// honors ITAMAE_VERSION.
+func writeCheckPlugin(t *testing.T, dir, id string, installed bool, version string, inputs ...Input) ToolPlugin {
+	t.Helper()
+	check := "exit 1"
+	if installed {
+		check = "exit 0"
+	}
+	script := "#!/bin/bash\ncase \"$1\" in\n    install) echo \"$ITAMAE_VERSION\" ;;\n    check) " + check + " ;;\n    version) echo " + version + " ;;\nesac\n"
+	path := filepath.Join(dir, id+".sh")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return ToolPlugin{ID: id, Name: id, InstallMethod: "binary", ScriptPath: path, RequiredInputs: inputs}
+}
+
+func TestImportInstalledPlugins(t *testing.T) {
+	dir := t.TempDir()
+	all := []ToolPlugin{
+		writeCheckPlugin(t, dir, "git-ish", true, "2.43.0",
+			Input{Name: "USER_NAME", Type: InputString, DefaultCmd: "echo Ada"},
+			Input{Name: "USER_EMAIL", Type: InputEmail, DefaultCmd: "echo not-an-email"},
+			Input{Name: "API_TOKEN", Type: InputSecret, DefaultCmd: "echo s3cret"},
+		),
+		writeCheckPlugin(t, dir, "missing", false, "1.0.0", Input{Name: "OTHER", DefaultCmd: "echo other"}),
+		writeCheckPlugin(t, dir, "fzf-ish", true, "0.54.0"),
+	}
+
+	result := Import(all, ImportOptions{Name: "workstation", PinVersions: true, Inputs: true})
+	if len(result.Profile.Plugins) != 2 || result.Profile.Plugins[0] != "git-ish" || result.Profile.Plugins[1] != "fzf-ish" {
+		t.Errorf("expected the installed plugins in order, got %v", result.Profile.Plugins)
+	}
+	if result.Profile.Versions["fzf-ish"] != "0.54.0" {
+		t.Errorf("expected installed versions to be pinned, got %v", result.Profile.Versions)
+	}
+	// Invalid values, secrets and inputs of missing plugins are left out
+	if len(result.Inputs) != 1 || result.Inputs["USER_NAME"] != "Ada" {
+		t.Errorf("expected only USER_NAME to be imported, got %v", result.Inputs)
+	}
+
+	// A plugin that always installs the latest version is listed unpinned
+	latest := writeCheckPlugin(t, dir, "latest-only", true, "3.0.0")
+	if err := os.WriteFile(latest.ScriptPath, []byte("#!/bin/bash\ncase \"$1\" in\n    version) echo 3.0.0 ;;\nesac\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if only := Import([]ToolPlugin{latest}, ImportOptions{PinVersions: true}); len(only.Profile.Plugins) != 1 || only.Profile.Versions != nil {
+		t.Errorf("expected latest-only to be listed without a pin, got %+v", only.Profile)
+	}
+
+	if unpinned := Import(all, ImportOptions{}); unpinned.Profile.Versions != nil {
+		t.Errorf("expected no versions without PinVersions, got %v", unpinned.Profile.Versions)
+	}
+
+	// Personal values stay out of the team file unless asked for
+	if plain := Import(all, ImportOptions{}); len(plain.Inputs) != 0 {
+		t.Errorf("expected no inputs without Inputs, got %v", plain.Inputs)
+	}
+
+	// The saved profile replays with 'itamae install --profile'
+	path := filepath.Join(t.TempDir(), "team", "dev.yaml")
+	if err := result.Save(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	profile, err := LoadProfile(path)
+	if err != nil {
+		t.Fatalf("saved profile does not load: %v", err)
+	}
+	selected, err := profile.Resolve(all)
+	if err != nil || len(selected) != 2 || selected[0].Version != "2.43.0" {
+		t.Errorf("expected the profile to resolve with pinned versions, got %v %v", selected, err)
+	}
+	defaults, err := LoadInputDefaults(TeamInputsPath(path))
+	if err != nil || defaults["USER_NAME"] != "Ada" {
+		t.Errorf("expected the inputs next to the profile, got %v %v", defaults, err)
+	}
+}
@@ -0,0 +1,359 @@
+package itamae
+
+import (
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+)
+
+// Input types declared in REQUIRES as NAME:type
+const (
+	InputString = "string"
+	InputEmail  = "email"
+	InputPath   = "path"
+	InputBool   = "bool"
+	InputChoice = "choice" // Declared as choice:a,b,c
+	InputSecret = "secret"
+)
+
+// parseInputType parses the type part of a REQUIRES name, e.g. "email" or
+// "choice:vim,nano". An empty spec is a string.
+func parseInputType(spec string) (string, []string, error) {
+	kind, rest, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "":
+		return InputString, nil, nil
+	case InputString, InputEmail, InputPath, InputBool, InputSecret:
+		if rest != "" {
+			return "", nil, fmt.Errorf("input type %s takes no options", kind)
+		}
+		return kind, nil, nil
+	case InputChoice:
+		choices := []string{}
+		for _, c := range strings.Split(rest, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				choices = append(choices, c)
+			}
+		}
+		if len(choices) == 0 {
+			return "", nil, fmt.Errorf("choice input needs options, e.g. choice:a,b,c")
+		}
+		return InputChoice, choices, nil
+	}
+	return "", nil, fmt.Errorf("unknown input type %q (use string, email, path, bool, choice:a,b,c or secret)", kind)
+}
+
+// TypeSpec renders the type back into its REQUIRES form.
+func (i Input) TypeSpec() string {
+	if i.Type == InputChoice {
+		return InputChoice + ":" + strings.Join(i.Choices, ",")
+	}
+	return i.kind()
+}
+
+// kind returns the input type, treating an unset type as a string.
+func (i Input) kind() string {
+	if i.Type == "" {
+		return InputString
+	}
+	return i.Type
+}
+
+// Validate checks a value against the input's type and pattern.
+func (i Input) Validate(value string) error {
+	switch i.kind() {
+	case InputBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s must be true or false", i.Name)
+		}
+		return nil
+	case InputChoice:
+		for _, c := range i.Choices {
+			if value == c {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s must be one of %s", i.Name, strings.Join(i.Choices, ", "))
+	}
+
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("%s cannot be empty", i.Name)
+	}
+
+	switch i.kind() {
+	case InputEmail:
+		addr, err := mail.ParseAddress(value)
+		if err != nil || addr.Address != value {
+			return fmt.Errorf("%s must be an email address like name@example.com", i.Name)
+		}
+	case InputPath:
+		if strings.ContainsAny(value, "\n\x00") {
+			return fmt.Errorf("%s must be a single path", i.Name)
+		}
+	}
+
+	if i.Pattern != "" {
+		re, err := regexp.Compile(i.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s has an invalid pattern: %w", i.Name, err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("%s must match %s", i.Name, i.Pattern)
+		}
+	}
+	return nil
+}
+
+// normalize converts a valid value into the form passed to scripts: booleans
+// become "true"/"false" and a leading ~ in paths is expanded.
+func (i Input) normalize(value string) string {
+	switch i.kind() {
+	case InputBool:
+		b, _ := strconv.ParseBool(value)
+		return strconv.FormatBool(b)
+	case InputPath:
+		if value == "~" || strings.HasPrefix(value, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				return filepath.Join(home, strings.TrimPrefix(value, "~"))
+			}
+		}
+	}
+	return value
+}
+
+// promptInput asks for a single input with the huh field matching its type.
+func promptInput(input Input, defaultValue string) (string, error) {
+	var field huh.Field
+	value := defaultValue
+	var confirmed bool
+
+	switch input.kind() {
+	case InputBool:
+		confirmed, _ = strconv.ParseBool(defaultValue)
+		field = huh.NewConfirm().
+			Title(input.Prompt).
+			Description(input.Help).
+			Value(&confirmed)
+	case InputChoice:
+		options := huh.NewOptions(input.Choices...)
+		field = huh.NewSelect[string]().
+			Title(input.Prompt).
+			Description(input.Help).
+			Options(options...).
+			Value(&value)
+	default:
+		text := huh.NewInput().
+			Title(input.Prompt).
+			Description(input.Help).
+			Value(&value).
+			Validate(input.Validate)
+		if input.kind() == InputSecret {
+			text = text.EchoMode(huh.EchoModePassword)
+		}
+		field = text
+	}
+
+	runner := newFormRunner(huh.NewForm(huh.NewGroup(field)))
+	if err := runner.Run(); err != nil {
+		return "", err
+	}
+
+	if input.kind() == InputBool {
+		value = strconv.FormatBool(confirmed)
+	}
+	return input.normalize(value), nil
+}
+
+// collectInputs returns the unique inputs required by the plugins, in order.
+func collectInputs(plugins []ToolPlugin) []Input {
+	seen := make(map[string]bool)
+	inputs := []Input{}
+	for _, p := range plugins {
+		for _, input := range p.RequiredInputs {
+			if !seen[input.Name] {
+				seen[input.Name] = true
+				inputs = append(inputs, input)
+			}
+		}
+	}
+	return inputs
+}
+
+// requiresInput reports whether the plugin declares the named input.
+func (p ToolPlugin) requiresInput(name string) bool {
+	for _, input := range p.RequiredInputs {
+		if input.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkProvidedInputs validates values given with --input against the inputs
+// the plugins require, returning them normalized.
+func checkProvidedInputs(inputs []Input, provided map[string]string) (map[string]string, error) {
+	byName := make(map[string]Input, len(inputs))
+	for _, input := range inputs {
+		byName[input.Name] = input
+	}
+
+	names := make([]string, 0, len(provided))
+	for name := range provided {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make(map[string]string, len(provided))
+	for _, name := range names {
+		input, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown input %s: not required by the selected plugins", name)
+		}
+		if err := input.Validate(provided[name]); err != nil {
+			return nil, fmt.Errorf("invalid --input: %w", err)
+		}
+		values[name] = input.normalize(provided[name])
+	}
+	return values, nil
+}
+
+// gatherInputs prompts for every input required by the plugins, once per input
+// name. Inputs given with --input, and secrets found in the secret store, are
+// not prompted for. Answers are remembered in opts.InputsFile.
+func gatherInputs(plugins []ToolPlugin, opts InstallOptions) (map[string]string, error) {
+	store := opts.SecretStore
+	inputs := collectInputs(plugins)
+	requiredInputs, err := checkProvidedInputs(inputs, opts.Inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, input := range inputs {
+		if value, ok := requiredInputs[input.Name]; ok {
+			rememberSecret(store, input, value)
+			continue
+		}
+		if value, ok := storedSecret(store, input); ok {
+			requiredInputs[input.Name] = value
+			continue
+		}
+		value, err := promptInput(input, inputDefault(input, opts.InputDefaults))
+		if err != nil {
+			return nil, fmt.Errorf("error getting input for %s: %w", input.Name, err)
+		}
+		requiredInputs[input.Name] = value
+		rememberSecret(store, input, value)
+	}
+
+	registerSecrets(inputs, requiredInputs)
+	if err := rememberInputs(opts.InputsFile, inputs, requiredInputs); err != nil {
+		Logger.Warnf("Could not remember input answers: %v", err)
+	}
+	return requiredInputs, nil
+}
+
+// defaultInputs fills required inputs without prompting: from --input when
+// given, then from the secret store, otherwise from remembered answers and
+// default commands. Inputs without a default are left empty; scripts skip the
+// configuration that needs them.
+func defaultInputs(plugins []ToolPlugin, opts InstallOptions) (map[string]string, error) {
+	store := opts.SecretStore
+	inputs := collectInputs(plugins)
+	requiredInputs, err := checkProvidedInputs(inputs, opts.Inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, input := range inputs {
+		if value, ok := requiredInputs[input.Name]; ok {
+			rememberSecret(store, input, value)
+			continue
+		}
+		if value, ok := storedSecret(store, input); ok {
+			requiredInputs[input.Name] = value
+			continue
+		}
+		value := inputDefault(input, opts.InputDefaults)
+		if value != "" {
+			if err := input.Validate(value); err != nil {
+				return nil, fmt.Errorf("default for %w; pass --input %s=<value>", err, input.Name)
+			}
+			value = input.normalize(value)
+		}
+		requiredInputs[input.Name] = value
+		registerSecrets([]Input{input}, requiredInputs)
+		DebugLog("Input %s defaulted to %q", input.Name, value)
+	}
+
+	registerSecrets(inputs, requiredInputs)
+	return requiredInputs, nil
+}
+
+// inputDefault returns the value an input starts with: a remembered or team
+// answer when it is still valid, otherwise the output of its default command.
+// Secrets never come from answer files.
+func inputDefault(input Input, defaults map[string]string) string {
+	if value, ok := defaults[input.Name]; ok && input.kind() != InputSecret && input.Validate(value) == nil {
+		return value
+	}
+	return getDefaultValue(input.DefaultCmd)
+}
+
+// registerSecrets marks the values of secret inputs for redaction.
+func registerSecrets(inputs []Input, values map[string]string) {
+	for _, input := range inputs {
+		if input.kind() == InputSecret {
+			registerSecret(values[input.Name])
+		}
+	}
+}
+
+// storedSecret looks a secret input up in the store. Unreadable or no longer
+// valid values are ignored so the input is asked for again.
+func storedSecret(store SecretStore, input Input) (string, bool) {
+	if store == nil || input.kind() != InputSecret {
+		return "", false
+	}
+	value, ok, err := store.Get(input.Name)
+	if err != nil {
+		Logger.Warnf("%v", err)
+		return "", false
+	}
+	if !ok || input.Validate(value) != nil {
+		return "", false
+	}
+	DebugLog("Input %s read from the secret store", input.Name)
+	return value, true
+}
+
+// rememberSecret saves a secret input's value to the store.
+func rememberSecret(store SecretStore, input Input, value string) {
+	if store == nil || input.kind() != InputSecret || value == "" {
+		return
+	}
+	if err := store.Set(input.Name, value); err != nil {
+		Logger.Warnf("%v", err)
+		return
+	}
+	DebugLog("Input %s saved to the secret store", input.Name)
+}
+
+// ParseInputFlags parses repeated --input NAME=VALUE flags.
+func ParseInputFlags(flags []string) (map[string]string, error) {
+	values := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		name, value, ok := strings.Cut(flag, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --input %q: expected NAME=VALUE", flag)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
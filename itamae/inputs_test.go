@@ -0,0 +1,152 @@
+package itamae
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/huh"
+)
+
+func TestParseMetadataTypedInputs(t *testing.T) {
+	plugin, err := parseMetadata(`#!/bin/bash
+# NAME: Example
+# DESCRIPTION: An example.
+# INSTALL_METHOD: binary
+# REQUIRES: EMAIL:email|Your email
+# INPUT_HELP: EMAIL Used for commits
+# REQUIRES: EDITOR:choice:vim, nano|Your editor
+# REQUIRES: TEAM|Your team
+# INPUT_PATTERN: TEAM ^[a-z]+-[0-9]+$
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	inputs := plugin.RequiredInputs
+	if len(inputs) != 3 {
+		t.Fatalf("expected 3 inputs, got %+v", inputs)
+	}
+	if inputs[0].Type != InputEmail || inputs[0].Help != "Used for commits" {
+		t.Errorf("unexpected email input: %+v", inputs[0])
+	}
+	if inputs[1].Type != InputChoice || strings.Join(inputs[1].Choices, ",") != "vim,nano" {
+		t.Errorf("unexpected choice input: %+v", inputs[1])
+	}
+	if inputs[2].Type != InputString || inputs[2].Pattern != "^[a-z]+-[0-9]+$" {
+		t.Errorf("unexpected string input: %+v", inputs[2])
+	}
+}
+
+func TestParseMetadataRejectsBadInputs(t *testing.T) {
+	_, err := parseMetadata(`#!/bin/bash
+# NAME: Example
+# DESCRIPTION: An example.
+# INSTALL_METHOD: binary
+# REQUIRES: A:number|A number
+# REQUIRES: B:choice:|A choice
+# INPUT_PATTERN: MISSING ^x$
+# REQUIRES: C|A pattern
+# INPUT_PATTERN: C ([
+`)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{
+		`line 5: REQUIRES input A: unknown input type "number"`,
+		"line 6: REQUIRES input B: choice input needs options",
+		"line 7: INPUT_PATTERN refers to MISSING",
+		"line 9: REQUIRES input C has an invalid pattern",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in:\n%v", want, err)
+		}
+	}
+}
+
+func TestInputValidate(t *testing.T) {
+	tests := []struct {
+		input Input
+		value string
+		ok    bool
+	}{
+		{Input{Name: "S"}, "anything", true},
+		{Input{Name: "S"}, " ", false},
+		{Input{Name: "E", Type: InputEmail}, "ada@example.com", true},
+		{Input{Name: "E", Type: InputEmail}, "Ada <ada@example.com>", false},
+		{Input{Name: "E", Type: InputEmail}, "ada", false},
+		{Input{Name: "B", Type: InputBool}, "yes", false},
+		{Input{Name: "B", Type: InputBool}, "1", true},
+		{Input{Name: "C", Type: InputChoice, Choices: []string{"vim", "nano"}}, "nano", true},
+		{Input{Name: "C", Type: InputChoice, Choices: []string{"vim", "nano"}}, "emacs", false},
+		{Input{Name: "P", Type: InputSecret, Pattern: "^[0-9a-f]{4}$"}, "beef", true},
+		{Input{Name: "P", Type: InputSecret, Pattern: "^[0-9a-f]{4}$"}, "cafe!", false},
+	}
+
+	for _, tt := range tests {
+		err := tt.input.Validate(tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s %q: expected ok=%v, got %v", tt.input.TypeSpec(), tt.value, tt.ok, err)
+		}
+	}
+}
+
+func TestDefaultInputsUsesProvidedValues(t *testing.T) {
+	plugins := []ToolPlugin{{RequiredInputs: []Input{
+		{Name: "EMAIL", Prompt: "Email", Type: InputEmail, DefaultCmd: "echo default@example.com"},
+		{Name: "DEBUG", Prompt: "Debug", Type: InputBool, DefaultCmd: "echo 0"},
+	}}}
+
+	values, err := defaultInputs(plugins, InstallOptions{Inputs: map[string]string{"EMAIL": "ada@example.com"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if values["EMAIL"] != "ada@example.com" || values["DEBUG"] != "false" {
+		t.Errorf("unexpected values: %v", values)
+	}
+
+	if _, err := defaultInputs(plugins, InstallOptions{Inputs: map[string]string{"EMAIL": "not-an-email"}}); err == nil {
+		t.Error("expected an invalid --input to be rejected")
+	}
+	if _, err := defaultInputs(plugins, InstallOptions{Inputs: map[string]string{"OTHER": "x"}}); err == nil {
+		t.Error("expected an unknown --input to be rejected")
+	}
+}
+
+func TestGatherInputsSkipsProvided(t *testing.T) {
+	original := newFormRunner
+	prompted := 0
+	newFormRunner = func(form *huh.Form) formRunner {
+		prompted++
+		return stubFormRunner{}
+	}
+	defer func() { newFormRunner = original }()
+
+	plugins := []ToolPlugin{
+		{RequiredInputs: []Input{{Name: "A", Prompt: "A", DefaultCmd: "echo a"}}},
+		{RequiredInputs: []Input{{Name: "A", Prompt: "A"}, {Name: "B", Prompt: "B"}}},
+	}
+
+	values, err := gatherInputs(plugins, InstallOptions{Inputs: map[string]string{"B": "given"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if prompted != 1 {
+		t.Errorf("expected one prompt, got %d", prompted)
+	}
+	if values["A"] != "a" || values["B"] != "given" {
+		t.Errorf("unexpected values: %v", values)
+	}
+}
+
+func TestParseInputFlags(t *testing.T) {
+	values, err := ParseInputFlags([]string{"NAME=Ada Lovelace", "EXPR=a=b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if values["NAME"] != "Ada Lovelace" || values["EXPR"] != "a=b" {
+		t.Errorf("unexpected values: %v", values)
+	}
+	if _, err := ParseInputFlags([]string{"NAME"}); err == nil {
+		t.Error("expected an error without '='")
+	}
+}
@@ -1,10 +1,9 @@
 package itamae
 
 import (
-	"bufio"
 	"embed"
+	"errors"
 	"fmt"
-	"io"
 	"io/fs"
 	"os"
 	"os/exec"
@@ -18,33 +17,54 @@ import (
 //go:embed scripts/core/* scripts/essentials/* scripts/unverified/*
 var scriptsFS embed.FS
 
+// Categories lists the embedded plugin categories in installation order.
+var Categories = []string{"core", "essentials", "unverified"}
+
 type Input struct {
 	Name       string
 	Prompt     string
-	DefaultCmd string // Command to retrieve existing/default value
+	DefaultCmd string   // Command to retrieve existing/default value
+	Type       string   // One of the Input* types; empty means string
+	Choices    []string // Allowed values for choice inputs
+	Pattern    string   // Optional regular expression the value must match
+	Help       string   // Optional help text shown under the prompt
 }
 
 type ToolPlugin struct {
 	ID             string // "vscode", "ripgrep"
 	Name           string // "Visual Studio Code"
 	Description    string
+	Category       string   // "core", "essentials", "unverified"
+	Tags           []string // Search keywords from the TAGS metadata
 	Omakase        bool
-	ScriptPath     string // The path to the executable in the /tmp/ directory
-	InstallMethod  string // "apt", "binary", "manual"
-	PackageName    string // For apt packages, the actual package name
-	RepoSetup      string // Function name for repository setup (optional, for APT packages needing custom repos)
-	PostInstall    string // Function name for post-install tasks (optional)
+	RequiresRoot   bool       // REQUIRES_ROOT metadata; false for plugins that install into $HOME
+	ScriptPath     string     // The path to the executable in the /tmp/ directory
+	InstallMethod  string     // "apt", "binary", "manual"
+	PackageName    string     // For apt packages, the actual package name
+	Version        string     // Pinned version (VERSION metadata, overridable per profile); empty means latest
+	LatestCmd      string     // Command printing the latest available version (binary plugins)
+	RepoSetup      string     // Function name for repository setup (optional, fallback when AptRepo is not declared)
+	AptRepo        AptRepo    // Declarative repository from APT_REPO/APT_KEY_URL (optional)
+	PostInstall    string     // Function name for post-install tasks (optional)
+	Artifacts      []Artifact // Files downloaded by the installer, cacheable for offline installs
+	Binaries       []string   // BINARY metadata; executables the install step stages for itamae to put in /usr/local/bin
+	LegacyPaths    []string   // LEGACY_PATH metadata; directories an earlier version installed into, removed once it is replaced
 	RequiredInputs []Input
 }
 
 func confirmInstallation() bool {
+	return confirmAction("Proceed with installation?", "This will install the selected tools on your system.")
+}
+
+// confirmAction asks the user a yes/no question before a system-changing operation.
+func confirmAction(title, description string) bool {
 	var confirm bool
 
 	form := huh.NewForm(
 		huh.NewGroup(
 			huh.NewConfirm().
-				Title("Proceed with installation?").
-				Description("This will install the selected tools on your system.").
+				Title(title).
+				Description(description).
 				Value(&confirm),
 		),
 	)
@@ -106,7 +126,12 @@ var newFormRunner = func(form *huh.Form) formRunner {
 }
 
 // ensureSudoAccess prompts for sudo password upfront to avoid interruptions during installation.
+// As root, e.g. in a container, there is nothing to ask for.
 func ensureSudoAccess() error {
+	if runningAsRoot() {
+		DebugLog("Running as root; not asking for sudo")
+		return nil
+	}
 	fmt.Println("\n🔐 Requesting sudo access for installation...")
 	cmd := exec.Command("sudo", "-v")
 	cmd.Stdin = os.Stdin
@@ -157,14 +182,82 @@ func RunTextInput(question string, defaultValue string) (string, error) {
 	return value, nil
 }
 
+// executeScript runs one step of a plugin script. For plugins that declare
+// BINARY, install and upgrade stage the files in $ITAMAE_STAGE_DIR without
+// sudo and itamae installs them through the privileged helper; remove deletes
+// them after the script's own remove step.
 func executeScript(plugin ToolPlugin, command string, env map[string]string) error {
+	if len(plugin.Binaries) == 0 {
+		return runScript(plugin, command, env)
+	}
+
+	switch command {
+	case "install", "upgrade":
+		return installStaged(plugin, command, env)
+	case "remove":
+		if err := runScript(plugin, command, env); err != nil {
+			return err
+		}
+		for _, name := range plugin.Binaries {
+			if _, err := elevate(privilegedRequest{Op: opRemoveBinary, Name: name}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return runScript(plugin, command, env)
+}
+
+// installStaged runs an install or upgrade step that stages the plugin's
+// BINARY files, then installs them into /usr/local/bin.
+func installStaged(plugin ToolPlugin, command string, env map[string]string) error {
+	stage, err := os.MkdirTemp("", "itamae-stage-")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stage)
+
+	staged := map[string]string{"ITAMAE_STAGE_DIR": stage}
+	for key, value := range env {
+		staged[key] = value
+	}
+	if err := runScript(plugin, command, staged); err != nil {
+		return err
+	}
+
+	for _, name := range plugin.Binaries {
+		source := filepath.Join(stage, name)
+		if _, err := os.Stat(source); err != nil {
+			return fmt.Errorf("%s step did not stage %s in $ITAMAE_STAGE_DIR", command, name)
+		}
+		if _, err := elevate(privilegedRequest{Op: opInstallBinary, Name: name, Source: source}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runScript runs a plugin script with the given command, streaming its output.
+func runScript(plugin ToolPlugin, command string, env map[string]string) error {
 	cmd := exec.Command("bash", plugin.ScriptPath, command)
 
-	// Set environment variables
+	// Set environment variables on top of the inherited environment (PATH, HOME, ...)
+	cmd.Env = os.Environ()
 	for key, value := range env {
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
 	}
 
+	// As root there may be no sudo binary, so scripts get a shim that runs the
+	// command directly; otherwise one that never waits for a password
+	shimDir, err := writeSudoShim()
+	if err != nil {
+		return err
+	}
+	if shimDir != "" {
+		defer os.RemoveAll(shimDir)
+		cmd.Env = append(cmd.Env, "PATH="+shimDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
+
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return fmt.Errorf("failed to get stdout pipe: %w", err)
@@ -184,12 +277,12 @@ func executeScript(plugin ToolPlugin, command string, env map[string]string) err
 
 	go func() {
 		defer wg.Done()
-		io.Copy(os.Stdout, stdout)
+		copyRedacted(os.Stdout, stdout)
 	}()
 
 	go func() {
 		defer wg.Done()
-		io.Copy(os.Stderr, stderr)
+		copyRedacted(os.Stderr, stderr)
 	}()
 
 	wg.Wait()
@@ -197,6 +290,37 @@ func executeScript(plugin ToolPlugin, command string, env map[string]string) err
 	return cmd.Wait()
 }
 
+// InstalledPlugins returns the plugins whose check command reports them as installed.
+func InstalledPlugins(plugins []ToolPlugin) []ToolPlugin {
+	installed := []ToolPlugin{}
+	for _, p := range plugins {
+		if isInstalled(p) {
+			installed = append(installed, p)
+		}
+	}
+	return installed
+}
+
+// SelectPlugins returns the plugins to install for a category. Core and essentials
+// install everything, "omakase" returns the curated set, "all" prompts with the
+// grouped multiselect, and other categories prompt with a multiselect.
+func SelectPlugins(plugins []ToolPlugin, category string) []ToolPlugin {
+	switch category {
+	case "core", "essentials":
+		return plugins
+	case CategoryOmakase:
+		return OmakasePlugins(plugins)
+	case CategoryAll:
+		return selectGroupedPlugins(plugins)
+	}
+	return selectPlugins(plugins)
+}
+
+// isInstalled runs the plugin's check command and reports whether it succeeded.
+func isInstalled(plugin ToolPlugin) bool {
+	return exec.Command("bash", plugin.ScriptPath, "check").Run() == nil
+}
+
 func SelectCategory() (string, error) {
 	var category string
 
@@ -206,6 +330,8 @@ func SelectCategory() (string, error) {
 				Title("Select package category").
 				Description("Choose which category of packages to install").
 				Options(
+					huh.NewOption("Omakase - Install the curated set without prompts", CategoryOmakase),
+					huh.NewOption("All - Choose from every category (core and essentials pre-selected)", CategoryAll),
 					huh.NewOption("Core - Install all essential packages", "core"),
 					huh.NewOption("Essentials - Install common developer extras", "essentials"),
 					huh.NewOption("Unverified - Select individual packages", "unverified"),
@@ -224,6 +350,13 @@ func SelectCategory() (string, error) {
 }
 
 func LoadPlugins(category string) ([]ToolPlugin, func(), error) {
+	if category == CategoryAll || category == CategoryOmakase {
+		return LoadAllPlugins()
+	}
+	if category == CategoryUser {
+		return loadUserPlugins(UserPluginDir())
+	}
+
 	tmpDir, err := os.MkdirTemp("", "itamae-scripts-")
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create temp dir: %w", err)
@@ -242,8 +375,8 @@ func LoadPlugins(category string) ([]ToolPlugin, func(), error) {
 	}
 
 	for _, file := range files {
-		if file.IsDir() {
-			continue
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".sh") {
+			continue // Manifests are read alongside their script
 		}
 
 		plugin, err := processPluginFile(file, tmpDir, category)
@@ -256,6 +389,59 @@ func LoadPlugins(category string) ([]ToolPlugin, func(), error) {
 	return plugins, cleanup, nil
 }
 
+// LoadAllPlugins loads the plugins of every category, followed by the user's
+// own plugins. A user plugin replaces a built-in plugin with the same ID.
+func LoadAllPlugins() ([]ToolPlugin, func(), error) {
+	var all []ToolPlugin
+	var cleanups []func()
+
+	cleanup := func() {
+		for _, c := range cleanups {
+			c()
+		}
+	}
+
+	for _, category := range Categories {
+		plugins, c, err := LoadPlugins(category)
+		if c != nil {
+			cleanups = append(cleanups, c)
+		}
+		if err != nil {
+			return nil, cleanup, err
+		}
+		all = append(all, plugins...)
+	}
+
+	userPlugins, c, err := loadUserPlugins(UserPluginDir())
+	if c != nil {
+		cleanups = append(cleanups, c)
+	}
+	if err != nil {
+		return nil, cleanup, err
+	}
+
+	return mergeUserPlugins(all, userPlugins), cleanup, nil
+}
+
+// FindPlugins returns the plugins matching the given IDs, in the order requested.
+func FindPlugins(plugins []ToolPlugin, ids []string) ([]ToolPlugin, error) {
+	byID := make(map[string]ToolPlugin, len(plugins))
+	for _, p := range plugins {
+		byID[p.ID] = p
+	}
+
+	found := []ToolPlugin{}
+	for _, id := range ids {
+		p, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown plugin %q", id)
+		}
+		found = append(found, p)
+	}
+
+	return found, nil
+}
+
 func processPluginFile(file fs.DirEntry, tmpDir string, category string) (ToolPlugin, error) {
 	fileName := file.Name()
 	scriptPath := filepath.Join(fmt.Sprintf("scripts/%s", category), fileName)
@@ -266,12 +452,29 @@ func processPluginFile(file fs.DirEntry, tmpDir string, category string) (ToolPl
 		return ToolPlugin{}, fmt.Errorf("failed to read embedded file %s: %w", scriptPath, err)
 	}
 
+	// Optional sidecar manifest
+	manifest, err := scriptsFS.ReadFile(filepath.Join(filepath.Dir(scriptPath), manifestName(fileName)))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return ToolPlugin{}, fmt.Errorf("failed to read manifest for %s: %w", fileName, err)
+	}
+
+	return processPluginContent(fileName, content, manifest, tmpDir, category)
+}
+
+// processPluginContent parses a plugin script, merged with its sidecar manifest
+// (nil if there is none), and unpacks it into tmpDir.
+func processPluginContent(fileName string, content, manifest []byte, tmpDir string, category string) (ToolPlugin, error) {
 	// Parse metadata
-	plugin, err := parseMetadata(string(content))
+	plugin, err := parsePluginMetadata(string(content), manifest, manifestName(fileName))
 	if err != nil {
-		return ToolPlugin{}, fmt.Errorf("failed to parse metadata for %s: %w", fileName, err)
+		var metaErr *MetadataError
+		if errors.As(err, &metaErr) {
+			metaErr.File = fileName
+		}
+		return ToolPlugin{}, fmt.Errorf("invalid metadata:\n%w", err)
 	}
 	plugin.ID = strings.TrimSuffix(fileName, ".sh")
+	plugin.Category = category
 
 	// Unpack script to temp directory
 	destPath := filepath.Join(tmpDir, fileName)
@@ -283,61 +486,9 @@ func processPluginFile(file fs.DirEntry, tmpDir string, category string) (ToolPl
 	return plugin, nil
 }
 
-func parseMetadata(content string) (ToolPlugin, error) {
-	plugin := ToolPlugin{}
-	scanner := bufio.NewScanner(strings.NewReader(content))
-	for scanner.Scan() {
-		line := scanner.Text()
-		if !strings.HasPrefix(line, "#") {
-			continue // Stop parsing metadata once we hit code
-		}
-
-		parts := strings.SplitN(line, ":", 2)
-		if len(parts) != 2 {
-			continue
-		}
-
-		key := strings.TrimSpace(strings.TrimPrefix(parts[0], "#"))
-		value := strings.TrimSpace(parts[1])
-
-		switch key {
-		case "NAME":
-			plugin.Name = value
-		case "OMAKASE":
-			plugin.Omakase = (value == "true")
-		case "DESCRIPTION":
-			plugin.Description = value
-		case "INSTALL_METHOD":
-			plugin.InstallMethod = value
-		case "PACKAGE_NAME":
-			plugin.PackageName = value
-		case "REPO_SETUP":
-			plugin.RepoSetup = value
-		case "POST_INSTALL":
-			plugin.PostInstall = value
-		case "REQUIRES":
-			parts := strings.SplitN(value, "|", 3)
-			if len(parts) >= 2 {
-				input := Input{
-					Name:   parts[0],
-					Prompt: parts[1],
-				}
-				if len(parts) == 3 {
-					input.DefaultCmd = parts[2]
-				}
-				plugin.RequiredInputs = append(plugin.RequiredInputs, input)
-			}
-		}
-	}
-	if err := scanner.Err(); err != nil {
-		return ToolPlugin{}, fmt.Errorf("scanner error while parsing metadata: %w", err)
-	}
-	return plugin, nil
-}
-
 // batchInstallApt installs multiple APT packages in a single command using nala or apt.
 // After installation, it runs any post-install tasks defined for each plugin.
-func RunInstall(plugins []ToolPlugin, category string) {
+func RunInstall(plugins []ToolPlugin, category string, opts InstallOptions) {
 	fmt.Println("\n🚀 Starting Itamae setup...")
 
 	// Request sudo access upfront to avoid interruptions during installation
@@ -345,6 +496,10 @@ func RunInstall(plugins []ToolPlugin, category string) {
 		fmt.Println("\n❌ Failed to obtain sudo access. Installation cancelled.")
 		return
 	}
+	keepAlive := keepSudoAlive(sudoKeepAliveInterval)
+	keepAlive.Notify(func(err error) { Logger.Warnf("%v", err) })
+	defer keepAlive.Stop()
+	defer stopPrivilegedHelper()
 
 	var selectedPlugins []ToolPlugin
 	if category == "core" {
@@ -361,19 +516,10 @@ func RunInstall(plugins []ToolPlugin, category string) {
 	}
 
 	// Gather all required inputs upfront
-	requiredInputs := make(map[string]string)
-	for _, p := range selectedPlugins {
-		for _, input := range p.RequiredInputs {
-			if _, ok := requiredInputs[input.Name]; !ok {
-				defaultValue := getDefaultValue(input.DefaultCmd)
-				value, err := RunTextInput(input.Prompt, defaultValue)
-				if err != nil {
-					Logger.Errorf("Error getting input for %s: %v", input.Name, err)
-					return
-				}
-				requiredInputs[input.Name] = value
-			}
-		}
+	requiredInputs, err := gatherInputs(selectedPlugins, opts)
+	if err != nil {
+		Logger.Errorf("%v", err)
+		return
 	}
 
 	// Confirm before proceeding
@@ -382,10 +528,10 @@ func RunInstall(plugins []ToolPlugin, category string) {
 		return
 	}
 
-	processInstall(selectedPlugins, requiredInputs)
+	processInstall(selectedPlugins, requiredInputs, opts)
 }
 
-func processInstall(selectedPlugins []ToolPlugin, requiredInputs map[string]string) {
+func processInstall(selectedPlugins []ToolPlugin, requiredInputs map[string]string, opts InstallOptions) {
 	// Separate plugins by install method
 	aptPlugins := []ToolPlugin{}
 	otherPlugins := []ToolPlugin{}
@@ -402,45 +548,54 @@ func processInstall(selectedPlugins []ToolPlugin, requiredInputs map[string]stri
 	failed := []string{}
 
 	// Phase 0: Repository Setup
+	aptRepos := collectAptRepos(aptPlugins)
 	repoPlugins := []ToolPlugin{}
 	for _, p := range aptPlugins {
-		if p.RepoSetup != "" {
+		if !p.AptRepo.IsSet() && p.RepoSetup != "" {
 			repoPlugins = append(repoPlugins, p)
 		}
 	}
 
-	if len(repoPlugins) > 0 {
-		fmt.Printf("\n🔧 Setting up %d custom repositor(ies)...\n", len(repoPlugins))
+	if len(aptRepos) > 0 || len(repoPlugins) > 0 {
+		fmt.Printf("\n🔧 Setting up %d custom repositor(ies)...\n", len(aptRepos)+len(repoPlugins))
+		for _, repo := range aptRepos {
+			fmt.Printf("   • %s\n", repo.URL)
+			if _, err := writeAptRepo(repo); err != nil {
+				Logger.Errorf("❌ Error setting up repository %s: %v\n", repo.URL, err)
+				fmt.Println("\n❌ Repository setup failed. Cannot proceed with installation.")
+				return
+			}
+		}
 		for _, p := range repoPlugins {
 			fmt.Printf("   • %s\n", p.Name)
-			if err := executeScript(p, "setup_repo", requiredInputs); err != nil {
+			if err := executeScript(p, "setup_repo", opts.scriptEnv(p, requiredInputs)); err != nil {
 				Logger.Errorf("❌ Error setting up repository for %s: %v\n", p.Name, err)
 				fmt.Println("\n❌ Repository setup failed. Cannot proceed with installation.")
 				return
 			}
 		}
+	}
 
-		// Run single apt-get update after all repos are added
-		fmt.Println("\n📦 Updating package lists...")
-		var updateCmd *exec.Cmd
-		if _, err := exec.LookPath("nala"); err == nil {
-			updateCmd = exec.Command("sudo", "nala", "update")
+	// Refresh package lists only when they are missing, stale, or sources changed
+	if len(aptPlugins) > 0 {
+		if update, reason := needsAptUpdate(opts.AptMaxAge); update {
+			fmt.Printf("\n📦 Updating package lists (%s)...\n", reason)
+			output, err := updatePackageLists()
+			fmt.Print(output)
+			if err != nil {
+				Logger.Errorf("❌ Error updating package lists: %v\n", err)
+				fmt.Println("\n❌ Package list update failed. Cannot proceed with installation.")
+				return
+			}
+			fmt.Println("✅ Package lists updated.")
 		} else {
-			updateCmd = exec.Command("sudo", "apt-get", "update")
-		}
-		updateCmd.Stdout = os.Stdout
-		updateCmd.Stderr = os.Stderr
-		if err := updateCmd.Run(); err != nil {
-			Logger.Errorf("❌ Error updating package lists: %v\n", err)
-			fmt.Println("\n❌ Package list update failed. Cannot proceed with installation.")
-			return
+			fmt.Printf("\n📦 Skipping package list update: %s\n", reason)
 		}
-		fmt.Println("✅ Package lists updated.")
 	}
 
 	// Phase 1: Batch install all APT packages
 	if len(aptPlugins) > 0 {
-		if err := batchInstallApt(aptPlugins, requiredInputs); err != nil {
+		if err := batchInstallApt(aptPlugins, requiredInputs, opts); err != nil {
 			Logger.Errorf("❌ Error in batch APT installation: %v\n", err)
 			for _, p := range aptPlugins {
 				failed = append(failed, p.Name)
@@ -455,7 +610,7 @@ func processInstall(selectedPlugins []ToolPlugin, requiredInputs map[string]stri
 	// Phase 2: Install other plugins individually
 	for _, p := range otherPlugins {
 		fmt.Printf("\n▶️  Installing %s...\n", p.Name)
-		if err := executeScript(p, "install", requiredInputs); err != nil {
+		if err := executeScript(p, "install", opts.scriptEnv(p, requiredInputs)); err != nil {
 			Logger.Errorf("❌ Error installing %s: %v\n", p.Name, err)
 			failed = append(failed, p.Name)
 		} else {
@@ -478,8 +633,7 @@ func selectPlugins(plugins []ToolPlugin) []ToolPlugin {
 
 	options := []huh.Option[string]{}
 	for _, p := range plugins {
-		label := fmt.Sprintf("%s - %s", p.Name, p.Description)
-		options = append(options, huh.NewOption(label, p.ID))
+		options = append(options, huh.NewOption(optionLabel(p), p.ID))
 	}
 
 	var selectedIDs []string
@@ -488,8 +642,9 @@ func selectPlugins(plugins []ToolPlugin) []ToolPlugin {
 		huh.NewGroup(
 			huh.NewMultiSelect[string]().
 				Title("Available Tools").
-				Description("Use space to select, enter to confirm").
+				Description("Use space to select, / to filter, enter to confirm").
 				Options(options...).
+				Filterable(true).
 				Value(&selectedIDs).
 				Height(30),
 		),
@@ -517,22 +672,18 @@ func selectPlugins(plugins []ToolPlugin) []ToolPlugin {
 	return selectedPlugins
 }
 
-func batchInstallApt(plugins []ToolPlugin, env map[string]string) error {
+func batchInstallApt(plugins []ToolPlugin, env map[string]string, opts InstallOptions) error {
 	if len(plugins) == 0 {
 		return nil
 	}
 
 	fmt.Printf("\n📦 Installing %d APT package(s)\n", len(plugins))
 
-	// Check if nala is available
-	_, err := exec.LookPath("nala")
-	useNala := err == nil
-
 	// Collect package names
 	packages := []string{}
 	for _, p := range plugins {
 		if p.PackageName != "" {
-			packages = append(packages, p.PackageName)
+			packages = append(packages, aptPackageSpec(p))
 			fmt.Printf("   • %s\n", p.Name)
 		}
 	}
@@ -542,24 +693,10 @@ func batchInstallApt(plugins []ToolPlugin, env map[string]string) error {
 		return nil
 	}
 
-	// Build install command
-	var cmd *exec.Cmd
-	if useNala {
-		args := append([]string{"nala", "install", "-y"}, packages...)
-		cmd = exec.Command("sudo", args...)
-		fmt.Println()
-	} else {
-		args := append([]string{"apt", "install", "-y"}, packages...)
-		cmd = exec.Command("sudo", args...)
-		fmt.Println()
-	}
-
-	// Execute with live output
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
-
-	if err := cmd.Run(); err != nil {
+	fmt.Println()
+	resp, err := elevate(privilegedRequest{Op: opAptInstall, Packages: packages})
+	fmt.Print(resp.Output)
+	if err != nil {
 		return fmt.Errorf("batch APT installation failed: %w", err)
 	}
 
@@ -571,7 +708,7 @@ func batchInstallApt(plugins []ToolPlugin, env map[string]string) error {
 		for _, p := range plugins {
 			if p.PostInstall != "" {
 				fmt.Printf("   • %s... ", p.Name)
-				if err := executeScript(p, "post_install", env); err != nil {
+				if err := executeScript(p, "post_install", opts.scriptEnv(p, env)); err != nil {
 					fmt.Println("❌")
 				} else {
 					fmt.Println("✅")
@@ -0,0 +1,359 @@
+package itamae
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lint severities
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// LintIssue is a problem found in a plugin script.
+type LintIssue struct {
+	File     string
+	Line     int // 0 when the issue applies to the whole file
+	Severity string
+	Message  string
+}
+
+func (i LintIssue) String() string {
+	if i.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s: %s", i.File, i.Line, i.Severity, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", i.File, i.Severity, i.Message)
+}
+
+// LintOptions controls the optional lint checks.
+type LintOptions struct {
+	Shellcheck bool // Also run shellcheck when it is installed
+}
+
+// requiredRouterCases are the commands every plugin must handle.
+var requiredRouterCases = []string{"install", "remove", "check"}
+
+var (
+	functionPattern   = regexp.MustCompile(`^\s*(?:function\s+)?([A-Za-z_][A-Za-z0-9_]*)\s*\(\)`)
+	routerBodyPattern = regexp.MustCompile(`\)\s*([A-Za-z_][A-Za-z0-9_]*)`)
+	bashLinePattern   = regexp.MustCompile(`line (\d+):\s*(.*)$`)
+	gccLinePattern    = regexp.MustCompile(`^[^:]*:(\d+):\d+:\s*(\w+):\s*(.*)$`)
+	sudoPattern       = regexp.MustCompile(`(^|[\s;&|(])sudo\s`)
+)
+
+// routerShellWords are commands a router case may call that aren't plugin functions.
+var routerShellWords = map[string]bool{"echo": true, "exit": true, "return": true, "true": true, "false": true}
+
+// LintPath lints a plugin script, or every .sh file under a directory.
+func LintPath(path string, opts LintOptions) ([]LintIssue, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files = nil
+		err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(p, ".sh") {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to walk %s: %w", path, err)
+		}
+	}
+
+	issues := []LintIssue{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		manifest, err := readManifest(file)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, LintScript(file, string(content), manifest, opts)...)
+	}
+	return issues, nil
+}
+
+// LintEmbedded lints the plugin scripts built into the binary.
+func LintEmbedded(opts LintOptions) ([]LintIssue, error) {
+	issues := []LintIssue{}
+	for _, category := range Categories {
+		dir := "scripts/" + category
+		files, err := scriptsFS.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read embedded scripts dir: %w", err)
+		}
+		for _, file := range files {
+			if file.IsDir() || !strings.HasSuffix(file.Name(), ".sh") {
+				continue
+			}
+			name := dir + "/" + file.Name()
+			content, err := scriptsFS.ReadFile(name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read embedded file %s: %w", name, err)
+			}
+			manifest, _ := scriptsFS.ReadFile(dir + "/" + manifestName(file.Name()))
+			issues = append(issues, LintScript(name, string(content), manifest, opts)...)
+		}
+	}
+	return issues, nil
+}
+
+// LintScript checks a plugin script's metadata (merged with its sidecar
+// manifest, nil if there is none), functions and router, and runs `bash -n`
+// (and optionally shellcheck) over it.
+func LintScript(name, content string, manifest []byte, opts LintOptions) []LintIssue {
+	l := &linter{file: name}
+	lines := strings.Split(content, "\n")
+
+	plugin, keyLines := l.lintMetadata(content, manifest)
+	functions := declaredFunctions(lines)
+	l.lintRouter(lines, plugin, keyLines, functions)
+	l.lintSudo(lines, plugin)
+	l.lintBinaries(content, plugin)
+	l.lintSyntax(content)
+	if opts.Shellcheck {
+		l.lintShellcheck(content)
+	}
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		return l.issues[i].Line < l.issues[j].Line
+	})
+	return l.issues
+}
+
+type linter struct {
+	file   string
+	issues []LintIssue
+}
+
+func (l *linter) add(line int, severity, format string, args ...any) {
+	l.issues = append(l.issues, LintIssue{
+		File:     l.file,
+		Line:     line,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// lintMetadata checks the header against the metadata schema and returns the
+// parsed plugin with the line each key was declared on.
+func (l *linter) lintMetadata(content string, manifest []byte) (ToolPlugin, map[string]int) {
+	plugin, lines, issues, unknown, err := scanMetadata(content, manifest, manifestName(filepath.Base(l.file)))
+	if err != nil {
+		l.add(0, LintError, "%v", err)
+		return plugin, lines
+	}
+	// Loading ignores unknown keys, but here they are most likely typos
+	for _, issue := range append(issues, unknown...) {
+		message := issue.Message
+		if issue.File != "" {
+			message = issue.File + ": " + message
+		}
+		l.add(issue.Line, LintError, "%s", message)
+	}
+
+	if plugin.InstallMethod != "" && plugin.InstallMethod != "apt" {
+		for _, key := range []string{"PACKAGE_NAME", "APT_REPO", "REPO_SETUP"} {
+			if line, ok := lines[key]; ok {
+				l.add(line, LintWarning, "%s is only used by apt plugins", key)
+			}
+		}
+	}
+
+	return plugin, lines
+}
+
+// declaredFunctions returns the shell functions defined in the script and their lines.
+func declaredFunctions(lines []string) map[string]int {
+	functions := map[string]int{}
+	for i, line := range lines {
+		if match := functionPattern.FindStringSubmatch(line); match != nil {
+			if _, seen := functions[match[1]]; !seen {
+				functions[match[1]] = i + 1
+			}
+		}
+	}
+	return functions
+}
+
+// lintRouter checks the `case "$1" in` router against the declared functions.
+func (l *linter) lintRouter(lines []string, plugin ToolPlugin, keyLines map[string]int, functions map[string]int) {
+	cases := map[string]int{}
+	routerLine := 0
+
+	for i, line := range lines {
+		n := i + 1
+		trimmed := strings.TrimSpace(line)
+		if routerLine == 0 {
+			if strings.HasPrefix(trimmed, `case "$1" in`) {
+				routerLine = n
+			}
+			continue
+		}
+		if trimmed == "esac" {
+			break
+		}
+
+		match := routerCasePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		for _, label := range strings.Split(match[1], "|") {
+			cases[label] = n
+		}
+
+		body := routerBodyPattern.FindStringSubmatch(line)
+		if body == nil || routerShellWords[body[1]] {
+			continue
+		}
+		if _, ok := functions[body[1]]; !ok {
+			l.add(n, LintError, "router case %s calls undefined function %s", match[1], body[1])
+		}
+	}
+
+	if routerLine == 0 {
+		l.add(0, LintError, `missing router: no 'case "$1" in' block`)
+		return
+	}
+
+	for _, command := range requiredRouterCases {
+		if _, ok := cases[command]; !ok {
+			l.add(routerLine, LintError, "router has no %s case", command)
+		}
+	}
+
+	// Repository setup and post-install hooks are invoked through fixed router commands
+	hooks := []struct{ key, function, command string }{
+		{"REPO_SETUP", plugin.RepoSetup, "setup_repo"},
+		{"POST_INSTALL", plugin.PostInstall, "post_install"},
+	}
+	for _, hook := range hooks {
+		if hook.function == "" {
+			continue
+		}
+		line := keyLines[hook.key]
+		if _, defined := functions[hook.function]; !defined {
+			l.add(line, LintError, "%s names function %s, which is not defined", hook.key, hook.function)
+		}
+		if _, routed := cases[hook.command]; !routed {
+			l.add(line, LintError, "%s is declared but the router has no %s case", hook.key, hook.command)
+		}
+	}
+}
+
+// lintSudo rejects sudo in plugin steps. itamae runs every step as the
+// invoking user and performs root work itself, through the privileged helper,
+// for the steps metadata declares: PACKAGE_NAME, APT_REPO and BINARY. The only
+// exception is an apt plugin's install function, which itamae never runs
+// because it installs PACKAGE_NAME in its batch.
+func (l *linter) lintSudo(lines []string, plugin ToolPlugin) {
+	step := ""
+	for i, line := range lines {
+		if match := functionPattern.FindStringSubmatch(line); match != nil {
+			step = match[1]
+		}
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "#") && sudoPattern.MatchString(trimmed) {
+			switch {
+			case plugin.InstallMethod == "apt" && step == "install":
+			case !plugin.RequiresRoot:
+				l.add(i+1, LintError, "sudo is not available in --user mode, but the plugin declares REQUIRES_ROOT: false")
+			case step == "":
+				l.add(i+1, LintError, "sudo outside a step; itamae performs root work through its privileged helper")
+			default:
+				l.add(i+1, LintError, "%s runs sudo; declare the root work with PACKAGE_NAME, APT_REPO or BINARY so itamae's privileged helper performs it", step)
+			}
+		}
+		if strings.HasPrefix(line, "}") || (step != "" && strings.HasSuffix(trimmed, "}") && functionPattern.MatchString(line)) {
+			step = ""
+		}
+	}
+}
+
+// lintBinaries checks that plugins declaring BINARY stage their files for itamae.
+func (l *linter) lintBinaries(content string, plugin ToolPlugin) {
+	if len(plugin.Binaries) > 0 && !strings.Contains(content, "ITAMAE_STAGE_DIR") {
+		l.add(0, LintWarning, "BINARY is declared but the script never uses $ITAMAE_STAGE_DIR")
+	}
+}
+
+// lintSyntax runs `bash -n` over the script.
+func (l *linter) lintSyntax(content string) {
+	cmd := exec.Command("bash", "-n")
+	cmd.Stdin = strings.NewReader(content)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err == nil {
+		return
+	}
+
+	scanner := bufio.NewScanner(&stderr)
+	reported := false
+	for scanner.Scan() {
+		if match := bashLinePattern.FindStringSubmatch(scanner.Text()); match != nil {
+			line, _ := strconv.Atoi(match[1])
+			l.add(line, LintError, "bash: %s", match[2])
+			reported = true
+		}
+	}
+	if !reported {
+		l.add(0, LintError, "bash -n failed: %s", strings.TrimSpace(stderr.String()))
+	}
+}
+
+// lintShellcheck runs shellcheck over the script when it is installed.
+func (l *linter) lintShellcheck(content string) {
+	if _, err := exec.LookPath("shellcheck"); err != nil {
+		l.add(0, LintWarning, "shellcheck not found in PATH, skipping")
+		return
+	}
+
+	cmd := exec.Command("shellcheck", "--format=gcc", "--shell=bash", "-")
+	cmd.Stdin = strings.NewReader(content)
+	output, _ := cmd.Output() // Exits non-zero when it reports anything
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		match := gccLinePattern.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(match[1])
+		severity := LintWarning
+		if match[2] == "error" {
+			severity = LintError
+		}
+		l.add(line, severity, "shellcheck: %s", match[3])
+	}
+}
+
+// HasLintErrors reports whether any issue is an error rather than a warning.
+func HasLintErrors(issues []LintIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == LintError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package itamae
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const validLintScript = `#!/bin/bash
+#
+# METADATA
+# NAME: Example
+# DESCRIPTION: An example plugin.
+# INSTALL_METHOD: apt
+# PACKAGE_NAME: example
+# POST_INSTALL: post_install
+# REQUIRES: EXAMPLE_TOKEN|Enter a token
+#
+
+install() { sudo apt-get install -y example; }
+remove() { echo "removed"; }
+check() { command -v example &> /dev/null; }
+post_install() { echo "done"; }
+
+# --- ROUTER ---
+case "$1" in
+    install) install ;;
+    remove) remove ;;
+    check) check ;;
+    post_install) post_install ;;
+    *) echo "Usage: $0 {install|remove|check|post_install}" && exit 1 ;;
+esac
+`
+
+func TestLintScriptValid(t *testing.T) {
+	if issues := LintScript("example.sh", validLintScript, nil, LintOptions{}); len(issues) != 0 {
+		t.Errorf("expected no issues, got %v", issues)
+	}
+}
+
+func TestLintScriptReportsProblems(t *testing.T) {
+	script := `#!/bin/bash
+# NAME: Broken
+# DESCRIPTION: A broken plugin.
+# INSTALL_METHOD: apt
+# REPO_SETUP: setup_repo
+# REQUIRES: missing prompt
+# COLOUR: blue
+#
+
+install() { echo install; }
+remove() { echo remove; }
+
+case "$1" in
+    install) install ;;
+    remove) remove ;;
+    update) update ;;
+esac
+`
+
+	issues := LintScript("broken.sh", script, nil, LintOptions{})
+
+	expected := []struct {
+		line    int
+		message string
+	}{
+		{4, "requires PACKAGE_NAME"},
+		{5, "setup_repo, which is not defined"},
+		{5, "no setup_repo case"},
+		{6, "REQUIRES must be"},
+		{7, "unknown metadata key COLOUR"},
+		{13, "no check case"},
+		{16, "undefined function update"},
+	}
+
+	for _, want := range expected {
+		found := false
+		for _, issue := range issues {
+			if issue.Line == want.line && strings.Contains(issue.Message, want.message) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("expected issue on line %d containing %q, got %v", want.line, want.message, issues)
+		}
+	}
+
+	if !HasLintErrors(issues) {
+		t.Error("expected lint errors")
+	}
+}
+
+func TestLintRejectsSudoInSteps(t *testing.T) {
+	script := `#!/bin/bash
+# NAME: Example
+# DESCRIPTION: An example.
+# INSTALL_METHOD: apt
+# PACKAGE_NAME: example
+# REPO_SETUP: setup_repo
+
+setup_repo() {
+    curl -fsSL https://example.com/setup | sudo -E bash -
+}
+install() { sudo apt-get install -y example; }
+remove() {
+    sudo rm -f /etc/apt/sources.list.d/example.list
+}
+check() { command -v example; }
+
+case "$1" in
+    setup_repo) setup_repo ;;
+    install) install ;;
+    remove) remove ;;
+    check) check ;;
+esac
+`
+	issues := LintScript("example.sh", script, nil, LintOptions{})
+	if len(issues) != 2 || issues[0].Line != 9 || issues[1].Line != 13 || !HasLintErrors(issues) {
+		t.Errorf("expected errors for sudo in setup_repo and remove only, got %v", issues)
+	}
+	if !strings.Contains(issues[0].Message, "setup_repo runs sudo") {
+		t.Errorf("expected the error to name the step, got %q", issues[0].Message)
+	}
+}
+
+func TestLintScriptSyntaxError(t *testing.T) {
+	script := strings.Replace(validLintScript, "post_install() { echo \"done\"; }", "post_install() { if true; then echo; }", 1)
+
+	issues := LintScript("syntax.sh", script, nil, LintOptions{})
+	found := false
+	for _, issue := range issues {
+		if strings.HasPrefix(issue.Message, "bash:") && issue.Line > 0 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected a bash syntax error with a line number, got %v", issues)
+	}
+}
+
+func TestLintPathDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "example.sh"), []byte(validLintScript), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "empty.sh"), []byte("#!/bin/bash\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := LintPath(dir, LintOptions{})
+	if err != nil {
+		t.Fatalf("LintPath failed: %v", err)
+	}
+	for _, issue := range issues {
+		if filepath.Base(issue.File) != "empty.sh" {
+			t.Errorf("unexpected issue in %s: %s", issue.File, issue)
+		}
+	}
+	if !HasLintErrors(issues) {
+		t.Error("expected errors for empty.sh")
+	}
+}
+
+func TestEmbeddedPluginsLintClean(t *testing.T) {
+	issues, err := LintEmbedded(LintOptions{})
+	if err != nil {
+		t.Fatalf("LintEmbedded failed: %v", err)
+	}
+	for _, issue := range issues {
+		t.Errorf("%s", issue)
+	}
+}
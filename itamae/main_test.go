@@ -31,62 +31,62 @@ var pluginAssertions = map[string]pluginAssertion{
 	// Core plugins (OMAKASE: true)
 	"ansible":             {install: "pipx install", remove: "pipx uninstall ansible"},
 	"bin":                 {install: "curl -sL", remove: "rm -f"},
-	"btop":                {install: "sudo nala install -y btop", remove: "sudo apt-get purge -y btop"},
-	"httpie":              {install: "sudo nala install -y httpie", remove: "sudo apt-get purge -y httpie"},
-	"pass":                {install: "sudo nala install -y pass", remove: "sudo apt-get purge -y pass"},
-	"ruby":                {install: "sudo nala install -y ruby-full", remove: "sudo apt-get purge -y ruby-full"},
+	"btop":                {install: "sudo nala install -y btop", remove: ""},
+	"httpie":              {install: "sudo nala install -y httpie", remove: ""},
+	"pass":                {install: "sudo nala install -y pass", remove: ""},
+	"ruby":                {install: "sudo nala install -y ruby-full", remove: ""},
 	"semgrep":             {install: "pipx install semgrep", remove: "pipx uninstall semgrep"},
 	"tldr":                {install: "curl -L https://github.com/tealdeer-rs/tealdeer/releases/latest/download/tealdeer-linux-x86_64-musl", remove: "rm"},
-	"vscode":              {install: "sudo apt-get install -y /tmp/vscode-itamae.deb", remove: "sudo apt-get purge -y code"},
-	"helm":                {install: "curl --silent", remove: "sudo rm -f /usr/local/bin/helm"},
-	"kubectl":             {install: "curl -sLO", remove: "sudo rm -f /usr/local/bin/kubectl"},
+	"vscode":              {install: "sudo nala install -y code", remove: ""},
+	"helm":                {install: "curl --silent", remove: ""},
+	"kubectl":             {install: "curl -sL", remove: ""},
 	"task":                {install: "curl --silent", remove: "rm -f"},
-	"alacritty":           {install: "sudo nala install -y alacritty", remove: "sudo apt-get purge -y alacritty"},
-	"dotnet-sdk-8.0":      {install: "sudo nala install -y dotnet-sdk-8.0", remove: "sudo apt-get purge -y dotnet-sdk-8.0"},
-	"jq":                  {install: "sudo nala install -y jq", remove: "sudo apt-get purge -y jq"},
+	"alacritty":           {install: "sudo nala install -y alacritty", remove: ""},
+	"dotnet-sdk-8.0":      {install: "sudo nala install -y dotnet-sdk-8.0", remove: ""},
+	"jq":                  {install: "sudo nala install -y jq", remove: ""},
 	"kubecolor":           {install: "curl -s", remove: "rm -f"},
-	"lsd":                 {install: "sudo nala install -y lsd", remove: "sudo apt-get purge -y lsd"},
-	"nodejs":              {install: "sudo nala install -y nodejs", remove: "sudo apt-get purge -y nodejs"},
-	"npm":                 {install: "sudo nala install -y npm", remove: "sudo apt-get purge -y npm"},
-	"python3-full":        {install: "sudo nala install -y python3-full", remove: "sudo apt-get purge -y python3-full"},
-	"pipx":                {install: "sudo nala install -y pipx", remove: "sudo apt-get purge -y pipx"},
-	"wget":                {install: "sudo nala install -y wget", remove: "sudo apt-get purge -y wget"},
-	"wireguard":           {install: "sudo nala install -y wireguard", remove: "sudo apt-get purge -y wireguard"},
-	"yq":                  {install: "sudo curl --silent", remove: "sudo rm /usr/local/bin/yq"},
-	"curl":                {install: "sudo nala install -y curl", remove: "sudo apt-get purge -y curl"},
-	"apt-transport-https": {install: "sudo nala install -y apt-transport-https", remove: "sudo apt-get purge -y apt-transport-https"},
-	"ca-certificates":     {install: "sudo nala install -y ca-certificates", remove: "sudo apt-get purge -y ca-certificates"},
-	"gnupg":               {install: "sudo nala install -y gnupg", remove: "sudo apt-get purge -y gnupg"},
-	"nala":                {install: "sudo apt-get install -y nala", remove: "sudo apt-get purge -y nala"},
-	"fd":                  {install: "sudo nala install -y fd-find", remove: "sudo apt-get purge -y fd-find"},
-	"fzf":                 {install: "sudo nala install -y fzf", remove: "sudo apt-get purge -y fzf"},
-	"gh":                  {install: "sudo nala install -y gh", remove: "sudo apt-get purge -y gh"},
+	"lsd":                 {install: "sudo nala install -y lsd", remove: ""},
+	"nodejs":              {install: "sudo nala install -y nodejs", remove: ""},
+	"npm":                 {install: "sudo nala install -y npm", remove: ""},
+	"python3-full":        {install: "sudo nala install -y python3-full", remove: ""},
+	"pipx":                {install: "sudo nala install -y pipx", remove: ""},
+	"wget":                {install: "sudo nala install -y wget", remove: ""},
+	"wireguard":           {install: "sudo nala install -y wireguard", remove: ""},
+	"yq":                  {install: "curl --silent", remove: ""},
+	"curl":                {install: "sudo nala install -y curl", remove: ""},
+	"apt-transport-https": {install: "sudo nala install -y apt-transport-https", remove: ""},
+	"ca-certificates":     {install: "sudo nala install -y ca-certificates", remove: ""},
+	"gnupg":               {install: "sudo nala install -y gnupg", remove: ""},
+	"nala":                {install: "sudo apt-get install -y nala", remove: ""},
+	"fd":                  {install: "sudo nala install -y fd-find", remove: "rm -f"},
+	"fzf":                 {install: "sudo nala install -y fzf", remove: ""},
+	"gh":                  {install: "sudo nala install -y gh", remove: ""},
 
 	// Essentials plugins (common developer extras)
-	"stow":     {install: "sudo nala install -y stow", remove: "sudo apt-get purge -y stow"},
-	"ripgrep":  {install: "sudo nala install -y ripgrep", remove: "sudo apt-get purge -y ripgrep"},
-	"bat":      {install: "sudo nala install -y bat", remove: "sudo apt-get purge -y bat"},
+	"stow":     {install: "sudo nala install -y stow", remove: ""},
+	"ripgrep":  {install: "sudo nala install -y ripgrep", remove: ""},
+	"bat":      {install: "sudo nala install -y bat", remove: ""},
 	"zoxide":   {install: "curl -sS https://raw.githubusercontent.com/ajeetdsouza/zoxide/main/install.sh", remove: "rm"},
 	"starship": {install: "curl -sS https://starship.rs/install.sh", remove: "sh -c rm \"$(command -v starship)\""},
 	"atuin":    {install: "bash", remove: "bash -s -- --uninstall"},
 	"rust":     {install: "curl --proto", remove: "rustup self uninstall -y"},
 	"sdkman":   {install: "curl -s", remove: "rm -rf"},
-	"java":     {install: "sudo nala install -y temurin-21-jdk", remove: "sudo apt-get purge -y temurin-21-jdk"},
-	"maven":    {install: "wget", remove: "sudo rm -rf /opt/maven"},
+	"java":     {install: "sudo nala install -y temurin-21-jdk", remove: ""},
+	"maven":    {install: "sudo nala install -y maven", remove: ""},
 
 	// À la carte plugins (OMAKASE: false)
-	"btop-desktop":  {install: "sudo nala install -y btop", remove: "sudo apt-get purge -y btop"},
+	"btop-desktop":  {install: "sudo nala install -y btop", remove: ""},
 	"cascadia-code": {install: "mkdir -p", remove: "rm -f"},
 	"chezmoi":       {install: "sh -c", remove: "rm"},
-	"dunst":         {install: "sudo nala install -y dunst", remove: "sudo apt-get purge -y dunst"},
-	"flameshot":     {install: "sudo nala install -y flameshot", remove: "sudo apt-get purge -y flameshot"},
+	"dunst":         {install: "sudo nala install -y dunst", remove: ""},
+	"flameshot":     {install: "sudo nala install -y flameshot", remove: ""},
 	"ghostty":       {install: "mkdir -p", remove: "rm -f"},
-	"meld":          {install: "sudo nala install -y meld", remove: "sudo apt-get purge -y meld"},
-	"ncdu":          {install: "sudo nala install -y ncdu", remove: "sudo apt-get purge -y ncdu"},
-	"polybar":       {install: "sudo nala install -y polybar", remove: "sudo apt-get purge -y polybar"},
-	"rofi":          {install: "sudo nala install -y rofi", remove: "sudo apt-get purge -y rofi"},
-	"zellij":        {install: "curl -L https://github.com/zellij-project/zellij/releases/latest/download/zellij-x86_64-unknown-linux-musl.tar.gz", remove: "sudo rm /usr/local/bin/zellij"},
-	"zsh":           {install: "sudo nala install -y zsh", remove: "sudo apt-get purge -y zsh"},
+	"meld":          {install: "sudo nala install -y meld", remove: ""},
+	"ncdu":          {install: "sudo nala install -y ncdu", remove: ""},
+	"polybar":       {install: "sudo nala install -y polybar", remove: ""},
+	"rofi":          {install: "sudo nala install -y rofi", remove: ""},
+	"zellij":        {install: "curl -L https://github.com/zellij-project/zellij/releases/latest/download/zellij-x86_64-unknown-linux-musl.tar.gz", remove: ""},
+	"zsh":           {install: "sudo nala install -y zsh", remove: ""},
 } // TestMain sets up the test environment for the entire package.
 func TestMain(m *testing.M) {
 	var cleanupPlugins func()
@@ -240,7 +240,7 @@ func TestMetadataParsing(t *testing.T) {
 		"semgrep":             "binary",
 		"task":                "binary",
 		"tldr":                "binary",
-		"vscode":              "binary",
+		"vscode":              "apt",
 		"wget":                "apt",
 		"wireguard":           "apt",
 		"yq":                  "binary",
@@ -255,7 +255,7 @@ func TestMetadataParsing(t *testing.T) {
 		"rust":     "binary",
 		"sdkman":   "binary",
 		"java":     "apt",
-		"maven":    "binary",
+		"maven":    "apt",
 
 		// À la carte plugins (OMAKASE: false)
 		"btop-desktop":  "apt",
@@ -303,6 +303,7 @@ func TestMetadataParsing(t *testing.T) {
 		"ripgrep": "ripgrep",
 		"bat":     "bat",
 		"java":    "temurin-21-jdk",
+		"maven":   "maven",
 
 		// À la carte plugins
 		"btop-desktop": "btop",
@@ -312,6 +313,7 @@ func TestMetadataParsing(t *testing.T) {
 		"ncdu":         "ncdu",
 		"polybar":      "polybar",
 		"rofi":         "rofi",
+		"vscode":       "code",
 		"zsh":          "zsh",
 	}
 
@@ -0,0 +1,319 @@
+package itamae
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest is the optional YAML sidecar (<id>.yaml) next to a plugin script.
+// It carries the same metadata as the comment header, plus structured data the
+// header can't express well, such as per-distro package names.
+type Manifest struct {
+	MetadataVersion int                `yaml:"metadata_version,omitempty"`
+	Name            string             `yaml:"name,omitempty"`
+	Description     string             `yaml:"description,omitempty"`
+	Tags            []string           `yaml:"tags,omitempty"`
+	Omakase         *bool              `yaml:"omakase,omitempty"`
+	RequiresRoot    *bool              `yaml:"requires_root,omitempty"`
+	InstallMethod   string             `yaml:"install_method,omitempty"`
+	PackageName     string             `yaml:"package_name,omitempty"`
+	Packages        map[string]string  `yaml:"packages,omitempty"` // Package name per distro ID, e.g. ubuntu: fd-find
+	Version         string             `yaml:"version,omitempty"`
+	LatestCmd       string             `yaml:"latest_cmd,omitempty"`
+	AptRepo         *ManifestAptRepo   `yaml:"apt_repo,omitempty"`
+	RepoSetup       string             `yaml:"repo_setup,omitempty"`
+	PostInstall     string             `yaml:"post_install,omitempty"`
+	Artifacts       []ManifestArtifact `yaml:"artifacts,omitempty"`
+	Binaries        []string           `yaml:"binaries,omitempty"`
+	LegacyPaths     []string           `yaml:"legacy_paths,omitempty"`
+	Requires        []ManifestInput    `yaml:"requires,omitempty"`
+}
+
+// ManifestAptRepo is the manifest form of APT_REPO and APT_KEY_URL.
+type ManifestAptRepo struct {
+	URL        string   `yaml:"url"`
+	Suite      string   `yaml:"suite"`
+	Components []string `yaml:"components"`
+	KeyURL     string   `yaml:"key_url,omitempty"`
+}
+
+// ManifestArtifact is the manifest form of ARTIFACT.
+type ManifestArtifact struct {
+	Name   string `yaml:"name"`
+	URL    string `yaml:"url"`
+	SHA256 string `yaml:"sha256,omitempty"`
+}
+
+// ManifestInput is the manifest form of REQUIRES, INPUT_PATTERN and INPUT_HELP.
+type ManifestInput struct {
+	Name       string `yaml:"name"`
+	Prompt     string `yaml:"prompt"`
+	DefaultCmd string `yaml:"default_cmd,omitempty"`
+	Type       string `yaml:"type,omitempty"` // e.g. email or choice:a,b,c
+	Pattern    string `yaml:"pattern,omitempty"`
+	Help       string `yaml:"help,omitempty"`
+}
+
+// manifestName returns the sidecar file name for a script file name.
+func manifestName(scriptName string) string {
+	return strings.TrimSuffix(scriptName, ".sh") + ".yaml"
+}
+
+// readManifest reads the sidecar next to a script on disk, returning nil if there is none.
+func readManifest(scriptPath string) ([]byte, error) {
+	path := filepath.Join(filepath.Dir(scriptPath), manifestName(filepath.Base(scriptPath)))
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// applyManifest decodes a manifest and applies it on top of the header values.
+// Keys set in the manifest are recorded in seen with line 0.
+func applyManifest(plugin *ToolPlugin, seen map[string]int, data []byte, name string) []MetadataIssue {
+	issues := []MetadataIssue{}
+	report := func(format string, args ...any) {
+		issues = append(issues, MetadataIssue{File: name, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var m Manifest
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&m); err != nil {
+		report("invalid manifest: %v", err)
+		return issues
+	}
+
+	set := func(key, value string) {
+		if value == "" {
+			return
+		}
+		seen[key] = 0
+		if err := metadataSchema[key].Apply(plugin, value); err != nil {
+			report("%v", err)
+		}
+	}
+
+	if m.MetadataVersion != 0 {
+		set("METADATA_VERSION", strconv.Itoa(m.MetadataVersion))
+	}
+	set("NAME", m.Name)
+	set("DESCRIPTION", m.Description)
+	if len(m.Tags) > 0 {
+		set("TAGS", strings.Join(m.Tags, ","))
+	}
+	if m.Omakase != nil {
+		set("OMAKASE", strconv.FormatBool(*m.Omakase))
+	}
+	if m.RequiresRoot != nil {
+		set("REQUIRES_ROOT", strconv.FormatBool(*m.RequiresRoot))
+	}
+	set("INSTALL_METHOD", m.InstallMethod)
+	set("PACKAGE_NAME", resolvePackage(m.PackageName, m.Packages, distroIDs()))
+	set("VERSION", m.Version)
+	set("LATEST_CMD", m.LatestCmd)
+	set("REPO_SETUP", m.RepoSetup)
+	set("POST_INSTALL", m.PostInstall)
+
+	if m.AptRepo != nil {
+		if len(m.AptRepo.Components) == 0 {
+			report("apt_repo needs at least one component")
+		} else {
+			set("APT_REPO", strings.Join(append([]string{m.AptRepo.URL, m.AptRepo.Suite}, m.AptRepo.Components...), " "))
+		}
+		set("APT_KEY_URL", m.AptRepo.KeyURL)
+	}
+
+	if len(m.Artifacts) > 0 {
+		seen["ARTIFACT"] = 0
+		plugin.Artifacts = nil
+		for _, a := range m.Artifacts {
+			artifact := Artifact{Name: a.Name, URL: a.URL, SHA256: a.SHA256}
+			if err := artifact.validate(); err != nil {
+				report("%v", err)
+				continue
+			}
+			plugin.Artifacts = append(plugin.Artifacts, artifact)
+		}
+	}
+
+	if len(m.Binaries) > 0 {
+		plugin.Binaries = nil
+		for _, name := range m.Binaries {
+			set("BINARY", name)
+		}
+	}
+
+	if len(m.LegacyPaths) > 0 {
+		plugin.LegacyPaths = nil
+		for _, path := range m.LegacyPaths {
+			set("LEGACY_PATH", path)
+		}
+	}
+
+	if len(m.Requires) > 0 {
+		seen["REQUIRES"] = 0
+		plugin.RequiredInputs = nil
+		for _, r := range m.Requires {
+			input := Input{Name: r.Name, Prompt: r.Prompt, DefaultCmd: r.DefaultCmd, Pattern: r.Pattern, Help: r.Help}
+			kind, choices, err := parseInputType(r.Type)
+			if err != nil {
+				report("requires %s: %v", r.Name, err)
+				continue
+			}
+			input.Type, input.Choices = kind, choices
+			if err := validateInput(input); err != nil {
+				report("%v", err)
+				continue
+			}
+			plugin.RequiredInputs = append(plugin.RequiredInputs, input)
+		}
+	}
+
+	return issues
+}
+
+// resolvePackage picks the package name for the first matching distro ID,
+// falling back to the default name.
+func resolvePackage(defaultName string, packages map[string]string, ids []string) string {
+	for _, id := range ids {
+		if name, ok := packages[id]; ok {
+			return name
+		}
+	}
+	return defaultName
+}
+
+// ManifestFromPlugin converts parsed metadata into a manifest.
+func ManifestFromPlugin(p ToolPlugin) Manifest {
+	m := Manifest{
+		MetadataVersion: MetadataVersion,
+		Name:            p.Name,
+		Description:     p.Description,
+		Tags:            p.Tags,
+		InstallMethod:   p.InstallMethod,
+		PackageName:     p.PackageName,
+		Version:         p.Version,
+		LatestCmd:       p.LatestCmd,
+		RepoSetup:       p.RepoSetup,
+		PostInstall:     p.PostInstall,
+		Binaries:        p.Binaries,
+		LegacyPaths:     p.LegacyPaths,
+	}
+	if p.Omakase {
+		omakase := true
+		m.Omakase = &omakase
+	}
+	if !p.RequiresRoot {
+		requiresRoot := false
+		m.RequiresRoot = &requiresRoot
+	}
+	if p.AptRepo.IsSet() {
+		m.AptRepo = &ManifestAptRepo{
+			URL:        p.AptRepo.URL,
+			Suite:      p.AptRepo.Suite,
+			Components: p.AptRepo.Components,
+			KeyURL:     p.AptRepo.KeyURL,
+		}
+	}
+	for _, a := range p.Artifacts {
+		m.Artifacts = append(m.Artifacts, ManifestArtifact{Name: a.Name, URL: a.URL, SHA256: a.SHA256})
+	}
+	for _, input := range p.RequiredInputs {
+		mi := ManifestInput{Name: input.Name, Prompt: input.Prompt, DefaultCmd: input.DefaultCmd, Pattern: input.Pattern, Help: input.Help}
+		if input.kind() != InputString {
+			mi.Type = input.TypeSpec()
+		}
+		m.Requires = append(m.Requires, mi)
+	}
+	return m
+}
+
+// MigrateOptions controls MigrateScript.
+type MigrateOptions struct {
+	KeepHeader bool // Leave the comment header in the script
+	Force      bool // Overwrite an existing manifest
+}
+
+// MigrateScript converts a script's comment header into a sidecar manifest and
+// strips the metadata keys from the header. It returns the manifest path.
+func MigrateScript(scriptPath string, opts MigrateOptions) (string, error) {
+	content, err := os.ReadFile(scriptPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", scriptPath, err)
+	}
+
+	existing, err := readManifest(scriptPath)
+	if err != nil {
+		return "", err
+	}
+	manifestPath := filepath.Join(filepath.Dir(scriptPath), manifestName(filepath.Base(scriptPath)))
+	if existing != nil && !opts.Force {
+		return "", fmt.Errorf("%s already exists (use --force to overwrite)", manifestPath)
+	}
+
+	plugin, err := parseMetadata(string(content))
+	if err != nil {
+		var metaErr *MetadataError
+		if errors.As(err, &metaErr) {
+			metaErr.File = scriptPath
+		}
+		return "", fmt.Errorf("cannot migrate invalid metadata:\n%w", err)
+	}
+
+	data, err := yaml.Marshal(ManifestFromPlugin(plugin))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(manifestPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", manifestPath, err)
+	}
+
+	if !opts.KeepHeader {
+		stripped := stripMetadataHeader(string(content), filepath.Base(manifestPath))
+		if err := os.WriteFile(scriptPath, []byte(stripped), 0755); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", scriptPath, err)
+		}
+	}
+
+	return manifestPath, nil
+}
+
+// stripMetadataHeader removes the metadata key lines from a script's header,
+// leaving a pointer to the manifest in place of the first one.
+func stripMetadataHeader(content, manifestFile string) string {
+	lines := strings.Split(content, "\n")
+	out := make([]string, 0, len(lines))
+	inHeader, pointed := true, false
+
+	for _, line := range lines {
+		if inHeader {
+			trimmed := strings.TrimSpace(line)
+			if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+				inHeader = false
+			} else if match := metadataLinePattern.FindStringSubmatch(line); match != nil {
+				if _, known := metadataSchema[match[1]]; known {
+					if !pointed {
+						out = append(out, "# Metadata is declared in "+manifestFile)
+						pointed = true
+					}
+					continue
+				}
+			}
+		}
+		out = append(out, line)
+	}
+
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,177 @@
+package itamae
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const manifestOnlyScript = `#!/bin/bash
+#
+# METADATA
+# Metadata is declared in example.yaml
+#
+
+install() { echo install; }
+`
+
+func TestParsePluginMetadataFromManifest(t *testing.T) {
+	manifest := []byte(`
+metadata_version: 1
+name: Example
+description: An example.
+tags: [Shell, CLI]
+omakase: true
+install_method: apt
+package_name: example
+apt_repo:
+  url: https://example.com/apt
+  suite: stable
+  components: [main]
+  key_url: https://example.com/key.gpg
+requires:
+  - name: EXAMPLE_TOKEN
+    prompt: "Token | with a pipe"
+    default_cmd: echo token
+`)
+
+	plugin, err := parsePluginMetadata(manifestOnlyScript, manifest, "example.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if plugin.Name != "Example" || plugin.InstallMethod != "apt" || plugin.PackageName != "example" || !plugin.Omakase {
+		t.Errorf("unexpected plugin: %+v", plugin)
+	}
+	if !reflect.DeepEqual(plugin.Tags, []string{"shell", "cli"}) {
+		t.Errorf("unexpected tags: %v", plugin.Tags)
+	}
+	if plugin.AptRepo.KeyURL != "https://example.com/key.gpg" || plugin.AptRepo.Suite != "stable" {
+		t.Errorf("unexpected repo: %+v", plugin.AptRepo)
+	}
+	if len(plugin.RequiredInputs) != 1 || plugin.RequiredInputs[0].Prompt != "Token | with a pipe" {
+		t.Errorf("unexpected inputs: %+v", plugin.RequiredInputs)
+	}
+}
+
+func TestManifestOverridesHeader(t *testing.T) {
+	script := `#!/bin/bash
+# NAME: Header Name
+# DESCRIPTION: From the header.
+# INSTALL_METHOD: binary
+# REQUIRES: FROM_HEADER|Header prompt
+`
+	plugin, err := parsePluginMetadata(script, []byte("name: Manifest Name\nrequires:\n  - {name: FROM_MANIFEST, prompt: Manifest prompt}\n"), "example.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plugin.Name != "Manifest Name" || plugin.Description != "From the header." {
+		t.Errorf("expected manifest values to override the header, got %+v", plugin)
+	}
+	if len(plugin.RequiredInputs) != 1 || plugin.RequiredInputs[0].Name != "FROM_MANIFEST" {
+		t.Errorf("expected manifest inputs to replace header inputs, got %+v", plugin.RequiredInputs)
+	}
+}
+
+func TestManifestIssuesNameTheManifest(t *testing.T) {
+	_, err := parsePluginMetadata(manifestOnlyScript, []byte("name: Example\ncolour: blue\n"), "example.yaml")
+
+	var metaErr *MetadataError
+	if !errors.As(err, &metaErr) {
+		t.Fatalf("expected a *MetadataError, got %v", err)
+	}
+	metaErr.File = "example.sh"
+	if !strings.Contains(err.Error(), "example.yaml: invalid manifest") || !strings.Contains(err.Error(), "colour") {
+		t.Errorf("expected the unknown manifest field to be reported, got %v", err)
+	}
+}
+
+func TestResolvePackage(t *testing.T) {
+	packages := map[string]string{"ubuntu": "fd-find", "fedora": "fd"}
+
+	if got := resolvePackage("fd", packages, []string{"pop", "ubuntu", "debian"}); got != "fd-find" {
+		t.Errorf("expected the ID_LIKE match, got %s", got)
+	}
+	if got := resolvePackage("fd-default", packages, []string{"debian"}); got != "fd-default" {
+		t.Errorf("expected the default, got %s", got)
+	}
+}
+
+func TestMigrateScript(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ITAMAE_PLUGIN_DIR", dir)
+
+	path, err := WritePluginScript(dir, ScaffoldOptions{ID: "example", Method: "apt", PostInstall: true}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, cleanup, err := loadUserPlugins(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup()
+
+	manifestPath, err := MigrateScript(path, MigrateOptions{})
+	if err != nil {
+		t.Fatalf("MigrateScript failed: %v", err)
+	}
+	if filepath.Base(manifestPath) != "example.yaml" {
+		t.Errorf("unexpected manifest path %s", manifestPath)
+	}
+
+	script, _ := os.ReadFile(path)
+	if strings.Contains(string(script), "# NAME:") || !strings.Contains(string(script), "example.yaml") {
+		t.Errorf("expected the header keys to be replaced by a pointer, got:\n%s", script)
+	}
+
+	after, cleanup, err := loadUserPlugins(dir)
+	if err != nil {
+		t.Fatalf("loading the migrated plugin failed: %v", err)
+	}
+	defer cleanup()
+
+	before[0].ScriptPath, after[0].ScriptPath = "", ""
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("metadata changed during migration:\nbefore: %+v\nafter:  %+v", before, after)
+	}
+
+	if _, err := MigrateScript(path, MigrateOptions{}); err == nil {
+		t.Error("expected an error when the manifest already exists")
+	}
+}
+
+func TestParseArtifactChecksum(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	artifact, err := parseArtifact("tool https://example.com/tool sha256:" + sum)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if artifact.SHA256 != sum {
+		t.Errorf("unexpected checksum %q", artifact.SHA256)
+	}
+
+	for _, value := range []string{"tool https://example.com/tool md5:abc", "tool https://example.com/tool sha256:xyz"} {
+		if _, err := parseArtifact(value); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestArtifactVerifyChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tool")
+	if err := os.WriteFile(path, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	good := Artifact{Name: "tool", SHA256: "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"}
+	if err := good.verifyChecksum(path); err != nil {
+		t.Errorf("expected the checksum to match: %v", err)
+	}
+	bad := Artifact{Name: "tool", SHA256: strings.Repeat("0", 64)}
+	if err := bad.verifyChecksum(path); err == nil {
+		t.Error("expected a checksum mismatch")
+	}
+}
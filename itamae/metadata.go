@@ -0,0 +1,361 @@
+package itamae
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MetadataVersion is the newest metadata format this build understands.
+// Scripts declare theirs with METADATA_VERSION; a missing value means 1.
+const MetadataVersion = 1
+
+// metadataField describes one key of the metadata schema.
+type metadataField struct {
+	Required   bool
+	Repeatable bool                                // May appear more than once; each value is applied in order
+	Apply      func(p *ToolPlugin, v string) error // Validates the value and stores it on the plugin
+}
+
+// metadataSchema defines every metadata key a plugin script may declare.
+var metadataSchema = map[string]metadataField{
+	"METADATA_VERSION": {Apply: func(p *ToolPlugin, v string) error {
+		version, err := strconv.Atoi(v)
+		if err != nil || version < 1 {
+			return fmt.Errorf("METADATA_VERSION must be a positive integer, got %q", v)
+		}
+		if version > MetadataVersion {
+			return fmt.Errorf("METADATA_VERSION %d is newer than supported version %d; upgrade itamae", version, MetadataVersion)
+		}
+		return nil
+	}},
+	"NAME":        {Required: true, Apply: func(p *ToolPlugin, v string) error { p.Name = v; return nil }},
+	"DESCRIPTION": {Required: true, Apply: func(p *ToolPlugin, v string) error { p.Description = v; return nil }},
+	"TAGS":        {Apply: func(p *ToolPlugin, v string) error { p.Tags = parseTags(v); return nil }},
+	"OMAKASE": {Apply: func(p *ToolPlugin, v string) error {
+		if v != "true" && v != "false" {
+			return fmt.Errorf("OMAKASE must be true or false, got %q", v)
+		}
+		p.Omakase = v == "true"
+		return nil
+	}},
+	"REQUIRES_ROOT": {Apply: func(p *ToolPlugin, v string) error {
+		if v != "true" && v != "false" {
+			return fmt.Errorf("REQUIRES_ROOT must be true or false, got %q", v)
+		}
+		p.RequiresRoot = v == "true"
+		return nil
+	}},
+	"INSTALL_METHOD": {Required: true, Apply: func(p *ToolPlugin, v string) error {
+		if v != "apt" && v != "binary" && v != "manual" {
+			return fmt.Errorf("INSTALL_METHOD must be apt, binary or manual, got %q", v)
+		}
+		p.InstallMethod = v
+		return nil
+	}},
+	"PACKAGE_NAME": {Apply: func(p *ToolPlugin, v string) error { p.PackageName = v; return nil }},
+	"VERSION":      {Apply: func(p *ToolPlugin, v string) error { p.Version = v; return nil }},
+	"LATEST_CMD":   {Apply: func(p *ToolPlugin, v string) error { p.LatestCmd = v; return nil }},
+	"REPO_SETUP":   {Apply: func(p *ToolPlugin, v string) error { p.RepoSetup = v; return nil }},
+	"POST_INSTALL": {Apply: func(p *ToolPlugin, v string) error { p.PostInstall = v; return nil }},
+	"APT_REPO": {Apply: func(p *ToolPlugin, v string) error {
+		repo, err := parseAptRepo(v)
+		if err != nil {
+			return err
+		}
+		repo.KeyURL = p.AptRepo.KeyURL // APT_KEY_URL may come first
+		p.AptRepo = repo
+		return nil
+	}},
+	"APT_KEY_URL": {Apply: func(p *ToolPlugin, v string) error {
+		if _, err := url.ParseRequestURI(v); err != nil {
+			return fmt.Errorf("invalid APT_KEY_URL %q: %w", v, err)
+		}
+		p.AptRepo.KeyURL = v
+		return nil
+	}},
+	"ARTIFACT": {Repeatable: true, Apply: func(p *ToolPlugin, v string) error {
+		artifact, err := parseArtifact(v)
+		if err != nil {
+			return err
+		}
+		p.Artifacts = append(p.Artifacts, artifact)
+		return nil
+	}},
+	"BINARY": {Repeatable: true, Apply: func(p *ToolPlugin, v string) error {
+		if !privilegedFileName.MatchString(v) {
+			return fmt.Errorf("BINARY must be a file name without '/', got %q", v)
+		}
+		p.Binaries = append(p.Binaries, v)
+		return nil
+	}},
+	"LEGACY_PATH": {Repeatable: true, Apply: func(p *ToolPlugin, v string) error {
+		if !legacyInstallPaths[v] {
+			return fmt.Errorf("LEGACY_PATH %q is not a directory the privileged helper may remove", v)
+		}
+		p.LegacyPaths = append(p.LegacyPaths, v)
+		return nil
+	}},
+	"REQUIRES": {Repeatable: true, Apply: func(p *ToolPlugin, v string) error {
+		input, err := parseRequires(v)
+		if err != nil {
+			return err
+		}
+		p.RequiredInputs = append(p.RequiredInputs, input)
+		return nil
+	}},
+	"INPUT_PATTERN": {Repeatable: true, Apply: func(p *ToolPlugin, v string) error {
+		input, pattern, err := findInput(p, "INPUT_PATTERN", v)
+		if err != nil {
+			return err
+		}
+		input.Pattern = pattern
+		return validateInput(*input)
+	}},
+	"INPUT_HELP": {Repeatable: true, Apply: func(p *ToolPlugin, v string) error {
+		input, help, err := findInput(p, "INPUT_HELP", v)
+		if err != nil {
+			return err
+		}
+		input.Help = help
+		return nil
+	}},
+}
+
+var (
+	metadataLinePattern = regexp.MustCompile(`^#\s*([A-Z][A-Z0-9_]*)\s*:(.*)$`)
+	inputNamePattern    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// MetadataIssue is a single problem in a script's metadata header or manifest.
+type MetadataIssue struct {
+	File    string // Set when the issue is in another file than the script, e.g. its manifest
+	Line    int    // 0 when the issue applies to the whole header
+	Message string
+}
+
+// MetadataError collects every problem found while parsing a metadata header.
+type MetadataError struct {
+	File   string
+	Issues []MetadataIssue
+}
+
+func (e *MetadataError) Error() string {
+	lines := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		file := e.File
+		if issue.File != "" {
+			file = issue.File
+		}
+		lines = append(lines, formatPosition(file, issue.Line)+issue.Message)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatPosition renders a "file:line: " prefix, omitting the parts that are unknown.
+func formatPosition(file string, line int) string {
+	switch {
+	case file != "" && line > 0:
+		return fmt.Sprintf("%s:%d: ", file, line)
+	case file != "":
+		return file + ": "
+	case line > 0:
+		return fmt.Sprintf("line %d: ", line)
+	}
+	return ""
+}
+
+// parseRequires parses a REQUIRES value of the form
+// "NAME[:type]|Prompt[|default command]".
+func parseRequires(value string) (Input, error) {
+	parts := strings.SplitN(value, "|", 3)
+	if len(parts) < 2 {
+		return Input{}, fmt.Errorf("REQUIRES must be 'NAME[:type]|Prompt[|default command]', got %q", value)
+	}
+
+	name, spec, _ := strings.Cut(parts[0], ":")
+	input := Input{Name: name, Prompt: parts[1]}
+	if len(parts) == 3 {
+		input.DefaultCmd = parts[2]
+	}
+	kind, choices, err := parseInputType(spec)
+	if err != nil {
+		return Input{}, fmt.Errorf("REQUIRES input %s: %w", name, err)
+	}
+	input.Type, input.Choices = kind, choices
+	return input, validateInput(input)
+}
+
+// findInput resolves the "NAME value" form of INPUT_PATTERN and INPUT_HELP to
+// an input declared by an earlier REQUIRES line.
+func findInput(p *ToolPlugin, key, value string) (*Input, string, error) {
+	name, rest, _ := strings.Cut(value, " ")
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		return nil, "", fmt.Errorf("%s must be 'NAME value', got %q", key, value)
+	}
+	for i := range p.RequiredInputs {
+		if p.RequiredInputs[i].Name == name {
+			return &p.RequiredInputs[i], rest, nil
+		}
+	}
+	return nil, "", fmt.Errorf("%s refers to %s, which no earlier REQUIRES declares", key, name)
+}
+
+// validateInput checks a required input's name, prompt, type and pattern.
+func validateInput(input Input) error {
+	if !inputNamePattern.MatchString(input.Name) {
+		return fmt.Errorf("REQUIRES input name %q is not a valid variable name", input.Name)
+	}
+	if strings.TrimSpace(input.Prompt) == "" {
+		return fmt.Errorf("REQUIRES input %s has an empty prompt", input.Name)
+	}
+	if input.Type != "" {
+		if _, _, err := parseInputType(input.TypeSpec()); err != nil {
+			return fmt.Errorf("REQUIRES input %s: %w", input.Name, err)
+		}
+	}
+	if input.Pattern != "" {
+		if _, err := regexp.Compile(input.Pattern); err != nil {
+			return fmt.Errorf("REQUIRES input %s has an invalid pattern: %w", input.Name, err)
+		}
+	}
+	return nil
+}
+
+// parseMetadata parses the comment header at the top of a plugin script against
+// metadataSchema. Parsing stops at the first line of code. All problems are
+// reported together as a *MetadataError.
+func parseMetadata(content string) (ToolPlugin, error) {
+	return parsePluginMetadata(content, nil, "")
+}
+
+// parsePluginMetadata parses a script's comment header merged with its sidecar
+// manifest, if any. Values from the manifest replace those from the header.
+func parsePluginMetadata(content string, manifest []byte, manifestName string) (ToolPlugin, error) {
+	plugin, _, issues, _, err := scanMetadata(content, manifest, manifestName)
+	if err != nil {
+		return ToolPlugin{}, err
+	}
+	if len(issues) > 0 {
+		return ToolPlugin{}, &MetadataError{Issues: issues}
+	}
+	return plugin, nil
+}
+
+// scanMetadata parses the header and manifest, returning the plugin, the line
+// each key was first declared on (0 for keys from the manifest), every
+// problem found, and the header lines that look like keys but aren't in the
+// schema. Those are only reported by lint, since a comment such as
+// "# WARNING: ..." reads the same way.
+func scanMetadata(content string, manifest []byte, manifestName string) (ToolPlugin, map[string]int, []MetadataIssue, []MetadataIssue, error) {
+	plugin, seen, issues, unknown, err := scanHeader(content)
+	if err != nil {
+		return ToolPlugin{}, nil, nil, nil, err
+	}
+	if manifest != nil {
+		issues = append(issues, applyManifest(&plugin, seen, manifest, manifestName)...)
+	}
+	issues = append(issues, validateMetadata(plugin, seen)...)
+	return plugin, seen, issues, unknown, nil
+}
+
+// commentTags are comment prefixes that look like metadata keys but never
+// are, so even lint leaves them alone.
+var commentTags = map[string]bool{"TODO": true, "NOTE": true, "FIXME": true, "XXX": true, "HACK": true}
+
+// scanHeader applies each key of the comment header to a plugin, reporting
+// problems with individual lines. Lines with keys outside the schema are
+// treated as comments and returned separately.
+func scanHeader(content string) (ToolPlugin, map[string]int, []MetadataIssue, []MetadataIssue, error) {
+	plugin := ToolPlugin{RequiresRoot: true} // Plugins need sudo unless they declare otherwise
+	issues := []MetadataIssue{}
+	unknown := []MetadataIssue{}
+	seen := map[string]int{}
+
+	lineNo := 0
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#!") {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			break // Metadata ends at the first line of code
+		}
+
+		match := metadataLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue // Free-form comment
+		}
+		key, value := match[1], strings.TrimSpace(match[2])
+
+		field, known := metadataSchema[key]
+		if !known {
+			if !commentTags[key] {
+				unknown = append(unknown, MetadataIssue{Line: lineNo, Message: fmt.Sprintf("unknown metadata key %s", key)})
+			}
+			continue
+		}
+		if first, dup := seen[key]; dup && !field.Repeatable {
+			issues = append(issues, MetadataIssue{Line: lineNo, Message: fmt.Sprintf("%s declared more than once (first on line %d)", key, first)})
+			continue
+		}
+		if _, dup := seen[key]; !dup {
+			seen[key] = lineNo
+		}
+		if value == "" {
+			issues = append(issues, MetadataIssue{Line: lineNo, Message: fmt.Sprintf("%s has an empty value", key)})
+			continue
+		}
+		if err := field.Apply(&plugin, value); err != nil {
+			issues = append(issues, MetadataIssue{Line: lineNo, Message: err.Error()})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ToolPlugin{}, nil, nil, nil, fmt.Errorf("scanner error while parsing metadata: %w", err)
+	}
+
+	return plugin, seen, issues, unknown, nil
+}
+
+// validateMetadata checks required keys and rules spanning several keys.
+func validateMetadata(plugin ToolPlugin, seen map[string]int) []MetadataIssue {
+	issues := []MetadataIssue{}
+	for _, key := range requiredMetadataKeys() {
+		if _, ok := seen[key]; !ok {
+			issues = append(issues, MetadataIssue{Message: fmt.Sprintf("missing required metadata %s", key)})
+		}
+	}
+	if plugin.InstallMethod == "apt" && plugin.PackageName == "" {
+		issues = append(issues, MetadataIssue{Line: seen["INSTALL_METHOD"], Message: "INSTALL_METHOD apt requires PACKAGE_NAME"})
+	}
+	if plugin.InstallMethod == "apt" && !plugin.RequiresRoot {
+		issues = append(issues, MetadataIssue{Line: seen["REQUIRES_ROOT"], Message: "REQUIRES_ROOT false is not possible for apt plugins"})
+	}
+	if len(plugin.Binaries) > 0 && !plugin.RequiresRoot {
+		issues = append(issues, MetadataIssue{Line: seen["REQUIRES_ROOT"], Message: "BINARY installs into /usr/local/bin, so REQUIRES_ROOT can't be false"})
+	}
+	if line, ok := seen["APT_KEY_URL"]; ok && !plugin.AptRepo.IsSet() {
+		issues = append(issues, MetadataIssue{Line: line, Message: "APT_KEY_URL declared without APT_REPO"})
+	}
+	return issues
+}
+
+// requiredMetadataKeys returns the required schema keys in sorted order.
+func requiredMetadataKeys() []string {
+	keys := []string{}
+	for key, field := range metadataSchema {
+		if field.Required {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,134 @@
+package itamae
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseMetadataStopsAtCode(t *testing.T) {
+	plugin, err := parseMetadata(`#!/bin/bash
+#
+# METADATA
+# NAME: Example
+# DESCRIPTION: An example.
+# INSTALL_METHOD: binary
+
+install() {
+    # NAME: Not metadata
+    echo
+}
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plugin.Name != "Example" {
+		t.Errorf("expected the header NAME, got %q", plugin.Name)
+	}
+}
+
+func TestParseMetadataIgnoresCommentsThatLookLikeKeys(t *testing.T) {
+	script := `#!/bin/bash
+# NAME: Example
+# DESCRIPTION: An example.
+# TODO: pin the version once upstream tags releases.
+# NOTE: the installer needs curl.
+# WARNING: installs system-wide.
+# INSTALL_METHOD: binary
+`
+	plugin, err := parseMetadata(script)
+	if err != nil {
+		t.Fatalf("expected comments to be ignored, got %v", err)
+	}
+	if plugin.InstallMethod != "binary" {
+		t.Errorf("expected the keys after the comments to be parsed, got %+v", plugin)
+	}
+
+	// Lint still flags the unknown key, but not the comment tags
+	var flagged []string
+	for _, issue := range LintScript("example.sh", script, nil, LintOptions{}) {
+		if strings.Contains(issue.Message, "unknown metadata key") {
+			flagged = append(flagged, issue.Message)
+		}
+	}
+	if len(flagged) != 1 || !strings.Contains(flagged[0], "WARNING") {
+		t.Errorf("expected lint to flag only WARNING, got %v", flagged)
+	}
+}
+
+func TestParseMetadataRepeatableKeys(t *testing.T) {
+	plugin, err := parseMetadata(`#!/bin/bash
+# METADATA_VERSION: 1
+# NAME: Example
+# DESCRIPTION: An example.
+# INSTALL_METHOD: binary
+# REQUIRES: FIRST|First value
+# REQUIRES: SECOND|Second value|echo default
+# ARTIFACT: a https://example.com/a
+# ARTIFACT: b https://example.com/b
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plugin.RequiredInputs) != 2 || plugin.RequiredInputs[1].DefaultCmd != "echo default" {
+		t.Errorf("unexpected inputs: %+v", plugin.RequiredInputs)
+	}
+	if len(plugin.Artifacts) != 2 {
+		t.Errorf("expected 2 artifacts, got %+v", plugin.Artifacts)
+	}
+}
+
+func TestParseMetadataReportsAllIssues(t *testing.T) {
+	_, err := parseMetadata(`#!/bin/bash
+# DESCRIPTION: Broken.
+# DESCRIPTION: Again.
+# INSTALL_METHOD: snap
+# REQUIRES: NO_PROMPT
+# OMAKASE: yes
+# COLOUR: blue
+# METADATA_VERSION: 99
+`)
+
+	var metaErr *MetadataError
+	if !errors.As(err, &metaErr) {
+		t.Fatalf("expected a *MetadataError, got %v", err)
+	}
+
+	expected := []struct {
+		line    int
+		message string
+	}{
+		{3, "DESCRIPTION declared more than once"},
+		{4, "INSTALL_METHOD must be apt, binary or manual"},
+		{5, "REQUIRES must be"},
+		{6, "OMAKASE must be true or false"},
+		{8, "newer than supported version"},
+		{0, "missing required metadata NAME"},
+	}
+	for _, want := range expected {
+		found := false
+		for _, issue := range metaErr.Issues {
+			if issue.Line == want.line && strings.Contains(issue.Message, want.message) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected issue on line %d containing %q, got %v", want.line, want.message, metaErr.Issues)
+		}
+	}
+	for _, issue := range metaErr.Issues {
+		if strings.Contains(issue.Message, "COLOUR") {
+			t.Errorf("expected the unknown key to be left to lint, got %v", issue)
+		}
+	}
+}
+
+func TestProcessPluginContentReportsFilePositions(t *testing.T) {
+	_, err := processPluginContent("broken.sh", []byte("#!/bin/bash\n# NAME: Broken\n# OMAKASE: yes\n"), nil, t.TempDir(), "user")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "broken.sh:3: OMAKASE must be true or false") {
+		t.Errorf("expected a file:line position, got %v", err)
+	}
+}
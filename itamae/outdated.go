@@ -0,0 +1,93 @@
+package itamae
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+)
+
+// Version status values reported by CheckOutdated
+const (
+	VersionUpToDate = "up-to-date"
+	VersionOutdated = "outdated"
+	VersionUnknown  = "unknown"
+)
+
+// VersionReport describes the installed and latest available version of a plugin.
+type VersionReport struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Method    string `json:"install_method"`
+	Installed string `json:"installed"`
+	Latest    string `json:"latest"`
+	Pinned    string `json:"pinned,omitempty"`
+	Status    string `json:"status"`
+}
+
+// CheckOutdated reports versions for the given (installed) plugins.
+func CheckOutdated(plugins []ToolPlugin) []VersionReport {
+	reports := make([]VersionReport, 0, len(plugins))
+	for _, p := range plugins {
+		report := VersionReport{
+			ID:        p.ID,
+			Name:      p.Name,
+			Method:    p.InstallMethod,
+			Installed: installedVersion(p),
+			Latest:    latestVersion(p),
+			Pinned:    p.Version,
+		}
+		report.Status = versionStatus(report.Installed, report.Latest)
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// latestVersion returns the newest available version of a plugin: the APT
+// candidate for APT plugins, or the output of LATEST_CMD for others.
+func latestVersion(plugin ToolPlugin) string {
+	if plugin.InstallMethod == "apt" && plugin.PackageName != "" {
+		return aptCandidateVersion(plugin.PackageName)
+	}
+	if plugin.LatestCmd == "" {
+		return ""
+	}
+	return getDefaultValue(plugin.LatestCmd)
+}
+
+// aptCandidateVersion returns the candidate version from `apt-cache policy`.
+func aptCandidateVersion(pkg string) string {
+	output, err := exec.Command("apt-cache", "policy", pkg).Output()
+	if err != nil {
+		return ""
+	}
+	return parseAptPolicy(string(output))
+}
+
+// parseAptPolicy extracts the Candidate version from `apt-cache policy` output.
+func parseAptPolicy(output string) string {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if ok && key == "Candidate" {
+			value = strings.TrimSpace(value)
+			if value == "(none)" {
+				return ""
+			}
+			return value
+		}
+	}
+	return ""
+}
+
+// versionStatus compares an installed version with the latest one using
+// compareVersions. An installed version newer than the latest, e.g. from a
+// backport or a pre-release, is up to date.
+func versionStatus(installed, latest string) string {
+	if installed == "" || latest == "" {
+		return VersionUnknown
+	}
+	if compareVersions(installed, latest) >= 0 {
+		return VersionUpToDate
+	}
+	return VersionOutdated
+}
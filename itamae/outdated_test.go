@@ -0,0 +1,65 @@
+package itamae
+
+import "testing"
+
+func TestParseAptPolicy(t *testing.T) {
+	output := `git:
+  Installed: 1:2.43.0-1ubuntu7
+  Candidate: 1:2.43.0-1ubuntu7.1
+  Version table:
+     1:2.43.0-1ubuntu7.1 500
+        500 http://archive.ubuntu.com/ubuntu noble-updates/main amd64 Packages
+`
+	if got := parseAptPolicy(output); got != "1:2.43.0-1ubuntu7.1" {
+		t.Errorf("unexpected candidate: %q", got)
+	}
+
+	if got := parseAptPolicy("foo:\n  Installed: (none)\n  Candidate: (none)\n"); got != "" {
+		t.Errorf("expected no candidate, got %q", got)
+	}
+}
+
+func TestVersionStatus(t *testing.T) {
+	tests := []struct {
+		installed, latest, want string
+	}{
+		{"v1.31.0", "1.31.0", VersionUpToDate},
+		{"1.30.0", "v1.31.0", VersionOutdated},
+		{"1.9.0", "1.10.0", VersionOutdated},
+		{"v1.32.0", "1.31.0", VersionUpToDate}, // Newer than the latest release
+		{"1:2.43.0-1ubuntu7.1", "1:2.43.0-1ubuntu7", VersionUpToDate},
+		{"1.0.0-rc1", "1.0.0", VersionOutdated},
+		{"", "1.31.0", VersionUnknown},
+		{"1.31.0", "", VersionUnknown},
+	}
+	for _, tt := range tests {
+		if got := versionStatus(tt.installed, tt.latest); got != tt.want {
+			t.Errorf("versionStatus(%q, %q) = %s, want %s", tt.installed, tt.latest, got, tt.want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.31.0", "v1.31.0", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"0.54.0", "0.54.1", -1},
+		{"1:1.0", "2.0", 1}, // Epoch wins
+		{"2.43.0-1ubuntu7", "2.43.0-1ubuntu7.1", -1},
+		{"1.0~rc1", "1.0", -1}, // ~ sorts before the release
+		{"v2.0.0-beta.2", "2.0.0", -1},
+		{"v2.0.0-beta.2", "v2.0.0-beta.10", -1},
+		{"1.0a", "1.0+", -1}, // Letters sort before symbols
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := compareVersions(tt.b, tt.a); got != -tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
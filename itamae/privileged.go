@@ -0,0 +1,713 @@
+package itamae
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Operations the privileged helper accepts. Anything else is refused.
+const (
+	opAptUpdate     = "apt_update"
+	opAptInstall    = "apt_install"
+	opAptRemove     = "apt_remove"
+	opWriteAptFile  = "write_apt_file"
+	opRemoveAptFile = "remove_apt_file"
+	opInstallBinary = "install_binary"
+	opRemoveBinary  = "remove_binary"
+	opRemoveLegacy  = "remove_legacy"
+)
+
+// binDir is where install_binary puts BINARY files.
+const binDir = "/usr/local/bin"
+
+// legacyInstallPaths are the directories earlier plugin versions installed
+// into outside the package manager, which remove_legacy may delete.
+var legacyInstallPaths = map[string]bool{
+	"/opt/maven": true, // maven.sh before it used the distro package
+}
+
+var (
+	// privilegedRoot prefixes every path the helper writes. Tests point it at a
+	// temporary directory.
+	privilegedRoot = "/"
+
+	// auditLogPath is where the helper records each privileged action.
+	auditLogPath = "/var/log/itamae/audit.log"
+
+	// privilegedCommand builds the apt commands the helper runs. Tests replace it.
+	privilegedCommand = exec.Command
+
+	aptPackagePattern  = regexp.MustCompile(`^[a-z0-9][a-z0-9+.-]*(:[a-z0-9-]+)?(=[A-Za-z0-9.+~:-]+)?$`)
+	privilegedFileName = regexp.MustCompile(`^[A-Za-z0-9_+-][A-Za-z0-9._+-]*$`)
+)
+
+// privilegedRequest is one operation sent to the privileged helper.
+type privilegedRequest struct {
+	Op        string   `json:"op"`
+	Packages  []string `json:"packages,omitempty"`  // apt_install, apt_remove
+	Debs      []string `json:"debs,omitempty"`      // apt_install from the offline cache
+	Upgrade   bool     `json:"upgrade,omitempty"`   // apt_install --only-upgrade
+	Downgrade bool     `json:"downgrade,omitempty"` // apt_install --allow-downgrades of pinned versions
+	Path      string   `json:"path,omitempty"`      // write_apt_file, remove_apt_file
+	Content   []byte   `json:"content,omitempty"`   // write_apt_file
+	Name      string   `json:"name,omitempty"`      // install_binary, remove_binary
+	Source    string   `json:"source,omitempty"`    // install_binary
+}
+
+// privilegedResponse is the helper's answer to a request.
+type privilegedResponse struct {
+	Changed bool   `json:"changed,omitempty"`
+	Output  string `json:"output,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// auditEntry is one line of the audit log.
+type auditEntry struct {
+	Time   string   `json:"time"`
+	User   string   `json:"user"`
+	Op     string   `json:"op"`
+	Args   []string `json:"args,omitempty"`
+	SHA256 string   `json:"sha256,omitempty"`
+	Result string   `json:"result"` // ok, unchanged, failed or refused
+	Error  string   `json:"error,omitempty"`
+}
+
+// ServePrivileged runs the privileged helper. It reads one JSON request per
+// line from in, performs it if it is on the whitelist, and writes the response
+// to out. Every request, refused or not, is appended to the audit log first.
+func ServePrivileged(in io.Reader, out io.Writer) error {
+	audit, err := openAuditLog()
+	if err != nil {
+		return err
+	}
+	defer audit.Close()
+
+	who := invokingUser()
+	dec := json.NewDecoder(in)
+	enc := json.NewEncoder(out)
+	for {
+		var req privilegedRequest
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("invalid privileged request: %w", err)
+		}
+
+		entry := auditEntry{Time: time.Now().UTC().Format(time.RFC3339), User: who, Op: req.Op, Args: req.describe()}
+		var resp privilegedResponse
+		if err := req.validate(); err != nil {
+			entry.Result, entry.Error = "refused", err.Error()
+			resp.Error = fmt.Sprintf("refused %s: %v", req.Op, err)
+		} else {
+			entry.SHA256, resp = req.perform()
+			switch {
+			case resp.Error != "":
+				entry.Result, entry.Error = "failed", resp.Error
+			case resp.Changed:
+				entry.Result = "ok"
+			default:
+				entry.Result = "unchanged"
+			}
+		}
+
+		line, _ := json.Marshal(entry)
+		if _, err := audit.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// openAuditLog opens the audit log for appending, creating it if needed.
+func openAuditLog() (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(auditLogPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(auditLogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return f, nil
+}
+
+// invokingUser names the user who started the helper, looking through sudo.
+func invokingUser() string {
+	if name := os.Getenv("SUDO_USER"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return strconv.Itoa(os.Getuid())
+}
+
+// describe summarises a request for the audit log, leaving out file contents.
+func (r privilegedRequest) describe() []string {
+	args := append([]string{}, r.Packages...)
+	args = append(args, r.Debs...)
+	if r.Upgrade {
+		args = append(args, "--only-upgrade")
+	}
+	if r.Downgrade {
+		args = append(args, "--allow-downgrades")
+	}
+	for _, s := range []string{r.Path, r.Source, r.Name} {
+		if s != "" {
+			args = append(args, s)
+		}
+	}
+	return args
+}
+
+// validate checks a request against the whitelist before anything runs.
+func (r privilegedRequest) validate() error {
+	switch r.Op {
+	case opAptUpdate:
+		return nil
+	case opAptInstall:
+		if len(r.Debs) > 0 {
+			if len(r.Packages) > 0 || r.Upgrade || r.Downgrade {
+				return fmt.Errorf("cached .deb files can't be combined with package names")
+			}
+			for _, deb := range r.Debs {
+				if !isCleanAbs(deb) || !strings.HasSuffix(deb, ".deb") {
+					return fmt.Errorf("%q is not an absolute path to a .deb file", deb)
+				}
+				// Packages run maintainer scripts as root: only take them from the user's cache
+				if err := ownedByInvokingUser(filepath.Dir(deb), true); err != nil {
+					return err
+				}
+				if err := ownedByInvokingUser(deb, false); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if r.Downgrade {
+			if r.Upgrade {
+				return fmt.Errorf("--allow-downgrades can't be combined with --only-upgrade")
+			}
+			// Only ever go down to a version the caller asked for by name
+			for _, pkg := range r.Packages {
+				if !strings.Contains(pkg, "=") {
+					return fmt.Errorf("%q has no version to downgrade to", pkg)
+				}
+			}
+		}
+		return validatePackages(r.Packages)
+	case opAptRemove:
+		return validatePackages(r.Packages)
+	case opWriteAptFile, opRemoveAptFile:
+		return validateAptFile(r.Path)
+	case opInstallBinary:
+		if !privilegedFileName.MatchString(r.Name) {
+			return fmt.Errorf("invalid binary name %q", r.Name)
+		}
+		return ownedByInvokingUser(r.Source, false)
+	case opRemoveBinary:
+		if !privilegedFileName.MatchString(r.Name) {
+			return fmt.Errorf("invalid binary name %q", r.Name)
+		}
+		return nil
+	case opRemoveLegacy:
+		if !legacyInstallPaths[r.Path] {
+			return fmt.Errorf("%q is not a legacy install directory", r.Path)
+		}
+		return nil
+	}
+	return fmt.Errorf("operation %q is not allowed", r.Op)
+}
+
+func validatePackages(packages []string) error {
+	if len(packages) == 0 {
+		return fmt.Errorf("no packages given")
+	}
+	for _, pkg := range packages {
+		if !aptPackagePattern.MatchString(pkg) {
+			return fmt.Errorf("invalid package name %q", pkg)
+		}
+	}
+	return nil
+}
+
+// validateAptFile only allows sources and keyrings directly inside the APT
+// directories itamae manages.
+func validateAptFile(path string) error {
+	if !isCleanAbs(path) {
+		return fmt.Errorf("%q is not a clean absolute path", path)
+	}
+	dir, name := filepath.Split(path)
+	dir = filepath.Clean(dir)
+	if dir != aptSourcesDir && dir != aptKeyringDir {
+		return fmt.Errorf("%s is outside %s and %s", path, aptSourcesDir, aptKeyringDir)
+	}
+	if !privilegedFileName.MatchString(name) {
+		return fmt.Errorf("invalid file name %q", name)
+	}
+	switch filepath.Ext(name) {
+	case ".sources", ".list", ".asc", ".gpg":
+		return nil
+	}
+	return fmt.Errorf("%s is not an APT sources or keyring file", path)
+}
+
+func isCleanAbs(path string) bool {
+	return filepath.IsAbs(path) && filepath.Clean(path) == path
+}
+
+// ownedByInvokingUser makes sure a path the helper reads from belongs to the
+// user who ran sudo, so the helper can't be used to copy
+// files the user could not read.
+func ownedByInvokingUser(path string, wantDir bool) error {
+	if !isCleanAbs(path) {
+		return fmt.Errorf("%q is not a clean absolute path", path)
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	switch {
+	case wantDir && !info.IsDir():
+		return fmt.Errorf("%s is not a directory", path)
+	case !wantDir && !info.Mode().IsRegular():
+		return fmt.Errorf("%s is not a regular file", path)
+	}
+	return checkInvokingUserOwns(path, info)
+}
+
+func checkInvokingUserOwns(path string, info os.FileInfo) error {
+	uid, err := strconv.Atoi(os.Getenv("SUDO_UID"))
+	if err != nil {
+		return nil // Not started through sudo; the caller already is root
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != uid {
+		return fmt.Errorf("%s is not owned by the invoking user", path)
+	}
+	return nil
+}
+
+// openOwned opens a file the helper reads from and repeats the checks of
+// ownedByInvokingUser on the open file. Reading through it, rather than the
+// path, closes the window in which the path could be swapped after validate.
+func openOwned(path string) (*os.File, error) {
+	// O_NONBLOCK keeps a FIFO swapped in for the file from blocking the helper
+	f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NOFOLLOW|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err == nil && !info.Mode().IsRegular() {
+		err = fmt.Errorf("%s is not a regular file", path)
+	}
+	if err == nil {
+		err = checkInvokingUserOwns(path, info)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// readOwned reads a file through openOwned.
+func readOwned(path string) ([]byte, error) {
+	f, err := openOwned(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// copyOwnedDebs copies cached .deb files, read through openOwned, into a new
+// directory only root can write to, so apt installs exactly what was checked.
+// The caller removes the directory.
+func copyOwnedDebs(debs []string) (string, []string, error) {
+	dir, err := os.MkdirTemp("", "itamae-debs-")
+	if err != nil {
+		return "", nil, err
+	}
+	copies := make([]string, len(debs))
+	for i, deb := range debs {
+		copies[i] = filepath.Join(dir, fmt.Sprintf("%d-%s", i, filepath.Base(deb)))
+		if err := copyOwned(deb, copies[i]); err != nil {
+			os.RemoveAll(dir)
+			return "", nil, err
+		}
+	}
+	return dir, copies, nil
+}
+
+func copyOwned(src, dest string) error {
+	in, err := openOwned(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// perform runs a validated request. It returns the sha256 of any file written,
+// for the audit log.
+func (r privilegedRequest) perform() (string, privilegedResponse) {
+	switch r.Op {
+	case opAptUpdate:
+		name := "apt-get"
+		if _, err := exec.LookPath("nala"); err == nil {
+			name = "nala"
+		}
+		resp := runPrivileged(name, "update")
+		if resp.Error == "" {
+			// Best effort: without the stamp the next run falls back to the lists directory
+			_ = touchRootFile(aptUpdateStamp)
+		}
+		return "", resp
+
+	case opAptInstall:
+		switch {
+		case len(r.Debs) > 0:
+			dir, debs, err := copyOwnedDebs(r.Debs)
+			if err != nil {
+				return "", fileResponse(false, err)
+			}
+			defer os.RemoveAll(dir)
+			return "", runPrivileged("apt-get", append([]string{"install", "-y", "--no-download"}, debs...)...)
+		case r.Upgrade:
+			return "", runPrivileged("apt-get", append([]string{"install", "--only-upgrade", "-y"}, r.Packages...)...)
+		case r.Downgrade:
+			return "", runPrivileged("apt-get", append([]string{"install", "-y", "--allow-downgrades"}, r.Packages...)...)
+		}
+		if _, err := exec.LookPath("nala"); err == nil {
+			return "", runPrivileged("nala", append([]string{"install", "-y"}, r.Packages...)...)
+		}
+		return "", runPrivileged("apt-get", append([]string{"install", "-y"}, r.Packages...)...)
+
+	case opAptRemove:
+		return "", runPrivileged("apt-get", append([]string{"purge", "-y"}, r.Packages...)...)
+
+	case opWriteAptFile:
+		changed, err := writeRootFile(r.Path, r.Content, 0644)
+		return checksum(r.Content), fileResponse(changed, err)
+
+	case opRemoveAptFile:
+		return "", fileResponse(removeRootFile(r.Path))
+
+	case opInstallBinary:
+		content, err := readOwned(r.Source)
+		if err != nil {
+			return "", fileResponse(false, err)
+		}
+		changed, err := writeRootFile(filepath.Join(binDir, r.Name), content, 0755)
+		return checksum(content), fileResponse(changed, err)
+
+	case opRemoveBinary:
+		return "", fileResponse(removeRootFile(filepath.Join(binDir, r.Name)))
+
+	case opRemoveLegacy:
+		return "", fileResponse(removeLegacyInstall(r.Path))
+	}
+	return "", privilegedResponse{Error: fmt.Sprintf("operation %q is not allowed", r.Op)}
+}
+
+// runPrivileged runs an apt command, capturing its output for the response.
+func runPrivileged(name string, args ...string) privilegedResponse {
+	output, err := privilegedCommand(name, args...).CombinedOutput()
+	resp := privilegedResponse{Changed: true, Output: string(output)}
+	if err != nil {
+		resp.Changed = false
+		resp.Error = fmt.Sprintf("%s %s failed: %v", name, strings.Join(args, " "), err)
+	}
+	return resp
+}
+
+func fileResponse(changed bool, err error) privilegedResponse {
+	if err != nil {
+		return privilegedResponse{Error: err.Error()}
+	}
+	return privilegedResponse{Changed: changed}
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// touchRootFile creates path or sets its modification time to now.
+func touchRootFile(path string) error {
+	path = filepath.Join(privilegedRoot, path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	now := time.Now()
+	return os.Chtimes(path, now, now)
+}
+
+// writeRootFile atomically writes content to dest with the given mode. The
+// file is left untouched if it already has the same content.
+func writeRootFile(dest string, content []byte, mode os.FileMode) (bool, error) {
+	dest = filepath.Join(privilegedRoot, dest)
+	if existing, err := os.ReadFile(dest); err == nil && bytes.Equal(existing, content) {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return false, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".itamae-")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// removeRootFile deletes path, reporting whether it existed.
+func removeRootFile(path string) (bool, error) {
+	err := os.Remove(filepath.Join(privilegedRoot, path))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// removeLegacyInstall deletes a legacy install directory and the symlinks
+// in /usr/local/bin that point into it, reporting whether it existed.
+func removeLegacyInstall(path string) (bool, error) {
+	dir := filepath.Join(privilegedRoot, path)
+	info, err := os.Lstat(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !info.IsDir() {
+		return false, fmt.Errorf("%s is not a directory", path)
+	}
+
+	bin := filepath.Join(privilegedRoot, binDir)
+	entries, err := os.ReadDir(bin)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	for _, entry := range entries {
+		link := filepath.Join(bin, entry.Name())
+		target, err := os.Readlink(link)
+		if err != nil || (target != path && !strings.HasPrefix(target, path+"/")) {
+			continue
+		}
+		if err := os.Remove(link); err != nil {
+			return false, err
+		}
+	}
+	return true, os.RemoveAll(dir)
+}
+
+// privilegedHelper is the orchestrator's end of the pipe to the helper.
+type privilegedHelper struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	dec   *json.Decoder
+	stdin io.Closer
+	wait  func() error
+}
+
+func newPrivilegedHelper(stdin io.WriteCloser, stdout io.Reader, wait func() error) *privilegedHelper {
+	return &privilegedHelper{enc: json.NewEncoder(stdin), dec: json.NewDecoder(stdout), stdin: stdin, wait: wait}
+}
+
+// do sends one request and waits for its response.
+func (h *privilegedHelper) do(req privilegedRequest) (privilegedResponse, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	DebugLog("Privileged %s %v", req.Op, req.describe())
+	var resp privilegedResponse
+	if err := h.enc.Encode(req); err != nil {
+		return resp, fmt.Errorf("privileged helper is not running: %w", err)
+	}
+	if err := h.dec.Decode(&resp); err != nil {
+		return resp, fmt.Errorf("privileged helper stopped responding: %w", err)
+	}
+	if resp.Output != "" {
+		DebugLog("Privileged %s output:\n%s", req.Op, resp.Output)
+	}
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// close ends the helper by closing its input and waits for it to exit.
+func (h *privilegedHelper) close() error {
+	h.stdin.Close()
+	return h.wait()
+}
+
+var (
+	helperMu     sync.Mutex
+	activeHelper *privilegedHelper
+)
+
+// startPrivilegedHelper runs `itamae privileged-helper` under sudo. Call it
+// after ensureSudoAccess has succeeded; it never prompts. As root it serves
+// requests in-process instead. Tests replace it.
+var startPrivilegedHelper = func() (*privilegedHelper, error) {
+	if runningAsRoot() {
+		DebugLog("Running as root; serving privileged steps in-process")
+		return inProcessHelper()
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the itamae binary: %w", err)
+	}
+
+	cmd := exec.Command("sudo", "-n", exe, "privileged-helper")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start privileged helper: %w", err)
+	}
+	DebugLog("Privileged helper started (pid %d)", cmd.Process.Pid)
+
+	return newPrivilegedHelper(stdin, stdout, func() error {
+		if err := cmd.Wait(); err != nil {
+			return fmt.Errorf("privileged helper failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}), nil
+}
+
+// inProcessHelper serves privileged requests from a goroutine over in-memory
+// pipes. When itamae already runs as root, this keeps the same whitelist and
+// audit log without a sudo child process.
+//
+// The pipes are OS pipes, like a child's: io.Pipe writes block until every
+// byte is read, and the JSON decoder may leave a request's trailing newline
+// unread while the response is written.
+func inProcessHelper() (*privilegedHelper, error) {
+	reqR, reqW, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start privileged helper: %w", err)
+	}
+	respR, respW, err := os.Pipe()
+	if err != nil {
+		reqR.Close()
+		reqW.Close()
+		return nil, fmt.Errorf("failed to start privileged helper: %w", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		err := ServePrivileged(reqR, respW)
+		reqR.Close()
+		respW.Close()
+		done <- err
+	}()
+	return newPrivilegedHelper(reqW, respR, func() error {
+		err := <-done
+		respR.Close()
+		return err
+	}), nil
+}
+
+// elevate runs one whitelisted operation as root, starting the helper on
+// first use. The same helper serves the rest of the run.
+func elevate(req privilegedRequest) (privilegedResponse, error) {
+	helperMu.Lock()
+	if activeHelper == nil {
+		h, err := startPrivilegedHelper()
+		if err != nil {
+			helperMu.Unlock()
+			return privilegedResponse{}, err
+		}
+		activeHelper = h
+	}
+	h := activeHelper
+	helperMu.Unlock()
+
+	return h.do(req)
+}
+
+// stopPrivilegedHelper shuts the helper down, if one was started.
+func stopPrivilegedHelper() {
+	helperMu.Lock()
+	defer helperMu.Unlock()
+	if activeHelper == nil {
+		return
+	}
+	if err := activeHelper.close(); err != nil {
+		DebugLog("WARNING: %v", err)
+	}
+	activeHelper = nil
+	DebugLog("Privileged helper stopped")
+}
+
+// absPaths makes paths absolute; the helper only accepts absolute paths.
+func absPaths(paths []string) []string {
+	abs := make([]string, len(paths))
+	for i, path := range paths {
+		if a, err := filepath.Abs(path); err == nil {
+			path = a
+		}
+		abs[i] = path
+	}
+	return abs
+}
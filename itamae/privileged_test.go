@@ -0,0 +1,390 @@
+package itamae
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// useInProcessHelper serves privileged requests from a goroutine instead of a
+// sudo child process. Files are written under the returned root directory,
+// which also holds the audit log.
+func useInProcessHelper(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	originalRoot, originalAudit, originalStart := privilegedRoot, auditLogPath, startPrivilegedHelper
+	privilegedRoot = root
+	auditLogPath = filepath.Join(root, "audit.log")
+	startPrivilegedHelper = func() (*privilegedHelper, error) {
+		return inProcessHelper()
+	}
+	t.Setenv("SUDO_UID", "")
+	t.Cleanup(func() {
+		stopPrivilegedHelper()
+		privilegedRoot, auditLogPath, startPrivilegedHelper = originalRoot, originalAudit, originalStart
+	})
+	return root
+}
+
+func readAuditLog(t *testing.T) []auditEntry {
+	t.Helper()
+	f, err := os.Open(auditLogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	entries := []auditEntry{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid audit line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestPrivilegedWhitelist(t *testing.T) {
+	t.Setenv("SUDO_UID", "")
+	binary := filepath.Join(t.TempDir(), "mytool")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	deb := filepath.Join(t.TempDir(), "git_2.43.0_amd64.deb")
+	if err := os.WriteFile(deb, []byte("!<arch>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		req     privilegedRequest
+		allowed bool
+	}{
+		{privilegedRequest{Op: opAptUpdate}, true},
+		{privilegedRequest{Op: opAptInstall, Packages: []string{"git", "ripgrep=14.1.0-1"}}, true},
+		{privilegedRequest{Op: opAptInstall, Debs: []string{deb}}, true},
+		{privilegedRequest{Op: opAptInstall, Packages: []string{"kubectl=1.29.0-1.1"}, Downgrade: true}, true},
+		{privilegedRequest{Op: opAptRemove, Packages: []string{"git"}}, true},
+		{privilegedRequest{Op: opWriteAptFile, Path: "/etc/apt/sources.list.d/example.sources"}, true},
+		{privilegedRequest{Op: opRemoveAptFile, Path: "/etc/apt/keyrings/example.asc"}, true},
+		{privilegedRequest{Op: opInstallBinary, Name: "mytool", Source: binary}, true},
+		{privilegedRequest{Op: opRemoveBinary, Name: "mytool"}, true},
+		{privilegedRequest{Op: opRemoveLegacy, Path: "/opt/maven"}, true},
+
+		{privilegedRequest{Op: "run", Name: "bash"}, false},
+		{privilegedRequest{Op: opAptInstall}, false},
+		{privilegedRequest{Op: opAptInstall, Packages: []string{"kubectl"}, Downgrade: true}, false},
+		{privilegedRequest{Op: opAptInstall, Packages: []string{"kubectl=1.29.0-1.1"}, Downgrade: true, Upgrade: true}, false},
+		{privilegedRequest{Op: opAptInstall, Packages: []string{"-o=APT::Get::AllowUnauthenticated=1"}}, false},
+		{privilegedRequest{Op: opAptInstall, Packages: []string{"git; rm -rf /"}}, false},
+		{privilegedRequest{Op: opAptInstall, Debs: []string{"debs/git.deb"}}, false},
+		{privilegedRequest{Op: opAptInstall, Debs: []string{"/etc/shadow"}}, false},
+		{privilegedRequest{Op: opAptInstall, Debs: []string{"/nonexistent/git.deb"}}, false},
+		{privilegedRequest{Op: opWriteAptFile, Path: "/etc/apt/sources.list.d/../../sudoers"}, false},
+		{privilegedRequest{Op: opWriteAptFile, Path: "/etc/sudoers.d/example.list"}, false},
+		{privilegedRequest{Op: opWriteAptFile, Path: "/etc/apt/sources.list.d/example.conf"}, false},
+		{privilegedRequest{Op: opInstallBinary, Name: "../sbin/init", Source: binary}, false},
+		{privilegedRequest{Op: opInstallBinary, Name: "mytool", Source: "/nonexistent"}, false},
+		{privilegedRequest{Op: opRemoveBinary, Name: ".."}, false},
+		{privilegedRequest{Op: opRemoveLegacy, Path: "/opt"}, false},
+		{privilegedRequest{Op: opRemoveLegacy, Path: "/opt/maven/../.."}, false},
+	}
+	for _, tt := range tests {
+		err := tt.req.validate()
+		if tt.allowed && err != nil {
+			t.Errorf("expected %+v to be allowed, got %v", tt.req, err)
+		}
+		if !tt.allowed && err == nil {
+			t.Errorf("expected %+v to be refused", tt.req)
+		}
+	}
+}
+
+func TestInstallBinaryRefusesOtherUsersFiles(t *testing.T) {
+	binary := filepath.Join(t.TempDir(), "mytool")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("SUDO_UID", strconv.Itoa(os.Getuid()))
+	if err := (privilegedRequest{Op: opInstallBinary, Name: "mytool", Source: binary}).validate(); err != nil {
+		t.Errorf("expected the invoking user's file to be accepted, got %v", err)
+	}
+
+	t.Setenv("SUDO_UID", strconv.Itoa(os.Getuid()+1))
+	err := (privilegedRequest{Op: opInstallBinary, Name: "mytool", Source: binary}).validate()
+	if err == nil || !strings.Contains(err.Error(), "not owned by the invoking user") {
+		t.Errorf("expected another user's file to be refused, got %v", err)
+	}
+}
+
+func TestInstallDebsRefusesOtherUsersFiles(t *testing.T) {
+	deb := filepath.Join(t.TempDir(), "git_2.43.0_amd64.deb")
+	if err := os.WriteFile(deb, []byte("!<arch>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("SUDO_UID", strconv.Itoa(os.Getuid()))
+	if err := (privilegedRequest{Op: opAptInstall, Debs: []string{deb}}).validate(); err != nil {
+		t.Errorf("expected the invoking user's cache to be accepted, got %v", err)
+	}
+
+	t.Setenv("SUDO_UID", strconv.Itoa(os.Getuid()+1))
+	err := (privilegedRequest{Op: opAptInstall, Debs: []string{deb}}).validate()
+	if err == nil || !strings.Contains(err.Error(), "not owned by the invoking user") {
+		t.Errorf("expected another user's cache to be refused, got %v", err)
+	}
+}
+
+func TestOpenOwnedRefusesSymlinks(t *testing.T) {
+	t.Setenv("SUDO_UID", "")
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target")
+	if err := os.WriteFile(target, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := readOwned(link); err == nil {
+		t.Error("expected a symlink swapped in after validation to be refused")
+	}
+	if _, err := readOwned(dir); err == nil {
+		t.Error("expected a directory to be refused")
+	}
+	if content, err := readOwned(target); err != nil || string(content) != "content" {
+		t.Errorf("expected the file to be read, got %q %v", content, err)
+	}
+}
+
+func TestInstallDebsFromCopies(t *testing.T) {
+	useInProcessHelper(t)
+	var args []string
+	original := privilegedCommand
+	privilegedCommand = func(name string, arg ...string) *exec.Cmd {
+		args = arg
+		return exec.Command("true")
+	}
+	t.Cleanup(func() { privilegedCommand = original })
+
+	deb := filepath.Join(t.TempDir(), "git_2.43.0_amd64.deb")
+	if err := os.WriteFile(deb, []byte("!<arch>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := elevate(privilegedRequest{Op: opAptInstall, Debs: []string{deb}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(args) != 4 || args[3] == deb || !strings.HasSuffix(args[3], "git_2.43.0_amd64.deb") {
+		t.Fatalf("expected apt-get to install a copy of the cached file, got %v", args)
+	}
+	if _, err := os.Stat(args[3]); !os.IsNotExist(err) {
+		t.Errorf("expected the copy to be removed after installing, got %v", err)
+	}
+}
+
+func TestPrivilegedHelperWritesFilesAndAudits(t *testing.T) {
+	root := useInProcessHelper(t)
+	t.Setenv("SUDO_USER", "ada")
+
+	sources := filepath.Join(aptSourcesDir, "example.sources")
+	changed, err := installRootFile(sources, []byte("Types: deb\n"))
+	if err != nil || !changed {
+		t.Fatalf("expected the sources file to be written, got changed=%t err=%v", changed, err)
+	}
+	if changed, err := installRootFile(sources, []byte("Types: deb\n")); err != nil || changed {
+		t.Errorf("expected an identical write to be skipped, got changed=%t err=%v", changed, err)
+	}
+	info, err := os.Stat(filepath.Join(root, sources))
+	if err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("expected a 0644 sources file, got %v %v", info, err)
+	}
+
+	if _, err := elevate(privilegedRequest{Op: opWriteAptFile, Path: "/etc/sudoers.d/example.list"}); err == nil {
+		t.Error("expected a write outside the APT directories to be refused")
+	}
+
+	entries := readAuditLog(t)
+	results := []string{}
+	for _, entry := range entries {
+		results = append(results, entry.Result)
+		if entry.User != "ada" {
+			t.Errorf("expected the invoking user in the audit log, got %q", entry.User)
+		}
+	}
+	if strings.Join(results, ",") != "ok,unchanged,refused" {
+		t.Errorf("unexpected audit results %v", results)
+	}
+	if entries[0].SHA256 == "" || entries[0].Args[0] != sources {
+		t.Errorf("expected the path and checksum to be audited, got %+v", entries[0])
+	}
+}
+
+func TestExecuteScriptStagesBinaries(t *testing.T) {
+	root := useInProcessHelper(t)
+
+	script := filepath.Join(t.TempDir(), "mytool.sh")
+	content := `#!/bin/bash
+case "$1" in
+    install) printf '#!/bin/sh\necho mytool\n' > "$ITAMAE_STAGE_DIR/mytool" ;;
+    remove) echo "removing" ;;
+esac
+`
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	plugin := ToolPlugin{ID: "mytool", Name: "My Tool", ScriptPath: script, Binaries: []string{"mytool"}}
+
+	if err := executeScript(plugin, "install", nil); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+	installed := filepath.Join(root, binDir, "mytool")
+	info, err := os.Stat(installed)
+	if err != nil || info.Mode().Perm() != 0755 {
+		t.Fatalf("expected an executable in /usr/local/bin, got %v %v", info, err)
+	}
+
+	if err := executeScript(plugin, "remove", nil); err != nil {
+		t.Fatalf("remove failed: %v", err)
+	}
+	if _, err := os.Stat(installed); !os.IsNotExist(err) {
+		t.Errorf("expected the binary to be removed, got %v", err)
+	}
+
+	plugin.Binaries = []string{"othertool"}
+	if err := executeScript(plugin, "install", nil); err == nil || !strings.Contains(err.Error(), "did not stage othertool") {
+		t.Errorf("expected a missing staged binary to fail, got %v", err)
+	}
+}
+
+func TestRemovePluginPurgesThroughHelper(t *testing.T) {
+	mockDir, logPath, cleanup := setupTestEnvironment()
+	defer cleanup()
+	t.Setenv("PATH", mockDir+":"+os.Getenv("PATH"))
+	useInProcessHelper(t)
+
+	script := filepath.Join(t.TempDir(), "git.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/bash\necho removed\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	plugin := ToolPlugin{ID: "git", Name: "Git", InstallMethod: "apt", PackageName: "git", ScriptPath: script}
+
+	if _, err := removePlugin(plugin); err != nil {
+		t.Fatalf("removePlugin failed: %v", err)
+	}
+	logBytes, _ := os.ReadFile(logPath)
+	if !strings.Contains(string(logBytes), "apt-get purge -y git") || strings.Contains(string(logBytes), "sudo") {
+		t.Errorf("expected apt-get purge without sudo, got:\n%s", logBytes)
+	}
+}
+
+func TestRemovePluginRemovesLegacyInstall(t *testing.T) {
+	withMockCommands(t, "bash")
+	root := useInProcessHelper(t)
+
+	legacy := filepath.Join(root, "opt", "maven")
+	bin := filepath.Join(root, "usr", "local", "bin")
+	for _, dir := range []string{filepath.Join(legacy, "bin"), bin} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(legacy, "bin", "mvn"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{"mvn": "/opt/maven/bin/mvn", "other": "/opt/other/bin/other"}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(bin, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	script := filepath.Join(t.TempDir(), "maven.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/bash\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	plugin := ToolPlugin{ID: "maven", Name: "Maven", InstallMethod: "apt", PackageName: "maven", ScriptPath: script, LegacyPaths: []string{"/opt/maven"}}
+
+	if _, err := removePlugin(plugin); err != nil {
+		t.Fatalf("removePlugin failed: %v", err)
+	}
+	if _, err := os.Lstat(legacy); !os.IsNotExist(err) {
+		t.Errorf("expected /opt/maven to be removed, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(bin, "mvn")); !os.IsNotExist(err) {
+		t.Errorf("expected the mvn symlink to be removed, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(bin, "other")); err != nil {
+		t.Errorf("expected the unrelated symlink to be kept, got %v", err)
+	}
+
+	// Nothing left to remove is not an error
+	if _, err := removePlugin(plugin); err != nil {
+		t.Errorf("expected a second removal to succeed, got %v", err)
+	}
+}
+
+func TestLegacyPathMetadata(t *testing.T) {
+	header := "#!/bin/bash\n# NAME: Maven\n# DESCRIPTION: An example.\n# INSTALL_METHOD: apt\n# PACKAGE_NAME: maven\n"
+
+	plugin, err := parseMetadata(header + "# LEGACY_PATH: /opt/maven\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(plugin.LegacyPaths, ",") != "/opt/maven" {
+		t.Errorf("unexpected legacy paths %v", plugin.LegacyPaths)
+	}
+	if _, err := parseMetadata(header + "# LEGACY_PATH: /usr\n"); err == nil {
+		t.Error("expected a LEGACY_PATH outside the helper's list to be rejected")
+	}
+}
+
+func TestBinaryMetadata(t *testing.T) {
+	header := "#!/bin/bash\n# NAME: Example\n# DESCRIPTION: An example.\n# INSTALL_METHOD: binary\n"
+
+	plugin, err := parseMetadata(header + "# BINARY: example\n# BINARY: example-helper\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(plugin.Binaries, ",") != "example,example-helper" {
+		t.Errorf("unexpected binaries %v", plugin.Binaries)
+	}
+
+	if _, err := parseMetadata(header + "# BINARY: bin/example\n"); err == nil {
+		t.Error("expected a BINARY with a '/' to be rejected")
+	}
+	if _, err := parseMetadata(header + "# BINARY: example\n# REQUIRES_ROOT: false\n"); err == nil {
+		t.Error("expected BINARY to conflict with REQUIRES_ROOT: false")
+	}
+}
+
+func TestLintRejectsSudoInBinaryPlugin(t *testing.T) {
+	script := `#!/bin/bash
+# NAME: Example
+# DESCRIPTION: An example.
+# INSTALL_METHOD: binary
+# BINARY: example
+
+install() { sudo curl -L https://example.com/example -o /usr/local/bin/example; }
+remove() { :; }
+check() { command -v example; }
+
+case "$1" in
+    install) install ;;
+    remove) remove ;;
+    check) check ;;
+esac
+`
+	issues := LintScript("example.sh", script, nil, LintOptions{})
+	if len(issues) != 2 || issues[0].Line != 0 || issues[1].Line != 7 {
+		t.Errorf("expected a missing staging warning and a sudo error on line 7, got %v", issues)
+	}
+}
@@ -0,0 +1,82 @@
+package itamae
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profile is a shareable set of plugins with optional pinned versions.
+type Profile struct {
+	Name     string            `yaml:"name"`
+	Plugins  []string          `yaml:"plugins"`
+	Versions map[string]string `yaml:"versions,omitempty"` // Plugin ID -> version, overrides VERSION metadata
+	Absent   []string          `yaml:"absent,omitempty"`   // Plugins 'itamae apply' removes when installed
+}
+
+// LoadProfile reads a profile from a YAML file.
+func LoadProfile(path string) (Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Profile{}, fmt.Errorf("failed to read profile: %w", err)
+	}
+
+	var profile Profile
+	if err := yaml.Unmarshal(data, &profile); err != nil {
+		return Profile{}, fmt.Errorf("failed to parse profile %s: %w", path, err)
+	}
+
+	if len(profile.Plugins) == 0 && len(profile.Absent) == 0 {
+		return Profile{}, fmt.Errorf("profile %s lists no plugins", path)
+	}
+
+	return profile, nil
+}
+
+// Resolve returns the profile's plugins with version overrides applied.
+func (pr Profile) Resolve(plugins []ToolPlugin) ([]ToolPlugin, error) {
+	selected, err := FindPlugins(plugins, pr.Plugins)
+	if err != nil {
+		return nil, fmt.Errorf("profile %s: %w", pr.Name, err)
+	}
+
+	listed := make(map[string]bool, len(selected))
+	for _, p := range selected {
+		listed[p.ID] = true
+	}
+	for id := range pr.Versions {
+		if !listed[id] {
+			return nil, fmt.Errorf("profile %s pins a version for %q, which it does not list", pr.Name, id)
+		}
+	}
+
+	if _, err := pr.ResolveAbsent(plugins); err != nil {
+		return nil, err
+	}
+	for _, id := range pr.Absent {
+		if listed[id] {
+			return nil, fmt.Errorf("profile %s lists %q as both a plugin and absent", pr.Name, id)
+		}
+	}
+
+	for i := range selected {
+		if version, ok := pr.Versions[selected[i].ID]; ok {
+			if !honorsVersion(selected[i]) {
+				return nil, fmt.Errorf("profile %s pins a version for %q, which always installs the latest version", pr.Name, selected[i].ID)
+			}
+			selected[i].Version = version
+		}
+	}
+
+	return selected, nil
+}
+
+// ResolveAbsent returns the plugins the profile marks as absent.
+func (pr Profile) ResolveAbsent(plugins []ToolPlugin) ([]ToolPlugin, error) {
+	absent, err := FindPlugins(plugins, pr.Absent)
+	if err != nil {
+		return nil, fmt.Errorf("profile %s: absent: %w", pr.Name, err)
+	}
+	return absent, nil
+}
@@ -0,0 +1,88 @@
+package itamae
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeProfile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "team.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestProfileResolve(t *testing.T) {
+	path := writeProfile(t, `name: team
+plugins:
+  - git
+  - kubectl
+versions:
+  git: "1:2.43.0-1ubuntu7"
+  kubectl: v1.30.2
+`)
+
+	profile, err := LoadProfile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	selected, err := profile.Resolve(plugins)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(selected) != 2 {
+		t.Fatalf("expected 2 plugins, got %d", len(selected))
+	}
+
+	if selected[1].ID != "kubectl" || selected[1].Version != "v1.30.2" {
+		t.Errorf("expected kubectl pinned to v1.30.2, got %s %s", selected[1].ID, selected[1].Version)
+	}
+	if spec := aptPackageSpec(selected[0]); spec != "git=1:2.43.0-1ubuntu7" {
+		t.Errorf("expected pinned apt spec, got %s", spec)
+	}
+}
+
+func TestProfileResolveErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown plugin":  "name: bad\nplugins: [does-not-exist]\n",
+		"unlisted pin":    "name: bad\nplugins: [git]\nversions:\n  kubectl: v1.30.2\n",
+		"missing plugins": "name: bad\n",
+		"unknown absent":  "name: bad\nabsent: [does-not-exist]\n",
+		"listed absent":   "name: bad\nplugins: [git]\nabsent: [git]\n",
+		"unpinnable":      "name: bad\nplugins: [helm]\nversions:\n  helm: v3.15.0\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			profile, err := LoadProfile(writeProfile(t, content))
+			if err == nil {
+				_, err = profile.Resolve(plugins)
+			}
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestPinnedVersionEnv(t *testing.T) {
+	for _, p := range plugins {
+		if p.ID != "kubectl" {
+			continue
+		}
+		if p.Version == "" {
+			t.Fatal("expected kubectl to declare a default VERSION")
+		}
+
+		env := InstallOptions{}.scriptEnv(p, map[string]string{"GIT_USER_NAME": "test"})
+		if env["ITAMAE_VERSION"] != p.Version || env["GIT_USER_NAME"] != "test" {
+			t.Errorf("unexpected script env: %v", env)
+		}
+		return
+	}
+	t.Fatal("kubectl plugin not found")
+}
@@ -0,0 +1,138 @@
+package itamae
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RunRemove uninstalls the plugins with the given IDs. Declarative repositories
+// are removed once no remaining installed plugin declares them.
+func RunRemove(plugins []ToolPlugin, ids []string) error {
+	selected, err := FindPlugins(plugins, ids)
+	if err != nil {
+		return err
+	}
+
+	if err := ensureSudoAccess(); err != nil {
+		return fmt.Errorf("failed to obtain sudo access: %w", err)
+	}
+	defer stopPrivilegedHelper()
+
+	removed := []ToolPlugin{}
+	failed := []string{}
+	for _, p := range selected {
+		fmt.Printf("\n🗑️  Removing %s...\n", p.Name)
+		output, err := removePlugin(p)
+		fmt.Print(output)
+		if err != nil {
+			Logger.Errorf("❌ Error removing %s: %v\n", p.Name, err)
+			failed = append(failed, p.Name)
+			continue
+		}
+		removed = append(removed, p)
+	}
+
+	if err := recordRemoved(removed); err != nil {
+		Logger.Warnf("Could not update install state: %v", err)
+	}
+
+	removing := make(map[string]bool)
+	for _, p := range removed {
+		removing[p.ID] = true
+	}
+
+	for _, repo := range collectAptRepos(removed) {
+		if users := installedRepoUsers(plugins, removing, repo); len(users) > 0 {
+			fmt.Printf("\n🔧 Keeping repository %s (still used by %s)\n", repo.URL, strings.Join(users, ", "))
+			continue
+		}
+
+		fmt.Printf("\n🔧 Removing repository %s\n", repo.URL)
+		if err := removeAptRepo(repo); err != nil {
+			Logger.Errorf("❌ %v\n", err)
+		}
+	}
+
+	names := make([]string, len(removed))
+	for i, p := range removed {
+		names[i] = p.Name
+	}
+	displayRemovalSummary(names, failed)
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d plugin(s) failed to remove", len(failed))
+	}
+	return nil
+}
+
+// removePlugin purges an APT plugin's package through the privileged helper,
+// then runs the script's remove step for anything else it cleans up, and
+// removes any LEGACY_PATH install. It returns apt's output.
+func removePlugin(p ToolPlugin) (string, error) {
+	output := ""
+	if p.InstallMethod == "apt" && p.PackageName != "" {
+		resp, err := elevate(privilegedRequest{Op: opAptRemove, Packages: []string{p.PackageName}})
+		output = resp.Output
+		if err != nil {
+			return output, err
+		}
+	}
+	if err := executeScript(p, "remove", nil); err != nil {
+		return output, err
+	}
+	return output, removeLegacyPaths(p)
+}
+
+// installedRepoUsers returns the names of installed plugins, other than those being
+// removed, that declare the given repository.
+func installedRepoUsers(plugins []ToolPlugin, removing map[string]bool, repo AptRepo) []string {
+	users := []string{}
+	for _, p := range plugins {
+		if removing[p.ID] || !p.AptRepo.IsSet() || p.AptRepo.Key() != repo.Key() {
+			continue
+		}
+		if isInstalled(p) {
+			users = append(users, p.Name)
+		}
+	}
+	return users
+}
+
+func displayRemovalSummary(removed, failed []string) {
+	fmt.Println("\n" + strings.Repeat("═", 60))
+	fmt.Println("📊 REMOVAL SUMMARY")
+	fmt.Println(strings.Repeat("═", 60))
+
+	if len(removed) > 0 {
+		fmt.Println("\n✅ Successfully removed:")
+		for _, name := range removed {
+			fmt.Printf("   • %s\n", name)
+		}
+	}
+
+	if len(failed) > 0 {
+		fmt.Println("\n❌ Failed to remove:")
+		for _, name := range failed {
+			fmt.Printf("   • %s\n", name)
+		}
+	}
+
+	fmt.Println("\n" + strings.Repeat("═", 60))
+}
+
+// removeLegacyPaths removes the directories earlier versions of a plugin
+// installed into, declared with LEGACY_PATH, once the plugin no longer needs
+// them.
+func removeLegacyPaths(plugin ToolPlugin) error {
+	for _, path := range plugin.LegacyPaths {
+		if _, err := os.Lstat(filepath.Join(privilegedRoot, path)); err != nil {
+			continue
+		}
+		if _, err := elevate(privilegedRequest{Op: opRemoveLegacy, Path: path}); err != nil {
+			return fmt.Errorf("failed to remove the old %s install in %s: %w", plugin.Name, path, err)
+		}
+	}
+	return nil
+}
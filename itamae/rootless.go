@@ -0,0 +1,31 @@
+package itamae
+
+// ExcludedPlugin is a plugin left out of a run, with the reason why.
+type ExcludedPlugin struct {
+	Plugin ToolPlugin
+	Reason string
+}
+
+// Reasons a plugin is excluded from --user mode
+const (
+	ReasonAptNeedsRoot = "APT packages are installed system-wide"
+	ReasonRequiresRoot = "installs outside $HOME (no REQUIRES_ROOT: false)"
+)
+
+// UserSpacePlugins splits plugins into those that install into $HOME without
+// sudo and those that need root, keeping their order.
+func UserSpacePlugins(plugins []ToolPlugin) ([]ToolPlugin, []ExcludedPlugin) {
+	kept := []ToolPlugin{}
+	excluded := []ExcludedPlugin{}
+	for _, p := range plugins {
+		switch {
+		case p.InstallMethod == "apt":
+			excluded = append(excluded, ExcludedPlugin{Plugin: p, Reason: ReasonAptNeedsRoot})
+		case p.RequiresRoot:
+			excluded = append(excluded, ExcludedPlugin{Plugin: p, Reason: ReasonRequiresRoot})
+		default:
+			kept = append(kept, p)
+		}
+	}
+	return kept, excluded
+}
@@ -0,0 +1,100 @@
+package itamae
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRequiresRootMetadata(t *testing.T) {
+	header := "#!/bin/bash\n# NAME: Example\n# DESCRIPTION: An example.\n"
+
+	plugin, err := parseMetadata(header + "# INSTALL_METHOD: binary\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !plugin.RequiresRoot {
+		t.Error("expected plugins to require root by default")
+	}
+
+	plugin, err = parseMetadata(header + "# INSTALL_METHOD: binary\n# REQUIRES_ROOT: false\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plugin.RequiresRoot {
+		t.Error("expected REQUIRES_ROOT: false to be honoured")
+	}
+
+	_, err = parseMetadata(header + "# INSTALL_METHOD: apt\n# PACKAGE_NAME: example\n# REQUIRES_ROOT: false\n")
+	if err == nil || !strings.Contains(err.Error(), "line 6: REQUIRES_ROOT false is not possible for apt plugins") {
+		t.Errorf("expected apt plugins to reject REQUIRES_ROOT: false, got %v", err)
+	}
+}
+
+func TestUserSpacePlugins(t *testing.T) {
+	kept, excluded := UserSpacePlugins([]ToolPlugin{
+		{ID: "git", InstallMethod: "apt", RequiresRoot: true},
+		{ID: "helm", InstallMethod: "binary", RequiresRoot: true},
+		{ID: "task", InstallMethod: "binary"},
+	})
+
+	if len(kept) != 1 || kept[0].ID != "task" {
+		t.Errorf("expected only task to be kept, got %+v", kept)
+	}
+	if len(excluded) != 2 || excluded[0].Reason != ReasonAptNeedsRoot || excluded[1].Reason != ReasonRequiresRoot {
+		t.Errorf("unexpected exclusions: %+v", excluded)
+	}
+}
+
+func TestEmbeddedUserSpacePlugins(t *testing.T) {
+	kept, _ := UserSpacePlugins(plugins)
+	ids := map[string]bool{}
+	for _, p := range kept {
+		ids[p.ID] = true
+	}
+	for _, id := range []string{"task", "kubecolor"} {
+		if !ids[id] {
+			t.Errorf("expected %s to be installable without root", id)
+		}
+	}
+	for _, id := range []string{"git", "helm", "kubectl"} {
+		if ids[id] {
+			t.Errorf("expected %s to need root", id)
+		}
+	}
+}
+
+func TestScriptEnvUserMode(t *testing.T) {
+	env := InstallOptions{UserMode: true}.scriptEnv(ToolPlugin{ID: "starship"}, nil)
+	if env["ITAMAE_USER_MODE"] != "1" {
+		t.Errorf("expected ITAMAE_USER_MODE=1, got %v", env)
+	}
+	if _, ok := (InstallOptions{}).scriptEnv(ToolPlugin{ID: "starship"}, nil)["ITAMAE_USER_MODE"]; ok {
+		t.Error("expected ITAMAE_USER_MODE to be unset outside user mode")
+	}
+}
+
+func TestLintRejectsSudoInUserSpacePlugin(t *testing.T) {
+	script := `#!/bin/bash
+# NAME: Example
+# DESCRIPTION: An example.
+# INSTALL_METHOD: binary
+# REQUIRES_ROOT: false
+
+install() {
+    # sudo is mentioned in a comment only
+    sudo cp example /usr/local/bin/
+}
+remove() { rm -f ~/.local/bin/example; }
+check() { command -v example; }
+
+case "$1" in
+    install) install ;;
+    remove) remove ;;
+    check) check ;;
+esac
+`
+	issues := LintScript("example.sh", script, nil, LintOptions{})
+	if len(issues) != 1 || issues[0].Line != 9 || issues[0].Severity != LintError {
+		t.Errorf("expected one error on line 9, got %v", issues)
+	}
+}
@@ -0,0 +1,52 @@
+package itamae
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// routerCasePattern matches a case label such as "install)" or "setup_repo)".
+var routerCasePattern = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_-]*(?:\|[A-Za-z_][A-Za-z0-9_-]*)*)\)`)
+
+// routerCases returns the commands handled by a script's `case "$1" in` router.
+func routerCases(content string) []string {
+	cases := []string{}
+	inRouter := false
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if !inRouter {
+			inRouter = strings.HasPrefix(trimmed, `case "$1" in`)
+			continue
+		}
+		if trimmed == "esac" {
+			break
+		}
+
+		if match := routerCasePattern.FindStringSubmatch(line); match != nil {
+			cases = append(cases, strings.Split(match[1], "|")...)
+		}
+	}
+
+	return cases
+}
+
+// hasRouterCase reports whether the plugin's script handles the given command.
+func hasRouterCase(plugin ToolPlugin, command string) bool {
+	content, err := os.ReadFile(plugin.ScriptPath)
+	if err != nil {
+		return false
+	}
+
+	for _, c := range routerCases(string(content)) {
+		if c == command {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package itamae
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRouterCases(t *testing.T) {
+	content := `#!/bin/bash
+install() { :; }
+
+# --- ROUTER ---
+case "$1" in
+    setup_repo) setup_repo ;;
+    install|reinstall) install ;;
+    check) check ;;
+    *) echo "Usage" && exit 1 ;;
+esac
+`
+	got := strings.Join(routerCases(content), ",")
+	if got != "setup_repo,install,reinstall,check" {
+		t.Errorf("unexpected router cases: %s", got)
+	}
+}
+
+func TestEmbeddedScriptsHandleCheck(t *testing.T) {
+	for _, plugin := range plugins {
+		if !hasRouterCase(plugin, "check") {
+			t.Errorf("plugin '%s' has no check router case", plugin.ID)
+		}
+	}
+
+	for _, plugin := range plugins {
+		if plugin.ID == "kubectl" && !hasRouterCase(plugin, "version") {
+			t.Error("expected kubectl to have a version router case")
+		}
+	}
+}
+
+func TestUpgradeLabel(t *testing.T) {
+	plugin := ToolPlugin{Name: "kubectl"}
+
+	if got := upgradeLabel(plugin, "v1.30.0", "v1.31.0"); got != "kubectl (v1.30.0 → v1.31.0)" {
+		t.Errorf("unexpected label: %s", got)
+	}
+	if got := upgradeLabel(plugin, "v1.31.0", "v1.31.0"); got != "kubectl (v1.31.0, unchanged)" {
+		t.Errorf("unexpected label: %s", got)
+	}
+	if got := upgradeLabel(plugin, "", "v1.31.0"); got != "kubectl (unknown → v1.31.0)" {
+		t.Errorf("unexpected label: %s", got)
+	}
+}
+
+func TestResolvedVersionPrefersDetected(t *testing.T) {
+	dir := t.TempDir()
+
+	// An installer that ignored the pin reports what it really installed
+	plugin := writeCheckPlugin(t, dir, "kubectl", true, "v1.31.0")
+	plugin.Version = "v1.30.2"
+	if got := resolvedVersion(plugin); got != "v1.31.0" {
+		t.Errorf("expected the detected version, got %s", got)
+	}
+
+	plugin = writeCheckPlugin(t, dir, "silent", true, "")
+	plugin.Version = "v1.30.2"
+	if got := resolvedVersion(plugin); got != "v1.30.2" {
+		t.Errorf("expected the pin when nothing is detected, got %s", got)
+	}
+	plugin.Version = ""
+	if got := resolvedVersion(plugin); got != "latest" {
+		t.Errorf("expected latest without a pin or a detected version, got %s", got)
+	}
+}
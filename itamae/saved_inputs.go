@@ -0,0 +1,192 @@
+package itamae
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// TeamInputsFile is the name of the team-wide input defaults file picked up
+// next to a profile.
+const TeamInputsFile = "inputs.toml"
+
+// InputsFile returns the file holding the user's remembered input answers
+// (~/.config/itamae/inputs.toml), overridable with ITAMAE_INPUTS_FILE.
+func InputsFile() string {
+	if path := os.Getenv("ITAMAE_INPUTS_FILE"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(os.Getenv("HOME"), ".config", "itamae", "inputs.toml")
+	}
+	return filepath.Join(dir, "itamae", "inputs.toml")
+}
+
+// ProfileInputsPath returns where the team defaults file for a profile lives,
+// whether or not it exists.
+func ProfileInputsPath(profilePath string) string {
+	return filepath.Join(filepath.Dir(profilePath), TeamInputsFile)
+}
+
+// TeamInputsPath returns the team defaults file next to a profile, or "" if
+// the profile has none.
+func TeamInputsPath(profilePath string) string {
+	if profilePath == "" {
+		return ""
+	}
+	path := ProfileInputsPath(profilePath)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// LoadInputAnswers reads a TOML file of NAME = "value" pairs. A missing file
+// yields no answers.
+func LoadInputAnswers(path string) (map[string]string, error) {
+	answers := map[string]string{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return answers, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := toml.Unmarshal(data, &answers); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return answers, nil
+}
+
+// LoadInputDefaults merges the answer files in order, later files taking
+// precedence. Empty paths are skipped.
+func LoadInputDefaults(paths ...string) (map[string]string, error) {
+	defaults := map[string]string{}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		answers, err := LoadInputAnswers(path)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range answers {
+			defaults[name] = value
+		}
+	}
+	return defaults, nil
+}
+
+// SaveInputAnswers writes answers to a TOML file, sorted by name.
+func SaveInputAnswers(path string, answers map[string]string) error {
+	var b bytes.Buffer
+	b.WriteString("# Input answers remembered by itamae. Edit with 'itamae inputs set|unset'.\n")
+	if err := toml.NewEncoder(&b).Encode(answers); err != nil {
+		return fmt.Errorf("failed to encode inputs: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// rememberInputs saves the non-secret answers to path, leaving other entries
+// in the file untouched. Nothing is written when no answer changed.
+func rememberInputs(path string, inputs []Input, values map[string]string) error {
+	if path == "" {
+		return nil
+	}
+	answers, err := LoadInputAnswers(path)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, input := range inputs {
+		value, ok := values[input.Name]
+		if !ok || value == "" || input.kind() == InputSecret || answers[input.Name] == value {
+			continue
+		}
+		answers[input.Name] = value
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	DebugLog("Saving input answers to %s", path)
+	return SaveInputAnswers(path, answers)
+}
+
+// InputUsage is an input declared by one or more plugins.
+type InputUsage struct {
+	Input   Input
+	Plugins []string // IDs of the plugins that require it
+}
+
+// KnownInputs returns every input the plugins require, in plugin order.
+func KnownInputs(plugins []ToolPlugin) []InputUsage {
+	index := map[string]int{}
+	usages := []InputUsage{}
+	for _, p := range plugins {
+		for _, input := range p.RequiredInputs {
+			i, ok := index[input.Name]
+			if !ok {
+				i = len(usages)
+				index[input.Name] = i
+				usages = append(usages, InputUsage{Input: input})
+			}
+			usages[i].Plugins = append(usages[i].Plugins, p.ID)
+		}
+	}
+	return usages
+}
+
+// SetInputAnswer validates a value against the input's declaration and saves
+// it to the answers file at path.
+func SetInputAnswer(path string, plugins []ToolPlugin, name, value string) error {
+	var input *Input
+	for _, usage := range KnownInputs(plugins) {
+		if usage.Input.Name == name {
+			input = &usage.Input
+			break
+		}
+	}
+	if input == nil {
+		return fmt.Errorf("unknown input %s: no plugin requires it", name)
+	}
+	if input.kind() == InputSecret {
+		return fmt.Errorf("%s is a secret; remember it with --secret-store instead", name)
+	}
+	if err := input.Validate(value); err != nil {
+		return err
+	}
+
+	answers, err := LoadInputAnswers(path)
+	if err != nil {
+		return err
+	}
+	answers[name] = input.normalize(value)
+	return SaveInputAnswers(path, answers)
+}
+
+// UnsetInputAnswers removes answers from the file at path.
+func UnsetInputAnswers(path string, names []string) error {
+	answers, err := LoadInputAnswers(path)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, ok := answers[name]; !ok {
+			return fmt.Errorf("%s is not set in %s", name, path)
+		}
+		delete(answers, name)
+	}
+	return SaveInputAnswers(path, answers)
+}
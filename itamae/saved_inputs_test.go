@@ -0,0 +1,102 @@
+package itamae
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/huh"
+)
+
+func TestLoadInputDefaultsPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	team := filepath.Join(dir, "team.toml")
+	user := filepath.Join(dir, "user.toml")
+	os.WriteFile(team, []byte("EDITOR = \"vim\"\nREGISTRY = \"registry.example.com\"\n"), 0644)
+	os.WriteFile(user, []byte("EDITOR = \"nano\"\n"), 0644)
+
+	defaults, err := LoadInputDefaults(team, "", user, filepath.Join(dir, "missing.toml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if defaults["EDITOR"] != "nano" || defaults["REGISTRY"] != "registry.example.com" {
+		t.Errorf("unexpected defaults: %v", defaults)
+	}
+}
+
+func TestGatherInputsRemembersAnswers(t *testing.T) {
+	original := newFormRunner
+	newFormRunner = func(form *huh.Form) formRunner { return stubFormRunner{} }
+	defer func() { newFormRunner = original }()
+
+	path := filepath.Join(t.TempDir(), "inputs.toml")
+	os.WriteFile(path, []byte("OTHER = \"kept\"\n"), 0644)
+
+	plugins := []ToolPlugin{{RequiredInputs: []Input{
+		{Name: "EMAIL", Prompt: "Email", Type: InputEmail, DefaultCmd: "echo cmd@example.com"},
+		{Name: "TOKEN", Prompt: "Token", Type: InputSecret, DefaultCmd: "echo tok-remember"},
+	}}}
+	opts := InstallOptions{
+		InputDefaults: map[string]string{"EMAIL": "saved@example.com", "TOKEN": "ignored"},
+		InputsFile:    path,
+	}
+
+	// The stub form keeps the pre-filled values
+	values, err := gatherInputs(plugins, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if values["EMAIL"] != "saved@example.com" || values["TOKEN"] != "tok-remember" {
+		t.Errorf("unexpected values: %v", values)
+	}
+
+	answers, err := LoadInputAnswers(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answers["EMAIL"] != "saved@example.com" || answers["OTHER"] != "kept" {
+		t.Errorf("unexpected saved answers: %v", answers)
+	}
+	if _, ok := answers["TOKEN"]; ok {
+		t.Error("secret input was saved to the answers file")
+	}
+}
+
+func TestInputDefaultIgnoresInvalidAnswers(t *testing.T) {
+	input := Input{Name: "EDITOR", Type: InputChoice, Choices: []string{"vim", "nano"}, DefaultCmd: "echo vim"}
+	if got := inputDefault(input, map[string]string{"EDITOR": "emacs"}); got != "vim" {
+		t.Errorf("expected the default command's value, got %q", got)
+	}
+}
+
+func TestSetAndUnsetInputAnswer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inputs.toml")
+	plugins := []ToolPlugin{{ID: "tool", RequiredInputs: []Input{
+		{Name: "DEBUG", Prompt: "Debug", Type: InputBool},
+		{Name: "TOKEN", Prompt: "Token", Type: InputSecret},
+	}}}
+
+	if err := SetInputAnswer(path, plugins, "DEBUG", "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if answers, _ := LoadInputAnswers(path); answers["DEBUG"] != "true" {
+		t.Errorf("expected a normalized bool, got %v", answers)
+	}
+
+	for _, tt := range []struct{ name, value string }{
+		{"DEBUG", "maybe"},
+		{"TOKEN", "secret"},
+		{"UNKNOWN", "x"},
+	} {
+		if err := SetInputAnswer(path, plugins, tt.name, tt.value); err == nil {
+			t.Errorf("expected setting %s=%s to fail", tt.name, tt.value)
+		}
+	}
+
+	if err := UnsetInputAnswers(path, []string{"DEBUG"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := UnsetInputAnswers(path, []string{"DEBUG"}); err == nil {
+		t.Error("expected unsetting a missing answer to fail")
+	}
+}
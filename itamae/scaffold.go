@@ -0,0 +1,193 @@
+package itamae
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"text/template"
+)
+
+// pluginIDPattern matches valid plugin IDs (the script file name without .sh).
+var pluginIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// ScaffoldOptions describes a new plugin script to generate.
+type ScaffoldOptions struct {
+	ID          string
+	Method      string // "apt", "binary" or "manual"
+	Name        string // Defaults to the ID
+	Description string
+	SetupRepo   bool // Add a setup_repo skeleton (apt only)
+	PostInstall bool // Add a post_install skeleton
+}
+
+func (o ScaffoldOptions) validate() error {
+	if !pluginIDPattern.MatchString(o.ID) {
+		return fmt.Errorf("invalid plugin id %q: use lower-case letters, digits, '.', '-' and '_'", o.ID)
+	}
+	switch o.Method {
+	case "apt", "binary", "manual":
+	default:
+		return fmt.Errorf("invalid install method %q: must be apt, binary or manual", o.Method)
+	}
+	if o.SetupRepo && o.Method != "apt" {
+		return fmt.Errorf("setup_repo is only supported for apt plugins")
+	}
+	return nil
+}
+
+var pluginTemplate = template.Must(template.New("plugin").Parse(`#!/bin/bash
+#
+# METADATA
+# METADATA_VERSION: {{.MetadataVersion}}
+# NAME: {{.Name}}
+# DESCRIPTION: {{.Description}}
+# INSTALL_METHOD: {{.Method}}
+{{- if eq .Method "apt"}}
+# PACKAGE_NAME: {{.ID}}
+{{- else if eq .Method "binary"}}
+# BINARY: {{.ID}}
+{{- end}}
+{{- if .SetupRepo}}
+# REPO_SETUP: setup_repo
+{{- end}}
+{{- if .PostInstall}}
+# POST_INSTALL: post_install
+{{- end}}
+#
+{{- if .SetupRepo}}
+
+setup_repo() {
+    echo "Setting up {{.Name}} repository..."
+    # TODO: add the signing key and sources list.
+    # Prefer declaring APT_REPO/APT_KEY_URL metadata when the repository fits it.
+}
+{{- end}}
+{{- if .PostInstall}}
+
+post_install() {
+    echo "Configuring {{.Name}}..."
+    # TODO: configure {{.Name}} after installation.
+    echo "✅ {{.Name}} configured."
+}
+{{- end}}
+
+install() {
+    echo "Installing {{.Name}}..."
+{{- if eq .Method "apt"}}
+    if command -v nala &> /dev/null; then
+        sudo nala install -y {{.ID}}
+    else
+        sudo apt-get install -y {{.ID}}
+    fi
+{{- else if eq .Method "binary"}}
+    # TODO: download the release binary. itamae installs it into /usr/local/bin.
+    curl --silent -L "https://example.com/{{.ID}}" -o "$ITAMAE_STAGE_DIR/{{.ID}}"
+{{- else}}
+    # TODO: install {{.Name}}.
+{{- end}}
+    echo "✅ {{.Name}} installed."
+}
+
+remove() {
+    echo "Removing {{.Name}}..."
+{{- if eq .Method "apt"}}
+    # itamae purges the package; remove anything else install created here.
+{{- else if eq .Method "binary"}}
+    # itamae deletes /usr/local/bin/{{.ID}}; remove anything else install created here.
+{{- else}}
+    # TODO: remove {{.Name}}.
+{{- end}}
+    echo "✅ {{.Name}} removed."
+}
+
+check() {
+    command -v {{.ID}} &> /dev/null
+}
+
+# --- ROUTER ---
+case "$1" in
+    install) install ;;
+    remove) remove ;;
+    check) check ;;
+{{- if .SetupRepo}}
+    setup_repo) setup_repo ;;
+{{- end}}
+{{- if .PostInstall}}
+    post_install) post_install ;;
+{{- end}}
+    *) echo "Usage: $0 {{"{"}}{{.Usage}}{{"}"}}" && exit 1 ;;
+esac
+`))
+
+// RenderPluginScript renders a new plugin script with the METADATA header and
+// the standard router.
+func RenderPluginScript(opts ScaffoldOptions) (string, error) {
+	if err := opts.validate(); err != nil {
+		return "", err
+	}
+	if opts.Name == "" {
+		opts.Name = opts.ID
+	}
+	if opts.Description == "" {
+		opts.Description = "TODO: describe " + opts.Name + "."
+	}
+
+	usage := "install|remove|check"
+	if opts.SetupRepo {
+		usage += "|setup_repo"
+	}
+	if opts.PostInstall {
+		usage += "|post_install"
+	}
+
+	data := struct {
+		ScaffoldOptions
+		Usage           string
+		MetadataVersion int
+	}{opts, usage, MetadataVersion}
+
+	var b bytes.Buffer
+	if err := pluginTemplate.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render plugin script: %w", err)
+	}
+	return b.String(), nil
+}
+
+// WritePluginScript renders a new plugin into dir and returns its path.
+// Existing files are only replaced when force is set.
+func WritePluginScript(dir string, opts ScaffoldOptions, force bool) (string, error) {
+	script, err := RenderPluginScript(opts)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, opts.ID+".sh")
+	if _, err := os.Stat(path); err == nil && !force {
+		return "", fmt.Errorf("%s already exists (use --force to overwrite)", path)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create plugin directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// AssertionStub returns a pluginAssertions entry for main_test.go matching the
+// commands the generated script runs.
+func AssertionStub(opts ScaffoldOptions) string {
+	install, remove := "TODO", "TODO"
+	switch opts.Method {
+	case "apt":
+		install = "sudo nala install -y " + opts.ID
+		remove = ""
+	case "binary":
+		install = "curl --silent -L"
+		remove = ""
+	}
+	return fmt.Sprintf("%q: {install: %q, remove: %q},", opts.ID, install, remove)
+}
@@ -0,0 +1,116 @@
+package itamae
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderPluginScriptLintsClean(t *testing.T) {
+	variants := []ScaffoldOptions{
+		{ID: "mytool", Method: "apt"},
+		{ID: "mytool", Method: "apt", SetupRepo: true, PostInstall: true},
+		{ID: "mytool", Method: "binary", PostInstall: true},
+		{ID: "mytool", Method: "manual", Name: "My Tool", Description: "A tool."},
+	}
+
+	for _, opts := range variants {
+		script, err := RenderPluginScript(opts)
+		if err != nil {
+			t.Fatalf("RenderPluginScript(%+v) failed: %v", opts, err)
+		}
+		for _, issue := range LintScript("mytool.sh", script, nil, LintOptions{}) {
+			t.Errorf("%+v: %s", opts, issue)
+		}
+	}
+}
+
+func TestRenderPluginScriptValidation(t *testing.T) {
+	invalid := []ScaffoldOptions{
+		{ID: "My Tool", Method: "apt"},
+		{ID: "mytool", Method: "snap"},
+		{ID: "mytool", Method: "binary", SetupRepo: true},
+	}
+	for _, opts := range invalid {
+		if _, err := RenderPluginScript(opts); err == nil {
+			t.Errorf("expected an error for %+v", opts)
+		}
+	}
+}
+
+func TestWritePluginScript(t *testing.T) {
+	dir := t.TempDir()
+	opts := ScaffoldOptions{ID: "mytool", Method: "apt"}
+
+	path, err := WritePluginScript(dir, opts, false)
+	if err != nil {
+		t.Fatalf("WritePluginScript failed: %v", err)
+	}
+	if !strings.HasSuffix(path, "mytool.sh") {
+		t.Errorf("unexpected path %s", path)
+	}
+
+	if _, err := WritePluginScript(dir, opts, false); err == nil {
+		t.Error("expected an error when the script already exists")
+	}
+	if _, err := WritePluginScript(dir, opts, true); err != nil {
+		t.Errorf("expected --force to overwrite, got %v", err)
+	}
+}
+
+func TestAssertionStub(t *testing.T) {
+	got := AssertionStub(ScaffoldOptions{ID: "mytool", Method: "apt"})
+	want := `"mytool": {install: "sudo nala install -y mytool", remove: ""},`
+	if got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestLoadAllPluginsIncludesUserPlugins(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ITAMAE_PLUGIN_DIR", dir)
+
+	for _, opts := range []ScaffoldOptions{
+		{ID: "mytool", Method: "binary"},
+		{ID: "git", Method: "apt", Description: "My own git."},
+	} {
+		if _, err := WritePluginScript(dir, opts, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A malformed plugin is skipped rather than failing the whole load
+	if err := os.WriteFile(filepath.Join(dir, "broken.sh"), []byte("#!/bin/bash\n# NAME: broken\n# ARTIFACT: broken\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	all, cleanup, err := LoadAllPlugins()
+	if err != nil {
+		t.Fatalf("LoadAllPlugins failed: %v", err)
+	}
+	defer cleanup()
+	if _, err := FindPlugins(all, []string{"broken"}); err == nil {
+		t.Error("expected the malformed plugin to be skipped")
+	}
+
+	found, err := FindPlugins(all, []string{"mytool", "git"})
+	if err != nil {
+		t.Fatalf("expected user plugins to be loaded: %v", err)
+	}
+	if found[0].Category != CategoryUser {
+		t.Errorf("expected category %s, got %s", CategoryUser, found[0].Category)
+	}
+	if found[1].Description != "My own git." {
+		t.Errorf("expected the user plugin to override git, got %q", found[1].Description)
+	}
+
+	gits := 0
+	for _, p := range all {
+		if p.ID == "git" {
+			gits++
+		}
+	}
+	if gits != 1 {
+		t.Errorf("expected one git plugin, got %d", gits)
+	}
+}
@@ -3,6 +3,7 @@
 # METADATA
 # NAME: Alacritty
 # DESCRIPTION: A fast, cross-platform, OpenGL terminal emulator.
+# TAGS: terminal, gui
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: alacritty
 #
@@ -19,7 +20,6 @@ install() {
 
 remove() {
     echo "Removing Alacritty..."
-    sudo apt-get purge -y alacritty
     echo "✅ Alacritty removed."
 }
 
@@ -3,6 +3,7 @@
 # METADATA
 # NAME: apt-transport-https
 # DESCRIPTION: Allows using repositories over HTTPS.
+# TAGS: apt, system
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: apt-transport-https
 #
@@ -19,7 +20,6 @@ install() {
 
 remove() {
     echo "Removing apt-transport-https..."
-    sudo apt-get purge -y apt-transport-https
     echo "✅ apt-transport-https removed."
 }
 
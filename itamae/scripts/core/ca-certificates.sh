@@ -3,6 +3,7 @@
 # METADATA
 # NAME: ca-certificates
 # DESCRIPTION: Provides common CA certificates for SSL/TLS.
+# TAGS: ssl, security, system
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: ca-certificates
 #
@@ -19,7 +20,6 @@ install() {
 
 remove() {
     echo "Removing ca-certificates..."
-    sudo apt-get purge -y ca-certificates
     echo "✅ ca-certificates removed."
 }
 
@@ -3,6 +3,8 @@
 # METADATA
 # NAME: curl
 # DESCRIPTION: A tool to transfer data from or to a server.
+# TAGS: http, network, download
+# OMAKASE: true
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: curl
 #
@@ -19,7 +21,6 @@ install() {
 
 remove() {
     echo "Removing curl..."
-    sudo apt-get purge -y curl
     echo "✅ curl removed."
 }
 
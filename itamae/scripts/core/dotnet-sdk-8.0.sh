@@ -3,19 +3,13 @@
 # METADATA
 # NAME: .NET SDK 8.0
 # DESCRIPTION: The Microsoft .NET 8.0 Software Development Kit.
+# TAGS: dotnet, csharp, language
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: dotnet-sdk-8.0
-# REPO_SETUP: setup_repo
+# APT_REPO: https://packages.microsoft.com/repos/microsoft-ubuntu-{codename}-prod {codename} main
+# APT_KEY_URL: https://packages.microsoft.com/keys/microsoft.asc
 #
 
-setup_repo() {
-    echo "Setting up Microsoft repository..."
-    wget -q https://packages.microsoft.com/config/ubuntu/$(lsb_release -rs)/packages-microsoft-prod.deb -O /tmp/packages-microsoft-prod.deb 2>/dev/null
-    sudo dpkg -i /tmp/packages-microsoft-prod.deb > /dev/null 2>&1
-    rm /tmp/packages-microsoft-prod.deb
-    echo "✅ Microsoft repository configured."
-}
-
 install() {
     echo "Installing .NET SDK 8.0..."
     if command -v nala &> /dev/null; then
@@ -28,9 +22,6 @@ install() {
 
 remove() {
     echo "Removing .NET SDK 8.0..."
-    sudo apt-get purge -y dotnet-sdk-8.0
-    sudo rm -f /etc/apt/sources.list.d/microsoft-prod.list
-    sudo rm -f /etc/apt/trusted.gpg.d/microsoft.gpg
     echo "✅ .NET SDK 8.0 removed."
 }
 
@@ -40,9 +31,8 @@ check() {
 
 # --- ROUTER ---
 case "$1" in
-    setup_repo) setup_repo ;;
     install) install ;;
     remove) remove ;;
     check) check ;;
-    *) echo "Usage: $0 {setup_repo|install|remove|check}" && exit 1 ;;
+    *) echo "Usage: $0 {install|remove|check}" && exit 1 ;;
 esac
@@ -3,6 +3,8 @@
 # METADATA
 # NAME: fd (fd-find)
 # DESCRIPTION: A fast and user-friendly alternative to 'find'.
+# TAGS: search, files, cli
+# OMAKASE: true
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: fd-find
 # POST_INSTALL: post_install
@@ -28,7 +30,6 @@ install() {
 
 remove() {
     echo "Removing fd..."
-    sudo apt-get purge -y fd-find
     rm -f "$HOME/.local/bin/fd"
     echo "✅ fd removed."
 }
@@ -3,6 +3,8 @@
 # METADATA
 # NAME: fzf
 # DESCRIPTION: A command-line fuzzy finder.
+# TAGS: search, fuzzy, cli
+# OMAKASE: true
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: fzf
 #
@@ -19,7 +21,6 @@ install() {
 
 remove() {
     echo "Removing fzf..."
-    sudo apt-get purge -y fzf
     echo "✅ fzf removed."
 }
 
@@ -3,20 +3,14 @@
 # METADATA
 # NAME: GitHub CLI
 # DESCRIPTION: The official GitHub command-line tool.
+# TAGS: git, github, vcs
+# OMAKASE: true
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: gh
-# REPO_SETUP: setup_repo
+# APT_REPO: [arch={arch}] https://cli.github.com/packages stable main
+# APT_KEY_URL: https://cli.github.com/packages/githubcli-archive-keyring.gpg
 #
 
-setup_repo() {
-    echo "Setting up GitHub CLI repository..."
-    sudo mkdir -p /etc/apt/keyrings
-    wget -qO- https://cli.github.com/packages/githubcli-archive-keyring.gpg 2>/dev/null | sudo tee /etc/apt/keyrings/githubcli-archive-keyring.gpg > /dev/null
-    sudo chmod go+r /etc/apt/keyrings/githubcli-archive-keyring.gpg
-    echo "deb [arch=$(dpkg --print-architecture) signed-by=/etc/apt/keyrings/githubcli-archive-keyring.gpg] https://cli.github.com/packages stable main" | sudo tee /etc/apt/sources.list.d/github-cli.list > /dev/null
-    echo "✅ GitHub CLI repository configured."
-}
-
 install() {
     echo "Installing GitHub CLI..."
     if command -v nala &> /dev/null; then
@@ -29,9 +23,6 @@ install() {
 
 remove() {
     echo "Removing GitHub CLI..."
-    sudo apt-get purge -y gh
-    sudo rm -f /etc/apt/sources.list.d/github-cli.list
-    sudo rm -f /etc/apt/keyrings/githubcli-archive-keyring.gpg
     echo "✅ GitHub CLI removed."
 }
 
@@ -41,9 +32,8 @@ check() {
 
 # --- ROUTER ---
 case "$1" in
-    setup_repo) setup_repo ;;
     install) install ;;
     remove) remove ;;
     check) check ;;
-    *) echo "Usage: $0 {setup_repo|install|remove|check}" && exit 1 ;;
+    *) echo "Usage: $0 {install|remove|check}" && exit 1 ;;
 esac
@@ -3,10 +3,13 @@
 # METADATA
 # NAME: Git
 # DESCRIPTION: A free and open source distributed version control system.
+# TAGS: git, vcs
+# OMAKASE: true
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: git
 # REQUIRES: GIT_USER_NAME|Enter your Git user name|git config --global user.name 2>/dev/null || echo ''
-# REQUIRES: GIT_USER_EMAIL|Enter your Git user email|git config --global user.email 2>/dev/null || echo ''
+# REQUIRES: GIT_USER_EMAIL:email|Enter your Git user email|git config --global user.email 2>/dev/null || echo ''
+# INPUT_HELP: GIT_USER_EMAIL Recorded as the author email of your commits
 
 install() {
     echo "Installing Git..."
@@ -29,7 +32,6 @@ install() {
 
 remove() {
     echo "Removing Git..."
-    sudo apt-get purge -y git
     echo "✅ Git removed."
 }
 
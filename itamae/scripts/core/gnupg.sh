@@ -3,6 +3,7 @@
 # METADATA
 # NAME: gnupg
 # DESCRIPTION: The GNU Privacy Guard, for encryption and signing.
+# TAGS: gpg, security, encryption
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: gnupg
 #
@@ -19,7 +20,6 @@ install() {
 
 remove() {
     echo "Removing gnupg..."
-    sudo apt-get purge -y gnupg
     echo "✅ gnupg removed."
 }
 
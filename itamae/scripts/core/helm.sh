@@ -3,18 +3,22 @@
 # METADATA
 # NAME: Helm
 # DESCRIPTION: The package manager for Kubernetes.
+# TAGS: kubernetes, k8s, cloud
 # INSTALL_METHOD: binary
+# BINARY: helm
 #
 
 install() {
     echo "Installing Helm..."
-    curl --silent https://raw.githubusercontent.com/helm/helm/main/scripts/get-helm-3 | bash
+    # Let the upstream installer write into the staging directory without sudo;
+    # itamae installs the staged binary into /usr/local/bin
+    curl --silent https://raw.githubusercontent.com/helm/helm/main/scripts/get-helm-3 | HELM_INSTALL_DIR="$ITAMAE_STAGE_DIR" USE_SUDO=false bash
     echo "✅ Helm installed."
 }
 
 remove() {
     echo "Removing Helm..."
-    sudo rm -f /usr/local/bin/helm
+    # itamae deletes /usr/local/bin/helm
     echo "✅ Helm removed."
 }
 
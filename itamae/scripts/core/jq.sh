@@ -3,6 +3,8 @@
 # METADATA
 # NAME: jq
 # DESCRIPTION: A lightweight and flexible command-line JSON processor.
+# TAGS: json, cli
+# OMAKASE: true
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: jq
 #
@@ -19,7 +21,6 @@ install() {
 
 remove() {
     echo "Removing jq..."
-    sudo apt-get purge -y jq
     echo "✅ jq removed."
 }
 
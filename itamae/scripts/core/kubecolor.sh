@@ -3,7 +3,9 @@
 # METADATA
 # NAME: kubecolor
 # DESCRIPTION: A tool to colorize kubectl output.
+# TAGS: kubernetes, k8s, cloud
 # INSTALL_METHOD: binary
+# REQUIRES_ROOT: false
 #
 
 BINDIR="$HOME/.local/bin"
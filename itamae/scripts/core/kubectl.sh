@@ -3,23 +3,27 @@
 # METADATA
 # NAME: kubectl
 # DESCRIPTION: The command-line tool for controlling Kubernetes clusters.
+# TAGS: kubernetes, k8s, cloud
 # INSTALL_METHOD: binary
+# BINARY: kubectl
+# VERSION: v1.31.0
+# LATEST_CMD: curl -fsSL https://dl.k8s.io/release/stable.txt
 #
 
 install() {
     echo "Installing kubectl..."
     
-    # Download the latest stable version
-    curl -sLO "https://dl.k8s.io/release/$(curl -L -s https://dl.k8s.io/release/stable.txt)/bin/linux/amd64/kubectl"
-    chmod +x kubectl
-    sudo mv kubectl /usr/local/bin/
+    # Download the pinned version, or the latest stable one if none is set
+    local VERSION="${ITAMAE_VERSION:-$(curl -L -s https://dl.k8s.io/release/stable.txt)}"
+    # itamae installs the staged binary into /usr/local/bin
+    curl -sL "https://dl.k8s.io/release/${VERSION}/bin/linux/amd64/kubectl" -o "$ITAMAE_STAGE_DIR/kubectl"
     
     echo "✅ kubectl installed."
 }
 
 remove() {
     echo "Removing kubectl..."
-    sudo rm -f /usr/local/bin/kubectl
+    # itamae deletes /usr/local/bin/kubectl
     echo "✅ kubectl removed."
 }
 
@@ -27,10 +31,15 @@ check() {
     command -v kubectl &> /dev/null
 }
 
+version() {
+    kubectl version --client 2>/dev/null | awk '/Client Version/ {print $3}'
+}
+
 # --- ROUTER ---
 case "$1" in
     install) install ;;
     remove) remove ;;
     check) check ;;
-    *) echo "Usage: $0 {install|remove|check}" && exit 1 ;;
+    version) version ;;
+    *) echo "Usage: $0 {install|remove|check|version}" && exit 1 ;;
 esac
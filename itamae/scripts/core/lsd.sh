@@ -3,6 +3,8 @@
 # METADATA
 # NAME: lsd (ls deluxe)
 # DESCRIPTION: A modern 'ls' with pretty colors and icons.
+# TAGS: files, ls, cli
+# OMAKASE: true
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: lsd
 #
@@ -19,7 +21,6 @@ install() {
 
 remove() {
     echo "Removing lsd..."
-    sudo apt-get purge -y lsd
     echo "✅ lsd removed."
 }
 
@@ -3,6 +3,7 @@
 # METADATA
 # NAME: nala
 # DESCRIPTION: A more optimized package management experience.
+# TAGS: apt, package-manager
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: nala
 #
@@ -16,7 +17,6 @@ install() {
 
 remove() {
     echo "Removing nala..."
-    sudo apt-get purge -y nala
     echo "✅ nala removed."
 }
 
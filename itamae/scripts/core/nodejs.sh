@@ -3,17 +3,13 @@
 # METADATA
 # NAME: Node.js
 # DESCRIPTION: A JavaScript runtime environment.
+# TAGS: javascript, node, language
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: nodejs
-# REPO_SETUP: setup_repo
+# APT_REPO: https://deb.nodesource.com/node_24.x nodistro main
+# APT_KEY_URL: https://deb.nodesource.com/gpgkey/nodesource-repo.gpg.key
 #
 
-setup_repo() {
-    echo "Setting up NodeSource repository..."
-    curl --silent -fsSL https://deb.nodesource.com/setup_lts.x | sudo -E bash - > /dev/null 2>&1
-    echo "✅ NodeSource repository configured."
-}
-
 install() {
     echo "Installing Node.js..."
     if command -v nala &> /dev/null; then
@@ -26,9 +22,6 @@ install() {
 
 remove() {
     echo "Removing Node.js..."
-    sudo apt-get purge -y nodejs
-    sudo rm -f /etc/apt/sources.list.d/nodesource.list*
-    sudo rm -f /etc/apt/keyrings/nodesource.gpg
     echo "✅ Node.js removed."
 }
 
@@ -38,9 +31,8 @@ check() {
 
 # --- ROUTER ---
 case "$1" in
-    setup_repo) setup_repo ;;
     install) install ;;
     remove) remove ;;
     check) check ;;
-    *) echo "Usage: $0 {setup_repo|install|remove|check}" && exit 1 ;;
+    *) echo "Usage: $0 {install|remove|check}" && exit 1 ;;
 esac
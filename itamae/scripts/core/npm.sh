@@ -3,6 +3,7 @@
 # METADATA
 # NAME: npm
 # DESCRIPTION: The package manager for Node.js.
+# TAGS: javascript, node, package-manager
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: npm
 #
@@ -19,7 +20,6 @@ install() {
 
 remove() {
     echo "Removing npm..."
-    sudo apt-get purge -y npm
     echo "✅ npm removed."
 }
 
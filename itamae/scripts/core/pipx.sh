@@ -3,6 +3,7 @@
 # METADATA
 # NAME: pipx
 # DESCRIPTION: A tool to install and run Python applications in isolated environments.
+# TAGS: python, package-manager
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: pipx
 #
@@ -20,7 +21,6 @@ install() {
 
 remove() {
     echo "Removing pipx..."
-    sudo apt-get purge -y pipx
     echo "✅ pipx removed."
 }
 
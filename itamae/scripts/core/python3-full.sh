@@ -3,6 +3,7 @@
 # METADATA
 # NAME: python3-full
 # DESCRIPTION: The complete Python 3 programming language environment.
+# TAGS: python, language
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: python3-full
 #
@@ -19,7 +20,6 @@ install() {
 
 remove() {
     echo "Removing python3-full..."
-    sudo apt-get purge -y python3-full
     echo "✅ python3-full removed."
 }
 
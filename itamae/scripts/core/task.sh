@@ -3,7 +3,9 @@
 # METADATA
 # NAME: Task
 # DESCRIPTION: A task runner / build tool that aims to be simpler than GNU Make.
+# TAGS: build, make, automation
 # INSTALL_METHOD: binary
+# REQUIRES_ROOT: false
 #
 
 BINDIR="$HOME/.local/bin"
@@ -3,6 +3,8 @@
 # METADATA
 # NAME: wget
 # DESCRIPTION: A utility for non-interactive download of files from the web.
+# TAGS: http, network, download
+# OMAKASE: true
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: wget
 #
@@ -19,7 +21,6 @@ install() {
 
 remove() {
     echo "Removing wget..."
-    sudo apt-get purge -y wget
     echo "✅ wget removed."
 }
 
@@ -3,6 +3,7 @@
 # METADATA
 # NAME: wireguard
 # DESCRIPTION: A fast, modern, and secure VPN tunnel.
+# TAGS: vpn, network, security
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: wireguard
 #
@@ -19,7 +20,6 @@ install() {
 
 remove() {
     echo "Removing wireguard..."
-    sudo apt-get purge -y wireguard
     echo "✅ wireguard removed."
 }
 
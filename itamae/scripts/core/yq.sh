@@ -3,21 +3,30 @@
 # METADATA
 # NAME: yq (Go)
 # DESCRIPTION: A 'jq' for YAML. (Installs the correct Go binary, not the python wrapper).
+# TAGS: yaml, json, cli
+# OMAKASE: true
 # INSTALL_METHOD: binary
+# BINARY: yq
+# ARTIFACT: yq https://github.com/mikefarah/yq/releases/latest/download/yq_linux_amd64
+# LATEST_CMD: curl -fsSL https://api.github.com/repos/mikefarah/yq/releases/latest | grep -oP '"tag_name": "v\K[^"]+'
 #
 
 install() {
     echo "Installing yq (Go binary)..."
     # This is critical: apt 'yq' is the wrong tool.
     local YQ_URL="https://github.com/mikefarah/yq/releases/latest/download/yq_linux_amd64"
-    sudo curl --silent -L "$YQ_URL" -o /usr/local/bin/yq
-    sudo chmod +x /usr/local/bin/yq
+    # itamae installs the staged binary into /usr/local/bin
+    if [ -n "$ITAMAE_ARTIFACT_DIR" ]; then
+        cp "$ITAMAE_ARTIFACT_DIR/yq" "$ITAMAE_STAGE_DIR/yq"
+    else
+        curl --silent -L "$YQ_URL" -o "$ITAMAE_STAGE_DIR/yq"
+    fi
     echo "✅ yq installed."
 }
 
 remove() {
     echo "Removing yq..."
-    sudo rm /usr/local/bin/yq
+    # itamae deletes /usr/local/bin/yq
     echo "✅ yq removed."
 }
 
@@ -25,10 +34,15 @@ check() {
     command -v yq &> /dev/null
 }
 
+version() {
+    yq --version | awk '{print $NF}' | sed 's/^v//'
+}
+
 # --- ROUTER ---
 case "$1" in
     install) install ;;
     remove) remove ;;
     check) check ;;
-    *) echo "Usage: $0 {install|remove|check}" && exit 1 ;;
+    version) version ;;
+    *) echo "Usage: $0 {install|remove|check|version}" && exit 1 ;;
 esac
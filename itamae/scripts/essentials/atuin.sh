@@ -3,7 +3,9 @@
 # METADATA
 # NAME: Atuin
 # DESCRIPTION: A better shell history.
+# TAGS: shell, history
 # INSTALL_METHOD: binary
+# REQUIRES_ROOT: false
 #
 
 install() {
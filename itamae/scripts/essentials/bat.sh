@@ -3,6 +3,8 @@
 # METADATA
 # NAME: bat
 # DESCRIPTION: A 'cat' clone with syntax highlighting and Git integration.
+# TAGS: cat, pager, cli
+# OMAKASE: true
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: bat
 # POST_INSTALL: post_install
@@ -24,7 +26,6 @@ install() {
 
 remove() {
     echo "Removing bat..."
-    sudo apt-get purge -y bat
     echo "✅ bat removed."
 }
 
@@ -3,19 +3,13 @@
 # METADATA
 # NAME: Java
 # DESCRIPTION: The Java Development Kit (Temurin/Adoptium).
+# TAGS: java, jdk, language
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: temurin-21-jdk
-# REPO_SETUP: setup_repo
+# APT_REPO: https://packages.adoptium.net/artifactory/deb {codename} main
+# APT_KEY_URL: https://packages.adoptium.net/artifactory/api/gpg/key/public
 #
 
-setup_repo() {
-    echo "Setting up Adoptium repository..."
-    sudo mkdir -p /etc/apt/keyrings
-    wget -O - https://packages.adoptium.net/artifactory/api/gpg/key/public 2>/dev/null | sudo tee /etc/apt/keyrings/adoptium.asc > /dev/null
-    echo "deb [signed-by=/etc/apt/keyrings/adoptium.asc] https://packages.adoptium.net/artifactory/deb $(awk -F= '/^VERSION_CODENAME/{print$2}' /etc/os-release) main" | sudo tee /etc/apt/sources.list.d/adoptium.list > /dev/null
-    echo "✅ Adoptium repository configured."
-}
-
 install() {
     echo "Installing Java (Temurin)..."
     if command -v nala &> /dev/null; then
@@ -28,9 +22,6 @@ install() {
 
 remove() {
     echo "Removing Java (Temurin)..."
-    sudo apt-get purge -y temurin-21-jdk
-    sudo rm -f /etc/apt/sources.list.d/adoptium.list
-    sudo rm -f /etc/apt/keyrings/adoptium.asc
     echo "✅ Java (Temurin) removed."
 }
 
@@ -40,9 +31,8 @@ check() {
 
 # --- ROUTER ---
 case "$1" in
-    setup_repo) setup_repo ;;
     install) install ;;
     remove) remove ;;
     check) check ;;
-    *) echo "Usage: $0 {setup_repo|install|remove|check}" && exit 1 ;;
+    *) echo "Usage: $0 {install|remove|check}" && exit 1 ;;
 esac
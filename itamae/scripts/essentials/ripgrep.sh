@@ -3,6 +3,8 @@
 # METADATA
 # NAME: ripgrep (rg)
 # DESCRIPTION: A fast, modern replacement for grep that respects .gitignore.
+# TAGS: search, grep, cli
+# OMAKASE: true
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: ripgrep
 #
@@ -19,7 +21,6 @@ install() {
 
 remove() {
     echo "Removing ripgrep..."
-    sudo apt-get purge -y ripgrep
     echo "✅ ripgrep removed."
 }
 
@@ -3,7 +3,10 @@
 # METADATA
 # NAME: Rust
 # DESCRIPTION: A multi-paradigm, general-purpose programming language.
+# TAGS: rust, cargo, language
 # INSTALL_METHOD: binary
+# REQUIRES_ROOT: false
+# LATEST_CMD: curl -fsSL https://api.github.com/repos/rust-lang/rust/releases/latest | grep -oP '"tag_name": "\K[^"]+'
 #
 
 install() {
@@ -22,10 +25,22 @@ check() {
     command -v rustc &> /dev/null
 }
 
+upgrade() {
+    echo "Upgrading Rust..."
+    rustup update
+    echo "✅ Rust upgraded."
+}
+
+version() {
+    rustc --version | awk '{print $2}'
+}
+
 # --- ROUTER ---
 case "$1" in
     install) install ;;
     remove) remove ;;
     check) check ;;
-    *) echo "Usage: $0 {install|remove|check}" && exit 1 ;;
+    upgrade) upgrade ;;
+    version) version ;;
+    *) echo "Usage: $0 {install|remove|check|upgrade|version}" && exit 1 ;;
 esac
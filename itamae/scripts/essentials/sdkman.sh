@@ -3,7 +3,9 @@
 # METADATA
 # NAME: SDKMan
 # DESCRIPTION: A tool for managing parallel versions of multiple Software Development Kits.
+# TAGS: java, sdk, version-manager
 # INSTALL_METHOD: binary
+# REQUIRES_ROOT: false
 #
 
 install() {
@@ -3,20 +3,34 @@
 # METADATA
 # NAME: Starship
 # DESCRIPTION: The minimal, fast, and customizable prompt.
+# TAGS: shell, prompt
+# OMAKASE: true
 # INSTALL_METHOD: binary
+# REQUIRES_ROOT: false
 #
 
 install() {
     echo "Installing Starship..."
     # Use -y to bypass the prompt
-    curl -sS https://starship.rs/install.sh | sh -s -- -y
+    if [ "$ITAMAE_USER_MODE" = "1" ]; then
+        # Without sudo, install into ~/.local/bin instead of /usr/local/bin
+        mkdir -p "$HOME/.local/bin"
+        curl -sS https://starship.rs/install.sh | sh -s -- -y -b "$HOME/.local/bin"
+    else
+        curl -sS https://starship.rs/install.sh | sh -s -- -y
+    fi
     echo "✅ Starship installed."
     echo "NOTE: You must add 'eval \"$(starship init zsh)\"' to your .zshrc"
 }
 
 remove() {
     echo "Removing Starship..."
-    sh -c 'rm "$(command -v starship)"'
+    # Only the per-user install can be removed without root
+    rm -f "$HOME/.local/bin/starship"
+    if [ -e /usr/local/bin/starship ]; then
+        echo "⚠️  /usr/local/bin/starship was installed system-wide; delete it as root to finish removing Starship"
+        return 1
+    fi
     echo "✅ Starship removed."
 }
 
@@ -3,6 +3,8 @@
 # METADATA
 # NAME: GNU Stow
 # DESCRIPTION: A simple symlink manager for dotfiles.
+# TAGS: dotfiles, symlink
+# OMAKASE: true
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: stow
 #
@@ -19,7 +21,6 @@ install() {
 
 remove() {
     echo "Removing GNU Stow..."
-    sudo apt-get purge -y stow
     echo "✅ GNU Stow removed."
 }
 
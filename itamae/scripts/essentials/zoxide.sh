@@ -3,7 +3,10 @@
 # METADATA
 # NAME: zoxide
 # DESCRIPTION: A smarter 'cd' command that remembers your directories.
+# TAGS: shell, cd, navigation
+# OMAKASE: true
 # INSTALL_METHOD: binary
+# REQUIRES_ROOT: false
 #
 
 install() {
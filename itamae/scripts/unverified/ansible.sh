@@ -3,7 +3,9 @@
 # METADATA
 # NAME: Ansible
 # DESCRIPTION: An open-source automation tool, including ansible-core and ansible-runner.
+# TAGS: automation, python, provisioning
 # INSTALL_METHOD: binary
+# REQUIRES_ROOT: false
 #
 
 install() {
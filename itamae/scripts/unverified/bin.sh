@@ -3,7 +3,9 @@
 # METADATA
 # NAME: bin
 # DESCRIPTION: A tool for easier management of binary tools.
+# TAGS: binary, package-manager
 # INSTALL_METHOD: binary
+# REQUIRES_ROOT: false
 #
 
 BINDIR="$HOME/.local/bin"
@@ -3,6 +3,7 @@
 # METADATA
 # NAME: btop-desktop
 # DESCRIPTION: A resource monitor that shows usage and stats for processor, memory, disks, network, and processes.
+# TAGS: monitoring, system, gui
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: btop
 #
@@ -20,7 +21,6 @@ install() {
 
 remove() {
     echo "Removing btop-desktop..."
-    sudo apt-get purge -y btop
     echo "✅ btop-desktop removed."
 }
 
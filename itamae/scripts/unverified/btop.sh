@@ -3,6 +3,8 @@
 # METADATA
 # NAME: btop
 # DESCRIPTION: A beautiful, modern resource monitor.
+# TAGS: monitoring, system
+# OMAKASE: true
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: btop
 #
@@ -20,7 +22,6 @@ install() {
 
 remove() {
     echo "Removing btop..."
-    sudo apt-get purge -y btop
     echo "✅ btop removed."
 }
 
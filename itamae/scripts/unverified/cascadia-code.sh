@@ -3,7 +3,9 @@
 # METADATA
 # NAME: Cascadia Code
 # DESCRIPTION: A monospaced font from Microsoft that includes programming ligatures.
+# TAGS: font
 # INSTALL_METHOD: binary
+# REQUIRES_ROOT: false
 #
 
 FONT_VERSION="2404.23"
@@ -3,7 +3,9 @@
 # METADATA
 # NAME: chezmoi
 # DESCRIPTION: Manages dotfiles across multiple machines.
+# TAGS: dotfiles
 # INSTALL_METHOD: binary
+# REQUIRES_ROOT: false
 #
 
 BINDIR="$HOME/.local/bin"
@@ -3,6 +3,7 @@
 # METADATA
 # NAME: Dunst
 # DESCRIPTION: A lightweight notification daemon for WMs.
+# TAGS: notifications, desktop
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: dunst
 #
@@ -19,7 +20,6 @@ install() {
 
 remove() {
     echo "Removing Dunst..."
-    sudo apt-get purge -y dunst
     echo "✅ Dunst removed."
 }
 
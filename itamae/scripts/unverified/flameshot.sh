@@ -3,6 +3,7 @@
 # METADATA
 # NAME: Flameshot
 # DESCRIPTION: A powerful, scriptable screenshot tool.
+# TAGS: screenshot, gui
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: flameshot
 #
@@ -19,7 +20,6 @@ install() {
 
 remove() {
     echo "Removing Flameshot..."
-    sudo apt-get purge -y flameshot
     echo "✅ Flameshot removed."
 }
 
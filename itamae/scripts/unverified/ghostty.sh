@@ -3,7 +3,9 @@
 # METADATA
 # NAME: Ghostty
 # DESCRIPTION: A GPU-accelerated terminal emulator.
+# TAGS: terminal, gui
 # INSTALL_METHOD: manual
+# REQUIRES_ROOT: false
 #
 
 install() {
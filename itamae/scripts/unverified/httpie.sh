@@ -3,6 +3,7 @@
 # METADATA
 # NAME: httpie
 # DESCRIPTION: A human-friendly 'curl' replacement for testing APIs.
+# TAGS: http, api, cli
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: httpie
 #
@@ -19,7 +20,6 @@ install() {
 
 remove() {
     echo "Removing httpie..."
-    sudo apt-get purge -y httpie
     echo "✅ httpie removed."
 }
 
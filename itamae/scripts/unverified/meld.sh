@@ -3,6 +3,7 @@
 # METADATA
 # NAME: Meld
 # DESCRIPTION: A visual diff and merge tool for developers.
+# TAGS: diff, git, gui
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: meld
 #
@@ -19,7 +20,6 @@ install() {
 
 remove() {
     echo "Removing Meld..."
-    sudo apt-get purge -y meld
     echo "✅ Meld removed."
 }
 
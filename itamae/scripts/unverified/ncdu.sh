@@ -3,6 +3,7 @@
 # METADATA
 # NAME: ncdu
 # DESCRIPTION: A disk usage analyzer with an ncurses interface.
+# TAGS: disk, files, system
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: ncdu
 #
@@ -19,7 +20,6 @@ install() {
 
 remove() {
     echo "Removing ncdu..."
-    sudo apt-get purge -y ncdu
     echo "✅ ncdu removed."
 }
 
@@ -3,6 +3,7 @@
 # METADATA
 # NAME: pass
 # DESCRIPTION: The standard unix password manager.
+# TAGS: password, security, gpg
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: pass
 #
@@ -19,7 +20,6 @@ install() {
 
 remove() {
     echo "Removing pass..."
-    sudo apt-get purge -y pass
     echo "✅ pass removed."
 }
 
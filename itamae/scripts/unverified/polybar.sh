@@ -3,6 +3,7 @@
 # METADATA
 # NAME: Polybar
 # DESCRIPTION: A fast and easy-to-use status bar for X11.
+# TAGS: statusbar, desktop
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: polybar
 #
@@ -19,7 +20,6 @@ install() {
 
 remove() {
     echo "Removing Polybar..."
-    sudo apt-get purge -y polybar
     echo "✅ Polybar removed."
 }
 
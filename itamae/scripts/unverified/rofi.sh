@@ -3,6 +3,7 @@
 # METADATA
 # NAME: Rofi
 # DESCRIPTION: A fast, keyboard-driven application launcher for WMs.
+# TAGS: launcher, desktop
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: rofi
 #
@@ -19,7 +20,6 @@ install() {
 
 remove() {
     echo "Removing Rofi..."
-    sudo apt-get purge -y rofi
     echo "✅ Rofi removed."
 }
 
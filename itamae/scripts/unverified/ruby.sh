@@ -3,6 +3,7 @@
 # METADATA
 # NAME: Ruby
 # DESCRIPTION: A dynamic, open source programming language.
+# TAGS: ruby, language
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: ruby-full
 #
@@ -19,7 +20,6 @@ install() {
 
 remove() {
     echo "Removing Ruby..."
-    sudo apt-get purge -y ruby-full
     echo "✅ Ruby removed."
 }
 
@@ -3,7 +3,9 @@
 # METADATA
 # NAME: semgrep
 # DESCRIPTION: A fast, open-source, static analysis tool for finding bugs.
+# TAGS: security, static-analysis, lint
 # INSTALL_METHOD: binary
+# REQUIRES_ROOT: false
 #
 
 install() {
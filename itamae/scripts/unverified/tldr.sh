@@ -3,15 +3,28 @@
 # METADATA
 # NAME: tldr (tealdeer)
 # DESCRIPTION: A fast, community-driven 'man' page replacement.
+# TAGS: docs, man, cli
+# OMAKASE: true
 # INSTALL_METHOD: binary
+# REQUIRES_ROOT: false
+# VERSION: 1.7.1
+# ARTIFACT: tldr https://github.com/tealdeer-rs/tealdeer/releases/download/v{version}/tealdeer-linux-x86_64-musl
+# LATEST_CMD: curl -fsSL https://api.github.com/repos/tealdeer-rs/tealdeer/releases/latest | grep -oP '"tag_name": "v\K[^"]+'
 #
 
 install() {
     echo "Installing tldr (tealdeer)..."
     # 'tealdeer' is the fast Rust client
     local TLDR_URL="https://github.com/tealdeer-rs/tealdeer/releases/latest/download/tealdeer-linux-x86_64-musl"
+    if [ -n "$ITAMAE_VERSION" ]; then
+        TLDR_URL="https://github.com/tealdeer-rs/tealdeer/releases/download/v${ITAMAE_VERSION}/tealdeer-linux-x86_64-musl"
+    fi
     mkdir -p "$HOME/.local/bin"
-    curl -L "$TLDR_URL" -o "$HOME/.local/bin/tldr"
+    if [ -n "$ITAMAE_ARTIFACT_DIR" ]; then
+        cp "$ITAMAE_ARTIFACT_DIR/tldr" "$HOME/.local/bin/tldr"
+    else
+        curl -L "$TLDR_URL" -o "$HOME/.local/bin/tldr"
+    fi
     chmod +x "$HOME/.local/bin/tldr"
     echo "✅ tldr installed to ~/.local/bin/tldr"
 }
@@ -26,10 +39,15 @@ check() {
     command -v tldr &> /dev/null
 }
 
+version() {
+    tldr --version | awk '{print $2}'
+}
+
 # --- ROUTER ---
 case "$1" in
     install) install ;;
     remove) remove ;;
     check) check ;;
-    *) echo "Usage: $0 {install|remove|check}" && exit 1 ;;
+    version) version ;;
+    *) echo "Usage: $0 {install|remove|check|version}" && exit 1 ;;
 esac
@@ -3,22 +3,20 @@
 # METADATA
 # NAME: Visual Studio Code
 # DESCRIPTION: A popular code editor.
-# INSTALL_METHOD: binary
+# TAGS: editor, ide, gui
+# INSTALL_METHOD: apt
+# PACKAGE_NAME: code
+# APT_REPO: https://packages.microsoft.com/repos/code stable main
+# APT_KEY_URL: https://packages.microsoft.com/keys/microsoft.asc
 #
 
 install() {
-    echo "Installing Visual Studio Code..."
-    # Download the .deb package
-    curl -fL "https://code.visualstudio.com/sha/download?build=stable&os=linux-deb-x64" -o "/tmp/vscode-itamae.deb"
-    # Install the package
-    sudo apt-get install -y "/tmp/vscode-itamae.deb"
-    # Clean up
-    rm "/tmp/vscode-itamae.deb"
+    echo "✅ Visual Studio Code installed."
 }
 
 remove() {
     echo "Removing Visual Studio Code..."
-    sudo apt-get purge -y code
+    echo "✅ Visual Studio Code removed."
 }
 
 check() {
@@ -3,20 +3,34 @@
 # METADATA
 # NAME: Zellij
 # DESCRIPTION: A modern terminal multiplexer (like tmux/screen).
+# TAGS: terminal, multiplexer
+# OMAKASE: true
 # INSTALL_METHOD: binary
+# BINARY: zellij
+# VERSION: 0.41.2
+# ARTIFACT: zellij.tar.gz https://github.com/zellij-org/zellij/releases/download/v{version}/zellij-x86_64-unknown-linux-musl.tar.gz
+# LATEST_CMD: curl -fsSL https://api.github.com/repos/zellij-org/zellij/releases/latest | grep -oP '"tag_name": "v\K[^"]+'
 #
 
 install() {
     echo "Installing Zellij..."
     # Install from binary release
     local ZELLIJ_URL="https://github.com/zellij-project/zellij/releases/latest/download/zellij-x86_64-unknown-linux-musl.tar.gz"
-    curl -L "$ZELLIJ_URL" | sudo tar -xz -C /usr/local/bin
+    if [ -n "$ITAMAE_VERSION" ]; then
+        ZELLIJ_URL="https://github.com/zellij-org/zellij/releases/download/v${ITAMAE_VERSION}/zellij-x86_64-unknown-linux-musl.tar.gz"
+    fi
+    # itamae installs the staged binary into /usr/local/bin
+    if [ -n "$ITAMAE_ARTIFACT_DIR" ]; then
+        tar -xzf "$ITAMAE_ARTIFACT_DIR/zellij.tar.gz" -C "$ITAMAE_STAGE_DIR"
+    else
+        curl -L "$ZELLIJ_URL" | tar -xz -C "$ITAMAE_STAGE_DIR"
+    fi
     echo "✅ Zellij installed."
 }
 
 remove() {
     echo "Removing Zellij..."
-    sudo rm /usr/local/bin/zellij
+    # itamae deletes /usr/local/bin/zellij
     echo "✅ Zellij removed."
 }
 
@@ -24,10 +38,15 @@ check() {
     command -v zellij &> /dev/null
 }
 
+version() {
+    zellij --version | awk '{print $2}'
+}
+
 # --- ROUTER ---
 case "$1" in
     install) install ;;
     remove) remove ;;
     check) check ;;
-    *) echo "Usage: $0 {install|remove|check}" && exit 1 ;;
+    version) version ;;
+    *) echo "Usage: $0 {install|remove|check|version}" && exit 1 ;;
 esac
@@ -3,6 +3,7 @@
 # METADATA
 # NAME: Zsh
 # DESCRIPTION: The Z Shell, a powerful foundation for the terminal.
+# TAGS: shell
 # INSTALL_METHOD: apt
 # PACKAGE_NAME: zsh
 #
@@ -20,7 +21,6 @@ install() {
 
 remove() {
     echo "Removing Zsh..."
-    sudo apt-get purge -y zsh
     echo "✅ Zsh removed."
 }
 
@@ -0,0 +1,100 @@
+package itamae
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sahilm/fuzzy"
+)
+
+// keyFieldBonus ranks matches on the ID, name or tags above matches that only
+// hit the (longer, noisier) description.
+const keyFieldBonus = 20
+
+// SearchResult is a plugin matched by SearchPlugins.
+type SearchResult struct {
+	Plugin ToolPlugin
+	Score  int
+	Field  string // The field that produced the best match: "id", "name", "tag" or "description"
+}
+
+// SearchPlugins fuzzy-matches the query against each plugin's ID, name,
+// description and tags, returning matches ordered from best to worst.
+func SearchPlugins(plugins []ToolPlugin, query string) []SearchResult {
+	query = strings.TrimSpace(query)
+	results := []SearchResult{}
+	if query == "" {
+		return results
+	}
+
+	for _, p := range plugins {
+		best, matched := SearchResult{Plugin: p}, false
+		for _, field := range searchFields(p) {
+			matches := fuzzy.Find(query, []string{field.value})
+			if len(matches) == 0 {
+				continue
+			}
+			score := matches[0].Score
+			if score < 0 && !strings.Contains(strings.ToLower(field.value), strings.ToLower(query)) {
+				continue // Letters scattered across the field, too weak to be a match
+			}
+			if field.name != "description" {
+				score += keyFieldBonus
+			}
+			if !matched || score > best.Score {
+				best.Score, best.Field, matched = score, field.name, true
+			}
+		}
+		if matched {
+			results = append(results, best)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Plugin.ID < results[j].Plugin.ID
+	})
+
+	return results
+}
+
+type searchField struct {
+	name  string
+	value string
+}
+
+func searchFields(p ToolPlugin) []searchField {
+	fields := []searchField{
+		{"id", p.ID},
+		{"name", p.Name},
+		{"description", p.Description},
+	}
+	for _, tag := range p.Tags {
+		fields = append(fields, searchField{"tag", tag})
+	}
+	return fields
+}
+
+// parseTags splits a comma-separated TAGS value into lower-case tags.
+func parseTags(value string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// optionLabel is the multiselect label for a plugin. Tags are included so the
+// list's filter matches them too.
+func optionLabel(p ToolPlugin) string {
+	label := p.Name + " - " + p.Description
+	if len(p.Tags) > 0 {
+		label += " [" + strings.Join(p.Tags, ", ") + "]"
+	}
+	return label
+}
@@ -0,0 +1,74 @@
+package itamae
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	got := parseTags(" Kubernetes, k8s,, cloud ")
+	want := []string{"kubernetes", "k8s", "cloud"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestSearchPlugins(t *testing.T) {
+	catalog := []ToolPlugin{
+		{ID: "git", Name: "Git", Description: "A distributed version control system.", Tags: []string{"vcs"}},
+		{ID: "kubectl", Name: "kubectl", Description: "Controls Kubernetes clusters.", Tags: []string{"kubernetes", "k8s"}},
+		{ID: "helm", Name: "Helm", Description: "The package manager for Kubernetes.", Tags: []string{"kubernetes", "k8s"}},
+		{ID: "jq", Name: "jq", Description: "A JSON processor."},
+	}
+
+	t.Run("ranks id matches first", func(t *testing.T) {
+		results := SearchPlugins(catalog, "kube")
+		if len(results) < 2 {
+			t.Fatalf("expected kubectl and helm, got %d results", len(results))
+		}
+		if results[0].Plugin.ID != "kubectl" {
+			t.Errorf("expected kubectl first, got %s", results[0].Plugin.ID)
+		}
+	})
+
+	t.Run("matches tags", func(t *testing.T) {
+		results := SearchPlugins(catalog, "k8s")
+		ids := []string{}
+		for _, r := range results {
+			ids = append(ids, r.Plugin.ID)
+		}
+		if !reflect.DeepEqual(ids, []string{"helm", "kubectl"}) {
+			t.Errorf("expected helm and kubectl, got %v", ids)
+		}
+		if results[0].Field != "tag" {
+			t.Errorf("expected a tag match, got %s", results[0].Field)
+		}
+	})
+
+	t.Run("ignores scattered letters", func(t *testing.T) {
+		for _, r := range SearchPlugins(catalog, "json") {
+			if r.Plugin.ID != "jq" {
+				t.Errorf("unexpected match %s", r.Plugin.ID)
+			}
+		}
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		if results := SearchPlugins(catalog, "zzz"); len(results) != 0 {
+			t.Errorf("expected no results, got %d", len(results))
+		}
+	})
+
+	t.Run("empty query", func(t *testing.T) {
+		if results := SearchPlugins(catalog, "  "); len(results) != 0 {
+			t.Errorf("expected no results, got %d", len(results))
+		}
+	})
+}
+
+func TestOptionLabelIncludesTags(t *testing.T) {
+	label := optionLabel(ToolPlugin{Name: "Helm", Description: "Charts.", Tags: []string{"kubernetes", "k8s"}})
+	if label != "Helm - Charts. [kubernetes, k8s]" {
+		t.Errorf("unexpected label: %q", label)
+	}
+}
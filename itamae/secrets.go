@@ -0,0 +1,327 @@
+package itamae
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"filippo.io/age"
+	"github.com/charmbracelet/huh"
+	"github.com/zalando/go-keyring"
+)
+
+// Secret stores for remembering secret inputs between runs
+const (
+	SecretStoreKeyring = "keyring" // freedesktop Secret Service (GNOME Keyring, KWallet, ...)
+	SecretStoreFile    = "file"    // Passphrase-encrypted age file in the itamae config directory
+)
+
+// redacted replaces secret values in logs and captured output.
+const redacted = "[REDACTED]"
+
+// keyringService is the Secret Service attribute secrets are stored under.
+const keyringService = "itamae"
+
+var (
+	secretValues    = map[string]bool{}
+	secretValuesMux sync.RWMutex
+)
+
+// registerSecret marks a value for redaction in debug logs and script output.
+func registerSecret(value string) {
+	if value == "" {
+		return
+	}
+	secretValuesMux.Lock()
+	defer secretValuesMux.Unlock()
+	secretValues[value] = true
+}
+
+// isSecret reports whether a value was registered as a secret.
+func isSecret(value string) bool {
+	secretValuesMux.RLock()
+	defer secretValuesMux.RUnlock()
+	return secretValues[value]
+}
+
+// Redact replaces every registered secret value in s.
+func Redact(s string) string {
+	for _, v := range secretList() {
+		s = strings.ReplaceAll(s, v, redacted)
+	}
+	return s
+}
+
+// secretList returns the registered secrets, longest first, so a secret
+// containing another is replaced whole.
+func secretList() []string {
+	secretValuesMux.RLock()
+	defer secretValuesMux.RUnlock()
+	values := make([]string, 0, len(secretValues))
+	for v := range secretValues {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	return values
+}
+
+// copyRedacted copies src to dst as it arrives, redacting secrets on the way,
+// so script output shown on the terminal never carries a secret input. Only
+// a tail that could be the start of a secret is held back until the next
+// read, so progress lines ending in \r still show up live. If dst fails, src
+// is still drained so the writer never blocks.
+func copyRedacted(dst io.Writer, src io.Reader) error {
+	buf := make([]byte, 32*1024)
+	pending := ""
+	for {
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			out := Redact(pending + string(buf[:n]))
+			keep := partialSecretSuffix(out)
+			pending = out[len(out)-keep:]
+			if _, err := io.WriteString(dst, out[:len(out)-keep]); err != nil {
+				io.Copy(io.Discard, src)
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			_, err := io.WriteString(dst, Redact(pending))
+			return err
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
+
+// partialSecretSuffix returns the length of the longest suffix of s that is
+// the start of a registered secret.
+func partialSecretSuffix(s string) int {
+	longest := 0
+	for _, v := range secretList() {
+		for l := min(len(v)-1, len(s)); l > longest; l-- {
+			if strings.HasSuffix(s, v[:l]) {
+				longest = l
+				break
+			}
+		}
+	}
+	return longest
+}
+
+// SecretStore persists secret inputs by name.
+type SecretStore interface {
+	Get(name string) (string, bool, error)
+	Set(name, value string) error
+	Delete(name string) error
+}
+
+// OpenSecretStore returns the named store, or nil for "" (secrets are not remembered).
+func OpenSecretStore(kind string) (SecretStore, error) {
+	switch kind {
+	case "":
+		return nil, nil
+	case SecretStoreKeyring:
+		return keyringStore{}, nil
+	case SecretStoreFile:
+		return newFileSecretStore(secretsDir()), nil
+	}
+	return nil, fmt.Errorf("unknown secret store %q: must be %s or %s", kind, SecretStoreKeyring, SecretStoreFile)
+}
+
+// secretsDir returns the directory holding the encrypted secrets file.
+func secretsDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(os.Getenv("HOME"), ".config", "itamae")
+	}
+	return filepath.Join(dir, "itamae")
+}
+
+// keyringStore keeps secrets in the freedesktop Secret Service.
+type keyringStore struct{}
+
+func (keyringStore) Get(name string) (string, bool, error) {
+	value, err := keyring.Get(keyringService, name)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s from the keyring: %w", name, err)
+	}
+	return value, true, nil
+}
+
+func (keyringStore) Set(name, value string) error {
+	if err := keyring.Set(keyringService, name, value); err != nil {
+		return fmt.Errorf("failed to save %s to the keyring: %w", name, err)
+	}
+	return nil
+}
+
+func (keyringStore) Delete(name string) error {
+	err := keyring.Delete(keyringService, name)
+	if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("failed to delete %s from the keyring: %w", name, err)
+	}
+	return nil
+}
+
+// fileSecretStore keeps secrets as a JSON object in an age-encrypted file
+// (secrets.age), encrypted with a passphrase through age's scrypt recipient.
+// No key is stored next to it: the passphrase comes from
+// ITAMAE_SECRETS_PASSPHRASE or is asked for once per run.
+type fileSecretStore struct {
+	path       string
+	workFactor int    // scrypt work factor; 0 uses age's default
+	passphrase string // Cached after the first prompt
+}
+
+func newFileSecretStore(dir string) *fileSecretStore {
+	return &fileSecretStore{path: filepath.Join(dir, "secrets.age")}
+}
+
+func (s *fileSecretStore) Get(name string) (string, bool, error) {
+	secrets, err := s.load()
+	if err != nil {
+		return "", false, err
+	}
+	value, ok := secrets[name]
+	return value, ok, nil
+}
+
+func (s *fileSecretStore) Set(name, value string) error {
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	secrets[name] = value
+	return s.save(secrets)
+}
+
+func (s *fileSecretStore) Delete(name string) error {
+	secrets, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[name]; !ok {
+		return nil
+	}
+	delete(secrets, name)
+	return s.save(secrets)
+}
+
+// getPassphrase returns the passphrase for the secrets file, prompting for
+// it the first time unless ITAMAE_SECRETS_PASSPHRASE is set. creating
+// changes the prompt when the file doesn't exist yet.
+func (s *fileSecretStore) getPassphrase(creating bool) (string, error) {
+	if s.passphrase != "" {
+		return s.passphrase, nil
+	}
+	passphrase := os.Getenv("ITAMAE_SECRETS_PASSPHRASE")
+	if passphrase == "" {
+		title := "Passphrase for " + s.path
+		if creating {
+			title = "Choose a passphrase to encrypt " + s.path
+		}
+		input := huh.NewInput().
+			Title(title).
+			EchoMode(huh.EchoModePassword).
+			Value(&passphrase).
+			Validate(func(v string) error {
+				if v == "" {
+					return fmt.Errorf("passphrase cannot be empty")
+				}
+				return nil
+			})
+		if err := newFormRunner(huh.NewForm(huh.NewGroup(input))).Run(); err != nil {
+			return "", fmt.Errorf("failed to read the secrets passphrase: %w", err)
+		}
+	}
+	registerSecret(passphrase)
+	s.passphrase = passphrase
+	return passphrase, nil
+}
+
+func (s *fileSecretStore) load() (map[string]string, error) {
+	secrets := map[string]string{}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", s.path, err)
+	}
+
+	passphrase, err := s.getPassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secrets passphrase: %w", err)
+	}
+	r, err := age.Decrypt(bytes.NewReader(data), identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", s.path, err)
+	}
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", s.path, err)
+	}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, fmt.Errorf("invalid secrets file %s: %w", s.path, err)
+	}
+	return secrets, nil
+}
+
+func (s *fileSecretStore) save(secrets map[string]string) error {
+	_, statErr := os.Stat(s.path)
+	passphrase, err := s.getPassphrase(os.IsNotExist(statErr))
+	if err != nil {
+		return err
+	}
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return fmt.Errorf("invalid secrets passphrase: %w", err)
+	}
+	if s.workFactor > 0 {
+		recipient.SetWorkFactor(s.workFactor)
+	}
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return fmt.Errorf("failed to encode secrets: %w", err)
+	}
+
+	var b bytes.Buffer
+	w, err := age.Encrypt(&b, recipient)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secrets: %w", err)
+	}
+	if _, err := w.Write(plain); err != nil {
+		return fmt.Errorf("failed to encrypt secrets: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to encrypt secrets: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(s.path), err)
+	}
+	// Write to a temporary file first so a failed write never loses the old secrets
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", s.path, err)
+	}
+	return nil
+}
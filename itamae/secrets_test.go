@@ -0,0 +1,187 @@
+package itamae
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+type memorySecretStore map[string]string
+
+func (m memorySecretStore) Get(name string) (string, bool, error) {
+	value, ok := m[name]
+	return value, ok, nil
+}
+
+func (m memorySecretStore) Set(name, value string) error {
+	m[name] = value
+	return nil
+}
+
+func (m memorySecretStore) Delete(name string) error {
+	delete(m, name)
+	return nil
+}
+
+func TestRedact(t *testing.T) {
+	registerSecret("tok-123")
+	registerSecret("tok-123-extended")
+
+	got := Redact("token=tok-123-extended, short=tok-123")
+	if got != "token=[REDACTED], short=[REDACTED]" {
+		t.Errorf("unexpected redaction: %q", got)
+	}
+}
+
+func TestCopyRedacted(t *testing.T) {
+	registerSecret("hunter2")
+
+	var out bytes.Buffer
+	if err := copyRedacted(&out, strings.NewReader("login with hunter2\ndone")); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "login with [REDACTED]\ndone" {
+		t.Errorf("expected the secret to be redacted, got %q", out.String())
+	}
+
+	// A secret split across reads is still caught
+	out.Reset()
+	if err := copyRedacted(&out, iotest.OneByteReader(strings.NewReader("progress 10%\rhunter2\r"))); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "progress 10%\r[REDACTED]\r" {
+		t.Errorf("expected the split secret to be redacted, got %q", out.String())
+	}
+
+	// Lines longer than any line buffer are copied whole
+	long := strings.Repeat("x", 2*1024*1024) + "hunter2\n"
+	out.Reset()
+	if err := copyRedacted(&out, strings.NewReader(long)); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != len(long)-len("hunter2")+len(redacted) || !strings.HasSuffix(out.String(), "x[REDACTED]\n") {
+		t.Errorf("expected the long line to be copied and redacted, got %d bytes", out.Len())
+	}
+}
+
+func TestRunScriptDrainsLongLines(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "long.sh")
+	// A single 2 MiB line on each stream, then more output
+	content := "#!/bin/bash\nhead -c 2097152 /dev/zero | tr '\\0' x\nhead -c 2097152 /dev/zero | tr '\\0' y >&2\necho done\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = devNull, devNull
+	defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+
+	done := make(chan error, 1)
+	go func() { done <- executeScript(ToolPlugin{ID: "long", ScriptPath: script}, "install", nil) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("script failed: %v", err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("script output was not drained")
+	}
+}
+
+func TestScriptEnvOnlyPassesSecretsToDeclaringPlugin(t *testing.T) {
+	token := Input{Name: "API_TOKEN", Prompt: "Token", Type: InputSecret}
+	gh := ToolPlugin{ID: "gh", RequiredInputs: []Input{token}}
+	git := ToolPlugin{ID: "git"}
+
+	values := map[string]string{"API_TOKEN": "ghp_scriptenv", "GIT_USER_NAME": "Ada"}
+	registerSecrets([]Input{token}, values)
+
+	if env := (InstallOptions{}).scriptEnv(gh, values); env["API_TOKEN"] != "ghp_scriptenv" {
+		t.Errorf("expected gh to receive the token, got %v", env)
+	}
+	env := (InstallOptions{}).scriptEnv(git, values)
+	if _, ok := env["API_TOKEN"]; ok {
+		t.Errorf("expected git not to receive the token, got %v", env)
+	}
+	if env["GIT_USER_NAME"] != "Ada" {
+		t.Errorf("expected plain inputs to be shared, got %v", env)
+	}
+}
+
+func TestDefaultInputsUsesSecretStore(t *testing.T) {
+	plugins := []ToolPlugin{{RequiredInputs: []Input{
+		{Name: "STORED", Prompt: "Stored", Type: InputSecret},
+		{Name: "GIVEN", Prompt: "Given", Type: InputSecret},
+	}}}
+	store := memorySecretStore{"STORED": "from-store"}
+
+	values, err := defaultInputs(plugins, InstallOptions{Inputs: map[string]string{"GIVEN": "from-flag"}, SecretStore: store})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if values["STORED"] != "from-store" || values["GIVEN"] != "from-flag" {
+		t.Errorf("unexpected values: %v", values)
+	}
+	if store["GIVEN"] != "from-flag" {
+		t.Errorf("expected the provided secret to be remembered, got %v", store)
+	}
+	if !isSecret("from-store") || !isSecret("from-flag") {
+		t.Error("expected secret values to be registered for redaction")
+	}
+}
+
+func TestFileSecretStore(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("ITAMAE_SECRETS_PASSPHRASE", "correct horse")
+	store := newFileSecretStore(dir)
+	store.workFactor = 10 // Keep scrypt fast in tests
+
+	if _, ok, err := store.Get("TOKEN"); err != nil || ok {
+		t.Fatalf("expected an empty store, got ok=%v err=%v", ok, err)
+	}
+	if err := store.Set("TOKEN", "s3cret"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "secrets.age"))
+	if err != nil {
+		t.Fatalf("expected an encrypted file: %v", err)
+	}
+	if bytes.Contains(data, []byte("s3cret")) {
+		t.Error("secrets file contains the plaintext value")
+	}
+	info, err := os.Stat(filepath.Join(dir, "secrets.age"))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected secrets.age to be 0600, got %v %v", info, err)
+	}
+	// Nothing that decrypts the file is stored next to it
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected only secrets.age in %s, got %v", dir, entries)
+	}
+
+	value, ok, err := newFileSecretStore(dir).Get("TOKEN")
+	if err != nil || !ok || value != "s3cret" {
+		t.Errorf("expected to read the secret back, got %q ok=%v err=%v", value, ok, err)
+	}
+
+	t.Setenv("ITAMAE_SECRETS_PASSPHRASE", "wrong")
+	if _, _, err := newFileSecretStore(dir).Get("TOKEN"); err == nil {
+		t.Error("expected a wrong passphrase to fail")
+	}
+
+	if err := store.Delete("TOKEN"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok, _ := store.Get("TOKEN"); ok {
+		t.Error("expected the secret to be deleted")
+	}
+}
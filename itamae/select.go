@@ -0,0 +1,121 @@
+package itamae
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+)
+
+// Pseudo-categories that span every category directory.
+const (
+	CategoryAll     = "all"     // Select from every category in a single grouped list
+	CategoryOmakase = "omakase" // Install the curated OMAKASE set without prompting
+)
+
+// preselectedCategories are checked by default in the grouped selection.
+var preselectedCategories = map[string]bool{"core": true, "essentials": true}
+
+// groupByCategory splits plugins by category, in the order of Categories.
+func groupByCategory(plugins []ToolPlugin) map[string][]ToolPlugin {
+	groups := make(map[string][]ToolPlugin)
+	for _, p := range plugins {
+		groups[p.Category] = append(groups[p.Category], p)
+	}
+	return groups
+}
+
+// selectGroupedPlugins shows every category as a section of one form. Core and
+// essentials plugins start checked but can be deselected, so a single run can
+// mix plugins from all categories.
+func selectGroupedPlugins(plugins []ToolPlugin) []ToolPlugin {
+	if len(plugins) == 0 {
+		return []ToolPlugin{}
+	}
+
+	fmt.Println("\n📦 Select the tools you'd like to install:")
+
+	groups := groupByCategory(plugins)
+	selections := make(map[string]*[]string)
+	formGroups := []*huh.Group{}
+
+	for _, category := range sectionOrder() {
+		categoryPlugins := groups[category]
+		if len(categoryPlugins) == 0 {
+			continue
+		}
+
+		selected := []string{}
+		options := []huh.Option[string]{}
+		for _, p := range categoryPlugins {
+			options = append(options, huh.NewOption(optionLabel(p), p.ID))
+			if preselectedCategories[category] {
+				selected = append(selected, p.ID)
+			}
+		}
+		selections[category] = &selected
+
+		description := "Use space to toggle, / to filter, enter for the next section"
+		if preselectedCategories[category] {
+			description = "Pre-selected; " + strings.ToLower(description[:1]) + description[1:]
+		}
+
+		formGroups = append(formGroups, huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title(fmt.Sprintf("%s (%d)", categoryTitle(category), len(categoryPlugins))).
+				Description(description).
+				Value(&selected).
+				Options(options...).
+				Filterable(true).
+				Height(30),
+		))
+	}
+
+	runner := newFormRunner(huh.NewForm(formGroups...))
+	if err := runner.Run(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return []ToolPlugin{}
+	}
+
+	selectedMap := make(map[string]bool)
+	for _, ids := range selections {
+		for _, id := range *ids {
+			selectedMap[id] = true
+		}
+	}
+
+	selectedPlugins := []ToolPlugin{}
+	for _, category := range sectionOrder() {
+		for _, p := range groups[category] {
+			if selectedMap[p.ID] {
+				selectedPlugins = append(selectedPlugins, p)
+			}
+		}
+	}
+
+	return selectedPlugins
+}
+
+// sectionOrder lists the categories shown in the grouped selection.
+func sectionOrder() []string {
+	return append(append([]string{}, Categories...), CategoryUser)
+}
+
+// categoryTitle capitalizes a category name for display.
+func categoryTitle(category string) string {
+	if category == "" {
+		return category
+	}
+	return strings.ToUpper(category[:1]) + category[1:]
+}
+
+// OmakasePlugins returns the curated set of plugins marked with OMAKASE: true.
+func OmakasePlugins(plugins []ToolPlugin) []ToolPlugin {
+	curated := []ToolPlugin{}
+	for _, p := range plugins {
+		if p.Omakase {
+			curated = append(curated, p)
+		}
+	}
+	return curated
+}
@@ -0,0 +1,54 @@
+package itamae
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/huh"
+)
+
+type stubFormRunner struct{}
+
+func (stubFormRunner) Run() error { return nil }
+
+func TestSelectGroupedPluginsPreselectsCoreAndEssentials(t *testing.T) {
+	original := newFormRunner
+	newFormRunner = func(form *huh.Form) formRunner { return stubFormRunner{} }
+	defer func() { newFormRunner = original }()
+
+	catalog := []ToolPlugin{
+		{ID: "zellij", Category: "unverified"},
+		{ID: "git", Category: "core"},
+		{ID: "bat", Category: "essentials"},
+		{ID: "curl", Category: "core"},
+	}
+
+	selected := selectGroupedPlugins(catalog)
+
+	want := []string{"git", "curl", "bat"}
+	if len(selected) != len(want) {
+		t.Fatalf("expected %v, got %d plugins", want, len(selected))
+	}
+	for i, p := range selected {
+		if p.ID != want[i] {
+			t.Errorf("expected %s at %d, got %s", want[i], i, p.ID)
+		}
+	}
+}
+
+func TestLoadPluginsAllCategories(t *testing.T) {
+	all, cleanup, err := LoadPlugins(CategoryAll)
+	if err != nil {
+		t.Fatalf("LoadPlugins(all) failed: %v", err)
+	}
+	defer cleanup()
+
+	seen := map[string]bool{}
+	for _, p := range all {
+		seen[p.Category] = true
+	}
+	for _, category := range Categories {
+		if !seen[category] {
+			t.Errorf("expected plugins from %s", category)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package itamae
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// installStatePath is the install state store. Tests replace it.
+var installStatePath = defaultInstallStatePath()
+
+// defaultInstallStatePath returns $ITAMAE_STATE_FILE, or state.json under
+// $XDG_STATE_HOME/itamae (~/.local/state/itamae).
+func defaultInstallStatePath() string {
+	if path := os.Getenv("ITAMAE_STATE_FILE"); path != "" {
+		return path
+	}
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		dir = filepath.Join(os.Getenv("HOME"), ".local", "state")
+	}
+	return filepath.Join(dir, "itamae", "state.json")
+}
+
+// InstallState records the plugins itamae installed on this machine, so that
+// later runs can tell them apart from tools installed by other means.
+type InstallState struct {
+	Plugins map[string]InstalledPlugin `json:"plugins"`
+}
+
+// InstalledPlugin is the state store entry of one plugin.
+type InstalledPlugin struct {
+	Version     string            `json:"version,omitempty"` // Installed version, when it can be detected
+	InstalledAt time.Time         `json:"installed_at"`
+	Inputs      map[string]string `json:"inputs,omitempty"` // Non-secret inputs the plugin was installed with
+}
+
+// LoadInstallState reads the state store. A missing file is an empty state.
+func LoadInstallState() (InstallState, error) {
+	state := InstallState{Plugins: map[string]InstalledPlugin{}}
+	data, err := os.ReadFile(installStatePath)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to read install state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to parse install state %s: %w", installStatePath, err)
+	}
+	if state.Plugins == nil {
+		state.Plugins = map[string]InstalledPlugin{}
+	}
+	return state, nil
+}
+
+func (s InstallState) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode install state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(installStatePath), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(installStatePath), err)
+	}
+	if err := os.WriteFile(installStatePath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write install state: %w", err)
+	}
+	return nil
+}
+
+// recordInstalled stores the plugins as installed, with their detected
+// version and the non-secret inputs they required. A nil inputs map keeps the
+// inputs already recorded, e.g. after an upgrade.
+func recordInstalled(plugins []ToolPlugin, inputs map[string]string) error {
+	if len(plugins) == 0 {
+		return nil
+	}
+	return updateInstallState(func(state InstallState) {
+		for _, p := range plugins {
+			entry := InstalledPlugin{Version: installedVersion(p), InstalledAt: time.Now().UTC()}
+			if entry.Version == "" {
+				entry.Version = p.Version
+			}
+			if inputs == nil {
+				entry.Inputs = state.Plugins[p.ID].Inputs
+			} else {
+				for _, input := range p.RequiredInputs {
+					value, ok := inputs[input.Name]
+					if !ok || value == "" || input.kind() == InputSecret {
+						continue
+					}
+					if entry.Inputs == nil {
+						entry.Inputs = map[string]string{}
+					}
+					entry.Inputs[input.Name] = value
+				}
+			}
+			state.Plugins[p.ID] = entry
+		}
+	})
+}
+
+// recordRemoved drops the plugins from the state store.
+func recordRemoved(plugins []ToolPlugin) error {
+	if len(plugins) == 0 {
+		return nil
+	}
+	return updateInstallState(func(state InstallState) {
+		for _, p := range plugins {
+			delete(state.Plugins, p.ID)
+		}
+	})
+}
+
+// updateInstallState applies change to the state store. Callers only warn
+// about errors: the store is bookkeeping and must not fail an installation.
+func updateInstallState(change func(state InstallState)) error {
+	if installStatePath == "" {
+		return nil
+	}
+	state, err := LoadInstallState()
+	if err != nil {
+		return err
+	}
+	change(state)
+	if err := state.save(); err != nil {
+		return err
+	}
+	DebugLog("Install state saved to %s", installStatePath)
+	return nil
+}
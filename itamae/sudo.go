@@ -0,0 +1,202 @@
+package itamae
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runningAsRoot reports whether itamae runs as root, as in a container build,
+// where sudo is unnecessary and often not installed. Tests replace it.
+var runningAsRoot = func() bool {
+	return os.Geteuid() == 0
+}
+
+// sudoShim stands in for sudo on the script PATH when itamae runs as root, so
+// scripts calling `sudo cmd ...` keep working without a sudo binary. It drops
+// sudo's options (including -u; everything already runs as root) and runs the
+// command directly.
+const sudoShim = `#!/bin/sh
+# sudo shim written by itamae: running as root, so run the command directly
+while [ $# -gt 0 ]; do
+    case "$1" in
+        -u|-g|-C|-D|-p|-r|-t|-R|-T|-U) shift 2 ;;
+        --) shift; break ;;
+        -*) shift ;;
+        *) break ;;
+    esac
+done
+[ $# -eq 0 ] && exit 0
+exec "$@"
+`
+
+// sudoNoPromptShim stands in for sudo on the script PATH otherwise. Scripts
+// run behind the TUI, where a password prompt can't be answered, so once the
+// credentials obtained at the start of the run expire sudo fails right away
+// instead of waiting for a password. %s is the real sudo.
+const sudoNoPromptShim = `#!/bin/sh
+# sudo shim written by itamae: fail instead of prompting for a password
+exec %s -n "$@"
+`
+
+// writeSudoShim writes the sudo shim for this run into a new temporary
+// directory and returns the directory, to be put first on a script's PATH.
+// Without root or a sudo binary there is nothing to shim and it returns "".
+func writeSudoShim() (string, error) {
+	shim := sudoShim
+	if !runningAsRoot() {
+		sudo, err := exec.LookPath("sudo")
+		if err != nil {
+			return "", nil
+		}
+		shim = fmt.Sprintf(sudoNoPromptShim, shellQuote(sudo))
+	}
+
+	dir, err := os.MkdirTemp("", "itamae-sudo-shim-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create sudo shim: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sudo"), []byte(shim), 0755); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to create sudo shim: %w", err)
+	}
+	return dir, nil
+}
+
+// sudoKeepAliveInterval is how often the sudo timestamp is refreshed during a
+// run. sudo's timestamp_timeout defaults to 15 minutes (5 on some distros).
+var sudoKeepAliveInterval = time.Minute
+
+// sudoRefreshCommand refreshes the sudo timestamp without ever prompting.
+// Tests replace it.
+var sudoRefreshCommand = func() *exec.Cmd {
+	return exec.Command("sudo", "-n", "-v")
+}
+
+// sudoKeepAlive refreshes the sudo timestamp in the background so long runs
+// don't end up with a password prompt hidden behind the TUI.
+type sudoKeepAlive struct {
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+
+	mu        sync.Mutex
+	onExpired func(error)
+	expired   bool
+}
+
+// keepSudoAlive starts refreshing the sudo timestamp every interval until Stop
+// is called. Call it after ensureSudoAccess has succeeded. As root there is
+// nothing to refresh and it returns nil, which is safe to use.
+func keepSudoAlive(interval time.Duration) *sudoKeepAlive {
+	if runningAsRoot() {
+		return nil
+	}
+	k := &sudoKeepAlive{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+		onExpired: func(err error) {
+			DebugLog("WARNING: %v", err)
+		},
+	}
+	go k.run(interval)
+	DebugLog("Sudo keep-alive started (every %s)", interval)
+	return k
+}
+
+func (k *sudoKeepAlive) run(interval time.Duration) {
+	defer close(k.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-k.stop:
+			return
+		case <-ticker.C:
+			k.refresh()
+		}
+	}
+}
+
+// refresh renews the timestamp, reporting once when sudo would need a
+// password again. A later successful refresh re-arms the report.
+func (k *sudoKeepAlive) refresh() {
+	cmd := sudoRefreshCommand()
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err == nil {
+		if k.expired {
+			DebugLog("Sudo credentials refreshed again")
+		}
+		k.expired = false
+		return
+	}
+	if k.expired {
+		return
+	}
+	k.expired = true
+
+	reason := strings.TrimSpace(stderr.String())
+	if reason == "" {
+		reason = err.Error()
+	}
+	k.onExpired(fmt.Errorf("sudo credentials expired and could not be refreshed (%s); steps using sudo will fail", reason))
+}
+
+// Notify replaces the function called when the credentials expire.
+func (k *sudoKeepAlive) Notify(fn func(error)) {
+	if k == nil {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.onExpired = fn
+}
+
+// Expired reports whether the last refresh failed.
+func (k *sudoKeepAlive) Expired() bool {
+	if k == nil {
+		return false
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.expired
+}
+
+// Stop ends the keep-alive and waits for it to exit. It is safe to call more
+// than once and on nil.
+func (k *sudoKeepAlive) Stop() {
+	if k == nil {
+		return
+	}
+	k.stopOnce.Do(func() {
+		close(k.stop)
+		<-k.done
+		DebugLog("Sudo keep-alive stopped")
+	})
+}
+
+// notifySudoExpiredTUI reports expired credentials in the TUI log, where they
+// are visible, instead of on stderr behind the alt-screen.
+func notifySudoExpiredTUI(p *tea.Program) func(error) {
+	return func(err error) {
+		DebugLog("ERROR: %v", err)
+		p.Send(LogMsg{
+			Level:   "error",
+			Package: "",
+			Message: fmt.Sprintf("%v. Press q to quit and run itamae again.", err),
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package itamae
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func stubSudoRefresh(t *testing.T, ok *atomic.Bool, calls *atomic.Int32) {
+	stubRunningAsRoot(t, false)
+	original := sudoRefreshCommand
+	sudoRefreshCommand = func() *exec.Cmd {
+		calls.Add(1)
+		if ok.Load() {
+			return exec.Command("true")
+		}
+		return exec.Command("bash", "-c", "echo 'sudo: a password is required' >&2; exit 1")
+	}
+	t.Cleanup(func() { sudoRefreshCommand = original })
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSudoKeepAliveRefreshesUntilStopped(t *testing.T) {
+	var ok atomic.Bool
+	var calls atomic.Int32
+	ok.Store(true)
+	stubSudoRefresh(t, &ok, &calls)
+
+	k := keepSudoAlive(5 * time.Millisecond)
+	waitFor(t, "refreshes", func() bool { return calls.Load() >= 3 })
+	k.Stop()
+	k.Stop() // Stopping twice is fine
+
+	stopped := calls.Load()
+	time.Sleep(30 * time.Millisecond)
+	if calls.Load() != stopped {
+		t.Error("keep-alive kept refreshing after Stop")
+	}
+	if k.Expired() {
+		t.Error("expected credentials to be valid")
+	}
+}
+
+func TestSudoKeepAliveReportsExpiryOnce(t *testing.T) {
+	var ok atomic.Bool
+	var calls, reports atomic.Int32
+	ok.Store(true)
+	stubSudoRefresh(t, &ok, &calls)
+
+	k := keepSudoAlive(5 * time.Millisecond)
+	defer k.Stop()
+	var message atomic.Value
+	k.Notify(func(err error) {
+		reports.Add(1)
+		message.Store(err.Error())
+	})
+	ok.Store(false)
+	failedFrom := calls.Load()
+
+	waitFor(t, "repeated failed refreshes", func() bool { return calls.Load() >= failedFrom+4 })
+	if reports.Load() != 1 {
+		t.Errorf("expected one report, got %d", reports.Load())
+	}
+	if !k.Expired() {
+		t.Error("expected credentials to be reported expired")
+	}
+	if got, _ := message.Load().(string); !strings.Contains(got, "a password is required") {
+		t.Errorf("expected sudo's reason in the report, got %q", got)
+	}
+
+	// Credentials coming back re-arm the report
+	ok.Store(true)
+	waitFor(t, "recovery", func() bool { return !k.Expired() })
+	ok.Store(false)
+	waitFor(t, "second expiry", func() bool { return reports.Load() == 2 })
+}
+
+func TestSudoKeepAliveNilIsSafe(t *testing.T) {
+	var k *sudoKeepAlive
+	k.Notify(func(error) {})
+	k.Stop()
+	if k.Expired() {
+		t.Error("nil keep-alive reported expiry")
+	}
+}
+
+func stubRunningAsRoot(t *testing.T, root bool) {
+	original := runningAsRoot
+	runningAsRoot = func() bool { return root }
+	t.Cleanup(func() { runningAsRoot = original })
+}
+
+// withMockCommands puts the mock command directory first on PATH and returns
+// a function reading the commands logged so far. Shells named in keep are left
+// unmocked so scripts actually run.
+func withMockCommands(t *testing.T, keep ...string) func() string {
+	dir, logPath, cleanup := setupTestEnvironment()
+	t.Cleanup(cleanup)
+	for _, name := range keep {
+		os.Remove(filepath.Join(dir, name))
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return func() string {
+		logBytes, _ := os.ReadFile(logPath)
+		return string(logBytes)
+	}
+}
+
+func TestScriptSudoModes(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "example.sh")
+	content := `#!/bin/bash
+sudo -E apt-get install -y example
+sudo -u root rm -f /nonexistent
+`
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	plugin := ToolPlugin{ID: "example", Name: "Example", ScriptPath: script}
+
+	tests := []struct {
+		name string
+		root bool
+		want []string
+	}{
+		{"with sudo", false, []string{"sudo -v", "sudo -n -E apt-get install -y example", "sudo -n -u root rm -f /nonexistent"}},
+		{"as root", true, []string{"apt-get install -y example", "rm -f /nonexistent"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubRunningAsRoot(t, tt.root)
+			log := withMockCommands(t, "bash", "sh")
+
+			if err := ensureSudoAccess(); err != nil {
+				t.Fatalf("ensureSudoAccess failed: %v", err)
+			}
+			if err := executeScript(plugin, "install", nil); err != nil {
+				t.Fatalf("script failed: %v", err)
+			}
+
+			got := strings.Split(strings.TrimSpace(log()), "\n")
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("expected commands %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestScriptSudoFailsOnceExpired(t *testing.T) {
+	stubRunningAsRoot(t, false)
+	dir := t.TempDir()
+	// A sudo whose credentials expired: it prompts unless told not to
+	sudo := `#!/bin/sh
+[ "$1" = "-n" ] && { echo "sudo: a password is required" >&2; exit 1; }
+sleep 30
+`
+	if err := os.WriteFile(filepath.Join(dir, "sudo"), []byte(sudo), 0755); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(dir, "example.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/bash\nsudo apt-get install -y example\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	done := make(chan error, 1)
+	go func() { done <- executeScript(ToolPlugin{ID: "example", ScriptPath: script}, "install", nil) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected the script to fail without sudo credentials")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected sudo to fail instead of waiting for a password")
+	}
+}
+
+func TestPrivilegedHelperModes(t *testing.T) {
+	originalAudit := auditLogPath
+	auditLogPath = filepath.Join(t.TempDir(), "audit.log")
+	t.Cleanup(func() { auditLogPath = originalAudit })
+	t.Cleanup(stopPrivilegedHelper)
+
+	// As root, privileged steps run in-process without sudo
+	stubRunningAsRoot(t, true)
+	log := withMockCommands(t)
+	if k := keepSudoAlive(time.Millisecond); k != nil {
+		k.Stop()
+		t.Error("expected no sudo keep-alive as root")
+	}
+	if _, err := elevate(privilegedRequest{Op: opAptInstall, Packages: []string{"git"}}); err != nil {
+		t.Fatalf("elevate failed: %v", err)
+	}
+	if got := log(); !strings.Contains(got, "nala install -y git") || strings.Contains(got, "sudo") {
+		t.Errorf("expected nala to run directly, got:\n%s", got)
+	}
+	stopPrivilegedHelper()
+
+	// Otherwise the helper is started through sudo, which the mock doesn't run
+	stubRunningAsRoot(t, false)
+	log = withMockCommands(t)
+	if _, err := elevate(privilegedRequest{Op: opAptInstall, Packages: []string{"git"}}); err == nil {
+		t.Error("expected the mocked sudo helper not to respond")
+	}
+	if got := log(); !strings.Contains(got, "sudo -n ") || !strings.Contains(got, " privileged-helper") {
+		t.Errorf("expected the helper to be started with sudo -n, got:\n%s", got)
+	}
+}
@@ -14,6 +14,7 @@ import (
 type PackageStatus struct {
 	ID        string    // Plugin ID
 	Name      string    // Display name
+	Version   string    // Pinned version, empty for latest
 	Status    string    // "pending", "running", "success", "error", "skipped"
 	Progress  string    // Progress message (e.g., "Installing...", "Configuring...")
 	Error     string    // Error message if status is "error"
@@ -48,7 +49,7 @@ type InstallModel struct {
 	errors []ErrorInfo
 
 	// Current state
-	activePhase string // "init", "repo_setup", "apt_batch", "individual", "summary", "complete"
+	activePhase string // "init", "repo_setup", "apt_batch", "individual", "remove", "summary", "complete"
 	currentPkg  string // ID of currently installing package
 
 	// UI components
@@ -75,7 +76,7 @@ type InstallModel struct {
 
 // PhaseStartMsg indicates a new installation phase is starting
 type PhaseStartMsg struct {
-	Phase string // "repo_setup", "apt_batch", "individual", "summary"
+	Phase string // "repo_setup", "apt_batch", "individual", "remove", "summary"
 	Count int    // Number of items in this phase
 }
 
@@ -134,9 +135,10 @@ func NewInstallModel(plugins []ToolPlugin) InstallModel {
 
 	for i, p := range plugins {
 		packages[i] = PackageStatus{
-			ID:     p.ID,
-			Name:   p.Name,
-			Status: "pending",
+			ID:      p.ID,
+			Name:    p.Name,
+			Version: p.Version,
+			Status:  "pending",
 		}
 		packageIndex[p.ID] = i
 	}
@@ -277,6 +279,13 @@ func (m InstallModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.complete = true
 		m.successful = msg.Successful
 		m.failed = msg.Failed
+		for _, name := range msg.Successful {
+			m.addLog("success", "", "Summary: "+name)
+		}
+		for _, name := range msg.Failed {
+			m.addLog("error", "", "Summary: "+name+" failed")
+		}
+		m.logViewport.GotoBottom()
 		return m, nil
 
 	case spinner.TickMsg:
@@ -2,70 +2,121 @@ package itamae
 
 import (
 	"fmt"
-	"os/exec"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-// RunInstallTUI runs the installation with the new TUI interface
-func RunInstallTUI(plugins []ToolPlugin, category string) {
+// InstallOptions controls how an installation run behaves.
+type InstallOptions struct {
+	AptMaxAge time.Duration // Refresh package lists older than this; 0 always refreshes
+	Offline   bool          // Install only from the cache in CacheDir
+	CacheDir  string        // Offline cache filled by 'itamae cache fill'
+	// NonInteractive skips the confirmation and fills required inputs from
+	// their default commands instead of prompting.
+	NonInteractive bool
+	// Inputs are values given with --input. They are validated against the
+	// plugins' REQUIRES declarations and are not prompted for.
+	Inputs map[string]string
+	// SecretStore remembers secret inputs between runs; nil asks every time.
+	SecretStore SecretStore
+	// InputDefaults pre-fill prompts ahead of the inputs' default commands,
+	// from team defaults and remembered answers.
+	InputDefaults map[string]string
+	// InputsFile is where answers are remembered; empty doesn't remember them.
+	InputsFile string
+	// UserMode installs without sudo. Only plugins that install into $HOME
+	// (see UserSpacePlugins) may be passed; scripts see ITAMAE_USER_MODE=1.
+	UserMode bool
+}
+
+// scriptEnv returns the environment passed to a plugin's script.
+func (o InstallOptions) scriptEnv(plugin ToolPlugin, requiredInputs map[string]string) map[string]string {
+	env := make(map[string]string, len(requiredInputs)+2)
+	for k, v := range requiredInputs {
+		// Secrets only reach the scripts that declare them
+		if isSecret(v) && !plugin.requiresInput(k) {
+			continue
+		}
+		env[k] = v
+	}
+	if plugin.Version != "" {
+		env["ITAMAE_VERSION"] = plugin.Version
+	}
+	if o.Offline {
+		env["ITAMAE_ARTIFACT_DIR"] = artifactDir(o.CacheDir, plugin.ID)
+	}
+	if o.UserMode {
+		env["ITAMAE_USER_MODE"] = "1"
+	}
+	return env
+}
+
+// RunInstallTUI installs the selected plugins with the TUI interface. It
+// returns an error if the run could not start or any plugin failed; declining
+// the confirmation is not an error.
+func RunInstallTUI(selectedPlugins []ToolPlugin, opts InstallOptions) error {
 	// Initialize debug logging
 	if err := InitDebugLog(); err != nil {
 		fmt.Printf("Warning: Could not initialize debug log: %v\n", err)
 	}
 	defer CloseDebugLog()
 
-	DebugLog("RunInstallTUI started with category: %s, plugins: %d", category, len(plugins))
-
-	// Request sudo access upfront
-	if err := ensureSudoAccess(); err != nil {
-		DebugLog("ERROR: Failed to obtain sudo access: %v", err)
-		fmt.Println("\n❌ Failed to obtain sudo access. Installation cancelled.")
-		return
+	DebugLog("RunInstallTUI started with %d plugins", len(selectedPlugins))
+	for _, plugin := range selectedPlugins {
+		if plugin.Version != "" {
+			DebugLog("Pinned version for %s: %s", plugin.ID, plugin.Version)
+		}
 	}
 
-	var selectedPlugins []ToolPlugin
-	if category == "core" || category == "essentials" {
-		// For core and essentials, install everything without prompting
-		fmt.Printf("Installing %d %s packages\n", len(plugins), category)
-		selectedPlugins = plugins
-		DebugLog("Auto-selected all %d plugins for %s category", len(plugins), category)
+	var keepAlive *sudoKeepAlive
+	if opts.UserMode {
+		if _, excluded := UserSpacePlugins(selectedPlugins); len(excluded) > 0 {
+			return fmt.Errorf("%s needs root and cannot be installed with --user", excluded[0].Plugin.Name)
+		}
+		DebugLog("User mode: skipping sudo")
 	} else {
-		// For unverified, show multiselect
-		selectedPlugins = selectPlugins(plugins)
-		if len(selectedPlugins) == 0 {
-			DebugLog("No plugins selected by user, exiting")
-			fmt.Println("No plugins selected. Exiting.")
-			return
+		// Request sudo access upfront
+		if err := ensureSudoAccess(); err != nil {
+			DebugLog("ERROR: Failed to obtain sudo access: %v", err)
+			return fmt.Errorf("failed to obtain sudo access, installation cancelled: %w", err)
 		}
-		DebugLog("User selected %d plugins", len(selectedPlugins))
+		keepAlive = keepSudoAlive(sudoKeepAliveInterval)
+		defer keepAlive.Stop()
+		defer stopPrivilegedHelper()
 	}
 
-	// Gather all required inputs upfront
-	requiredInputs := make(map[string]string)
-	for _, p := range selectedPlugins {
-		for _, input := range p.RequiredInputs {
-			if _, ok := requiredInputs[input.Name]; !ok {
-				defaultValue := getDefaultValue(input.DefaultCmd)
-				value, err := RunTextInput(input.Prompt, defaultValue)
-				if err != nil {
-					Logger.Errorf("Error getting input for %s: %v", input.Name, err)
-					return
-				}
-				requiredInputs[input.Name] = value
-			}
-		}
+	var requiredInputs map[string]string
+	var err error
+	if opts.NonInteractive {
+		fmt.Printf("Installing %d packages without prompts\n", len(selectedPlugins))
+		requiredInputs, err = defaultInputs(selectedPlugins, opts)
+	} else {
+		// Gather all required inputs upfront
+		requiredInputs, err = gatherInputs(selectedPlugins, opts)
+	}
+	if err != nil {
+		return err
 	}
 
 	// Confirm before proceeding
-	if !confirmInstallation() {
+	if !opts.NonInteractive && !confirmInstallation() {
 		fmt.Println("\nInstallation cancelled.")
-		return
+		return nil
 	}
 
+	return runProgram(selectedPlugins, keepAlive, func(p *tea.Program) error {
+		return processInstallTUI(p, selectedPlugins, applyChanges{}, requiredInputs, opts)
+	})
+}
+
+// runProgram runs the installation TUI for the plugins while work runs in the
+// background, and returns work's error once the TUI exits.
+func runProgram(plugins []ToolPlugin, keepAlive *sudoKeepAlive, work func(p *tea.Program) error) error {
 	// Initialize TUI model
-	DebugLog("Initializing TUI model with %d selected plugins", len(selectedPlugins))
-	model := NewInstallModel(selectedPlugins)
+	DebugLog("Initializing TUI model with %d selected plugins", len(plugins))
+	model := NewInstallModel(plugins)
 
 	// Create Bubbletea program
 	DebugLog("Creating Bubbletea program")
@@ -75,48 +126,164 @@ func RunInstallTUI(plugins []ToolPlugin, category string) {
 		tea.WithMouseCellMotion(),
 	)
 
-	// Start installation in the background
-	DebugLog("Starting installation goroutine")
-	go processInstallTUI(p, selectedPlugins, requiredInputs)
+	// Expired sudo credentials must show up in the TUI, not behind it
+	keepAlive.Notify(notifySudoExpiredTUI(p))
+	if keepAlive.Expired() {
+		go notifySudoExpiredTUI(p)(fmt.Errorf("sudo credentials expired before the run started; steps using sudo will fail"))
+	}
+
+	// Start the work in the background
+	DebugLog("Starting background goroutine")
+	done := make(chan error, 1)
+	go func() { done <- work(p) }()
 
 	// Run the TUI
 	DebugLog("Running TUI program")
 	if _, err := p.Run(); err != nil {
 		DebugLog("ERROR: TUI program failed: %v", err)
 		fmt.Printf("Error running TUI: %v\n", err)
-		return
+		return fmt.Errorf("TUI failed: %w", err)
 	}
 	DebugLog("TUI program exited normally")
+
+	select {
+	case err := <-done:
+		return err
+	default:
+		return fmt.Errorf("quit before the run finished")
+	}
 }
 
-// processInstallTUI orchestrates the installation and sends messages to the TUI
-func processInstallTUI(p *tea.Program, selectedPlugins []ToolPlugin, requiredInputs map[string]string) {
-	// Separate plugins by install method
-	aptPlugins := []ToolPlugin{}
-	otherPlugins := []ToolPlugin{}
-	for _, plugin := range selectedPlugins {
+// refreshPackageListsTUI runs a single package list update if needsAptUpdate says so
+func refreshPackageListsTUI(p *tea.Program, maxAge time.Duration) error {
+	update, reason := needsAptUpdate(maxAge)
+	DebugLog("Package list update needed: %t (%s)", update, reason)
+
+	if !update {
+		p.Send(LogMsg{Level: "info", Package: "", Message: fmt.Sprintf("Skipping package list update: %s", reason)})
+		return nil
+	}
+
+	p.Send(LogMsg{Level: "info", Package: "", Message: fmt.Sprintf("Updating package lists (%s)...", reason)})
+
+	output, err := updatePackageLists()
+	if err != nil {
+		DebugLog("ERROR: Package list update failed: %v", err)
+		p.Send(ErrorMsg{
+			Package: "",
+			Phase:   "update",
+			Message: fmt.Sprintf("Package list update failed: %v\nOutput: %s", err, output),
+		})
+		return err
+	}
+
+	DebugLog("Package list update successful")
+	p.Send(LogMsg{Level: "success", Package: "", Message: "Package lists updated successfully"})
+	return nil
+}
+
+// installPlan groups plugins into the phases of an installation run.
+type installPlan struct {
+	AptRepos     []AptRepo    // Declarative repositories, de-duplicated and written by Go
+	RepoPlugins  []ToolPlugin // APT plugins without APT_REPO that run their REPO_SETUP function
+	AptPlugins   []ToolPlugin // Installed in one batch, then their POST_INSTALL functions run
+	OtherPlugins []ToolPlugin // Installed one at a time by their scripts
+}
+
+// planInstall splits the plugins by install method, keeping their order.
+func planInstall(plugins []ToolPlugin) installPlan {
+	plan := installPlan{AptPlugins: []ToolPlugin{}, OtherPlugins: []ToolPlugin{}, RepoPlugins: []ToolPlugin{}}
+	for _, plugin := range plugins {
 		if plugin.InstallMethod == "apt" {
-			aptPlugins = append(aptPlugins, plugin)
+			plan.AptPlugins = append(plan.AptPlugins, plugin)
 		} else {
-			otherPlugins = append(otherPlugins, plugin)
+			plan.OtherPlugins = append(plan.OtherPlugins, plugin)
 		}
 	}
 
+	plan.AptRepos = collectAptRepos(plan.AptPlugins)
+	for _, plugin := range plan.AptPlugins {
+		if !plugin.AptRepo.IsSet() && plugin.RepoSetup != "" {
+			plan.RepoPlugins = append(plan.RepoPlugins, plugin)
+		}
+	}
+	return plan
+}
+
+// applyChanges lists what an installation run changes besides installing new
+// plugins, for 'itamae apply'.
+type applyChanges struct {
+	Versions    []ToolPlugin // Installed APT plugins moved to their pinned version, downgrades included
+	Remove      []ToolPlugin // Removed once everything else is installed
+	RemoveRepos []AptRepo    // Declarative repositories no remaining plugin uses
+}
+
+// processInstallTUI orchestrates the installation and sends messages to the
+// TUI. It returns an error if any plugin failed.
+func processInstallTUI(p *tea.Program, selectedPlugins []ToolPlugin, changes applyChanges, requiredInputs map[string]string, opts InstallOptions) error {
 	// Track success/failure
 	successful := []string{}
 	failed := []string{}
+	installed := []ToolPlugin{}
 
-	// Phase 0: Repository Setup
-	repoPlugins := []ToolPlugin{}
-	for _, plugin := range aptPlugins {
-		if plugin.RepoSetup != "" {
-			repoPlugins = append(repoPlugins, plugin)
+	// In offline mode, only plugins with a complete cache entry can be installed
+	var manifest CacheManifest
+	if opts.Offline {
+		var err error
+		manifest, err = loadCacheManifest(opts.CacheDir)
+		if err != nil {
+			DebugLog("ERROR: %v", err)
+			p.Send(ErrorMsg{Package: "", Phase: "offline", Message: err.Error()})
+			for _, plugin := range selectedPlugins {
+				failed = append(failed, plugin.Name)
+			}
+			return sendSummary(p, successful, failed)
 		}
+
+		available := []ToolPlugin{}
+		for _, plugin := range selectedPlugins {
+			if err := manifest.offlineSupport(plugin); err != nil {
+				DebugLog("Cannot install %s offline: %v", plugin.Name, err)
+				p.Send(ErrorMsg{Package: plugin.ID, Phase: "offline", Message: err.Error()})
+				p.Send(PackageCompleteMsg{PackageID: plugin.ID, Success: false, Error: "Not available offline: " + err.Error()})
+				failed = append(failed, plugin.Name)
+				continue
+			}
+			available = append(available, plugin)
+		}
+		selectedPlugins = available
 	}
 
-	if len(repoPlugins) > 0 {
-		DebugLog("Phase 0: Setting up %d custom repositories", len(repoPlugins))
-		p.Send(PhaseStartMsg{Phase: "repo_setup", Count: len(repoPlugins)})
+	plan := planInstall(selectedPlugins)
+	aptPlugins, otherPlugins := plan.AptPlugins, plan.OtherPlugins
+	aptRepos, repoPlugins := plan.AptRepos, plan.RepoPlugins
+
+	// Phase 0: Repository Setup
+	if opts.Offline {
+		DebugLog("Offline mode: skipping repository setup and package list update")
+	} else if len(aptRepos) > 0 || len(repoPlugins) > 0 {
+		DebugLog("Phase 0: Setting up %d declarative and %d scripted repositories", len(aptRepos), len(repoPlugins))
+		p.Send(PhaseStartMsg{Phase: "repo_setup", Count: len(aptRepos) + len(repoPlugins)})
+
+		for _, repo := range aptRepos {
+			users := pluginsUsingRepo(aptPlugins, repo)
+			DebugLog("Writing APT repository %s (used by %v)", repo.SourcesPath(), users)
+			p.Send(LogMsg{Level: "info", Package: "", Message: fmt.Sprintf("Configuring APT repository %s for %s", repo.URL, strings.Join(users, ", "))})
+
+			changed, err := writeAptRepo(repo)
+			if err != nil {
+				DebugLog("ERROR: Repository setup failed for %s: %v", repo.URL, err)
+				p.Send(ErrorMsg{
+					Package: "",
+					Phase:   "repo_setup",
+					Message: fmt.Sprintf("Repository setup failed for %s: %v", repo.URL, err),
+				})
+				p.Send(LogMsg{Level: "error", Package: "", Message: "Repository setup failed. Cannot proceed with installation."})
+				return sendSummary(p, successful, append(failed, users...))
+			}
+
+			DebugLog("Repository %s configured (changed: %t)", repo.URL, changed)
+		}
 
 		for _, plugin := range repoPlugins {
 			DebugLog("Setting up repository for: %s (ID: %s)", plugin.Name, plugin.ID)
@@ -124,7 +291,7 @@ func processInstallTUI(p *tea.Program, selectedPlugins []ToolPlugin, requiredInp
 			p.Send(LogMsg{Level: "info", Package: plugin.ID, Message: "Setting up custom repository..."})
 
 			// Execute repo setup synchronously (must be sequential)
-			if err := executeScript(plugin, "setup_repo", requiredInputs); err != nil {
+			if err := executeScript(plugin, "setup_repo", opts.scriptEnv(plugin, requiredInputs)); err != nil {
 				DebugLog("ERROR: Repository setup failed for %s: %v", plugin.Name, err)
 				p.Send(ErrorMsg{
 					Package: plugin.ID,
@@ -133,8 +300,7 @@ func processInstallTUI(p *tea.Program, selectedPlugins []ToolPlugin, requiredInp
 				})
 				p.Send(PackageCompleteMsg{PackageID: plugin.ID, Success: false, Error: err.Error()})
 				p.Send(LogMsg{Level: "error", Package: "", Message: "Repository setup failed. Cannot proceed with installation."})
-				p.Send(SummaryMsg{Successful: successful, Failed: append(failed, plugin.Name)})
-				return
+				return sendSummary(p, successful, append(failed, plugin.Name))
 			}
 
 			DebugLog("Repository setup successful for: %s", plugin.Name)
@@ -142,36 +308,17 @@ func processInstallTUI(p *tea.Program, selectedPlugins []ToolPlugin, requiredInp
 		}
 
 		p.Send(PhaseCompleteMsg{Phase: "repo_setup"})
+	}
 
-		// Run single apt-get update
-		DebugLog("Running package list update")
-		p.Send(LogMsg{Level: "info", Package: "", Message: "Updating package lists..."})
-
-		var updateCmd *exec.Cmd
-		if _, err := exec.LookPath("nala"); err == nil {
-			DebugLog("Using nala for update")
-			updateCmd = exec.Command("sudo", "nala", "update")
-		} else {
-			DebugLog("Using apt-get for update")
-			updateCmd = exec.Command("sudo", "apt-get", "update")
-		}
-
-		output, err := updateCmd.CombinedOutput()
-		DebugLog("Update output:\n%s", string(output))
-		if err != nil {
-			DebugLog("ERROR: Package list update failed: %v", err)
-			p.Send(ErrorMsg{
-				Package: "",
-				Phase:   "update",
-				Message: fmt.Sprintf("Package list update failed: %v\nOutput: %s", err, output),
-			})
+	// Refresh package lists only when they are missing, stale, or sources changed
+	if (len(aptPlugins) > 0 || len(changes.Versions) > 0) && !opts.Offline {
+		if err := refreshPackageListsTUI(p, opts.AptMaxAge); err != nil {
 			p.Send(LogMsg{Level: "error", Package: "", Message: "Package list update failed. Cannot proceed with installation."})
-			p.Send(SummaryMsg{Successful: successful, Failed: failed})
-			return
+			for _, plugin := range append(append([]ToolPlugin{}, selectedPlugins...), changes.Versions...) {
+				failed = append(failed, plugin.Name)
+			}
+			return sendSummary(p, successful, failed)
 		}
-
-		DebugLog("Package list update successful")
-		p.Send(LogMsg{Level: "success", Package: "", Message: "Package lists updated successfully"})
 	}
 
 	// Phase 1: Batch install APT packages
@@ -186,41 +333,33 @@ func processInstallTUI(p *tea.Program, selectedPlugins []ToolPlugin, requiredInp
 			p.Send(PackageStartMsg{PackageID: plugin.ID, Phase: "install"})
 		}
 
-		// Check if nala is available
-		_, err := exec.LookPath("nala")
-		useNala := err == nil
-		DebugLog("Using package manager: %s", map[bool]string{true: "nala", false: "apt-get"}[useNala])
-
 		// Collect package names
 		packages := []string{}
 		for _, plugin := range aptPlugins {
 			if plugin.PackageName != "" {
-				packages = append(packages, plugin.PackageName)
+				packages = append(packages, aptPackageSpec(plugin))
 			}
 		}
 		DebugLog("Packages to install: %v", packages)
 
-		// Build install command
-		var cmd *exec.Cmd
-		if useNala {
-			args := append([]string{"nala", "install", "-y"}, packages...)
-			cmd = exec.Command("sudo", args...)
-			DebugLog("Command: sudo %v", args)
+		// Install through the privileged helper
+		req := privilegedRequest{Op: opAptInstall, Packages: packages}
+		if opts.Offline {
+			req = privilegedRequest{Op: opAptInstall, Debs: absPaths(withoutInstalledDebs(manifest.debPaths(opts.CacheDir, aptPlugins)))}
+		}
+		DebugLog("Executing batch install...")
+		var resp privilegedResponse
+		var err error
+		if opts.Offline && len(req.Debs) == 0 {
+			DebugLog("Every cached package is already installed")
 		} else {
-			args := append([]string{"apt-get", "install", "-y"}, packages...)
-			cmd = exec.Command("sudo", args...)
-			DebugLog("Command: sudo %v", args)
+			resp, err = elevate(req)
 		}
 
-		// Execute with output capture
-		DebugLog("Executing batch install command...")
-		output, err := cmd.CombinedOutput()
-		DebugLog("Batch install output:\n%s", string(output))
-
 		if err != nil {
 			DebugLog("ERROR: Batch APT installation failed: %v", err)
 			p.Send(LogMsg{Level: "error", Package: "", Message: fmt.Sprintf("Batch APT installation failed: %v", err)})
-			p.Send(ErrorMsg{Package: "", Phase: "apt_batch", Message: string(output)})
+			p.Send(ErrorMsg{Package: "", Phase: "apt_batch", Message: resp.Output})
 
 			// Mark all as failed
 			for _, plugin := range aptPlugins {
@@ -236,7 +375,8 @@ func processInstallTUI(p *tea.Program, selectedPlugins []ToolPlugin, requiredInp
 			for _, plugin := range aptPlugins {
 				DebugLog("Marking %s as successful", plugin.Name)
 				p.Send(PackageCompleteMsg{PackageID: plugin.ID, Success: true})
-				successful = append(successful, plugin.Name)
+				successful = append(successful, summaryLabel(plugin))
+				installed = append(installed, plugin)
 			}
 
 			// Run post-install tasks
@@ -247,7 +387,7 @@ func processInstallTUI(p *tea.Program, selectedPlugins []ToolPlugin, requiredInp
 					p.Send(PackageStartMsg{PackageID: plugin.ID, Phase: "post_install"})
 					p.Send(LogMsg{Level: "info", Package: plugin.ID, Message: "Running post-installation tasks..."})
 
-					if err := executeScript(plugin, "post_install", requiredInputs); err != nil {
+					if err := executeScript(plugin, "post_install", opts.scriptEnv(plugin, requiredInputs)); err != nil {
 						DebugLog("ERROR: Post-install failed for %s: %v", plugin.Name, err)
 						p.Send(LogMsg{Level: "warning", Package: plugin.ID, Message: fmt.Sprintf("Post-install failed: %v", err)})
 					} else {
@@ -263,6 +403,39 @@ func processInstallTUI(p *tea.Program, selectedPlugins []ToolPlugin, requiredInp
 		p.Send(PhaseCompleteMsg{Phase: "apt_batch"})
 	}
 
+	// Phase 1b: Move installed APT packages to their pinned versions. This is
+	// its own request so a downgrade can't abort the install batch.
+	if len(changes.Versions) > 0 {
+		DebugLog("Phase 1b: Changing the version of %d APT packages", len(changes.Versions))
+		p.Send(PhaseStartMsg{Phase: "apt_version", Count: len(changes.Versions)})
+
+		packages := []string{}
+		for _, plugin := range changes.Versions {
+			p.Send(PackageStartMsg{PackageID: plugin.ID, Phase: "install"})
+			packages = append(packages, aptPackageSpec(plugin))
+		}
+		DebugLog("Package versions to install: %v", packages)
+
+		resp, err := elevate(privilegedRequest{Op: opAptInstall, Packages: packages, Downgrade: true})
+		if err != nil {
+			DebugLog("ERROR: APT version change failed: %v", err)
+			p.Send(LogMsg{Level: "error", Package: "", Message: fmt.Sprintf("APT version change failed: %v", err)})
+			p.Send(ErrorMsg{Package: "", Phase: "apt_version", Message: resp.Output})
+			for _, plugin := range changes.Versions {
+				p.Send(PackageCompleteMsg{PackageID: plugin.ID, Success: false, Error: "Version change failed"})
+				failed = append(failed, plugin.Name)
+			}
+		} else {
+			for _, plugin := range changes.Versions {
+				p.Send(PackageCompleteMsg{PackageID: plugin.ID, Success: true})
+				successful = append(successful, summaryLabel(plugin))
+				installed = append(installed, plugin)
+			}
+		}
+
+		p.Send(PhaseCompleteMsg{Phase: "apt_version"})
+	}
+
 	// Phase 2: Install other plugins individually
 	if len(otherPlugins) > 0 {
 		DebugLog("Phase 2: Installing %d individual packages", len(otherPlugins))
@@ -273,7 +446,7 @@ func processInstallTUI(p *tea.Program, selectedPlugins []ToolPlugin, requiredInp
 			p.Send(PackageStartMsg{PackageID: plugin.ID, Phase: "install"})
 			p.Send(LogMsg{Level: "info", Package: plugin.ID, Message: "Installing..."})
 
-			if err := executeScript(plugin, "install", requiredInputs); err != nil {
+			if err := executeScript(plugin, "install", opts.scriptEnv(plugin, requiredInputs)); err != nil {
 				DebugLog("ERROR: Installation failed for %s: %v", plugin.Name, err)
 				p.Send(ErrorMsg{
 					Package: plugin.ID,
@@ -285,7 +458,8 @@ func processInstallTUI(p *tea.Program, selectedPlugins []ToolPlugin, requiredInp
 			} else {
 				DebugLog("Installation successful for: %s", plugin.Name)
 				p.Send(PackageCompleteMsg{PackageID: plugin.ID, Success: true})
-				successful = append(successful, plugin.Name)
+				successful = append(successful, summaryLabel(plugin))
+				installed = append(installed, plugin)
 			}
 		}
 
@@ -293,7 +467,77 @@ func processInstallTUI(p *tea.Program, selectedPlugins []ToolPlugin, requiredInp
 		p.Send(PhaseCompleteMsg{Phase: "individual"})
 	}
 
+	// Old installs left by earlier versions would shadow the new one on PATH
+	for _, plugin := range installed {
+		if err := removeLegacyPaths(plugin); err != nil {
+			DebugLog("ERROR: %v", err)
+			p.Send(LogMsg{Level: "warning", Package: plugin.ID, Message: err.Error()})
+		}
+	}
+
+	if err := recordInstalled(installed, requiredInputs); err != nil {
+		DebugLog("ERROR: %v", err)
+		p.Send(LogMsg{Level: "warning", Package: "", Message: fmt.Sprintf("Could not update install state: %v", err)})
+	}
+
+	// Phase 3: Remove plugins the profile marks absent
+	if len(changes.Remove) > 0 {
+		DebugLog("Phase 3: Removing %d packages", len(changes.Remove))
+		p.Send(PhaseStartMsg{Phase: "remove", Count: len(changes.Remove)})
+
+		removed := []ToolPlugin{}
+		kept := []ToolPlugin{}
+		for _, plugin := range changes.Remove {
+			DebugLog("Removing package: %s", plugin.Name)
+			p.Send(PackageStartMsg{PackageID: plugin.ID, Phase: "remove"})
+			p.Send(LogMsg{Level: "info", Package: plugin.ID, Message: "Removing..."})
+
+			output, err := removePlugin(plugin)
+			if err != nil {
+				DebugLog("ERROR: Removal failed for %s: %v", plugin.Name, err)
+				p.Send(ErrorMsg{Package: plugin.ID, Phase: "remove", Message: fmt.Sprintf("Removal failed: %v\n%s", err, output)})
+				p.Send(PackageCompleteMsg{PackageID: plugin.ID, Success: false, Error: err.Error()})
+				failed = append(failed, plugin.Name)
+				kept = append(kept, plugin)
+				continue
+			}
+
+			DebugLog("Removal successful for: %s", plugin.Name)
+			p.Send(PackageCompleteMsg{PackageID: plugin.ID, Success: true})
+			successful = append(successful, plugin.Name+" (removed)")
+			removed = append(removed, plugin)
+		}
+
+		for _, repo := range changes.RemoveRepos {
+			if users := pluginsUsingRepo(kept, repo); len(users) > 0 {
+				DebugLog("Keeping repository %s for %v", repo.URL, users)
+				continue
+			}
+			p.Send(LogMsg{Level: "info", Package: "", Message: fmt.Sprintf("Removing APT repository %s", repo.URL)})
+			if err := removeAptRepo(repo); err != nil {
+				DebugLog("ERROR: %v", err)
+				p.Send(LogMsg{Level: "warning", Package: "", Message: err.Error()})
+			}
+		}
+
+		if err := recordRemoved(removed); err != nil {
+			DebugLog("ERROR: %v", err)
+			p.Send(LogMsg{Level: "warning", Package: "", Message: fmt.Sprintf("Could not update install state: %v", err)})
+		}
+		p.Send(PhaseCompleteMsg{Phase: "remove"})
+	}
+
 	// Send summary
 	DebugLog("Installation complete - Successful: %d, Failed: %d", len(successful), len(failed))
+	return sendSummary(p, successful, failed)
+}
+
+// sendSummary shows the summary of a run and returns an error naming the
+// plugins that failed, if any.
+func sendSummary(p *tea.Program, successful, failed []string) error {
 	p.Send(SummaryMsg{Successful: successful, Failed: failed})
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d plugin(s) failed: %s", len(failed), strings.Join(failed, ", "))
 }
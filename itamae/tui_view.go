@@ -64,13 +64,13 @@ func renderChecklistPane(m InstallModel) string {
 			// Show spinner for active package
 			line = fmt.Sprintf("%s %s %s",
 				m.spinner.View(),
-				PackageNameStyle().Render(pkg.Name),
+				packageLabel(pkg),
 				lipgloss.NewStyle().Foreground(TokyoNightComment).Render(pkg.Progress),
 			)
 		} else {
 			line = fmt.Sprintf("%s %s",
 				icon,
-				PackageNameStyle().Render(pkg.Name),
+				packageLabel(pkg),
 			)
 
 			// Add progress/error on next line if present
@@ -112,6 +112,15 @@ func renderChecklistPane(m InstallModel) string {
 		Render(viewportContent)
 }
 
+// packageLabel renders a package name, followed by its pinned version if any
+func packageLabel(pkg PackageStatus) string {
+	label := PackageNameStyle().Render(pkg.Name)
+	if pkg.Version != "" {
+		label += " " + TimestampStyle().Render(pkg.Version)
+	}
+	return label
+}
+
 // renderLogPane renders the right log pane
 func renderLogPane(m InstallModel) string {
 	if m.width == 0 {
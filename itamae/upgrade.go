@@ -0,0 +1,169 @@
+package itamae
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// RunUpgradeTUI upgrades the given (installed) plugins with the TUI interface.
+// APT plugins are upgraded in one batch; other plugins run their script's
+// `upgrade` router case, falling back to `install` when there is none. With
+// opts.UserMode it runs without sudo and refuses plugins that need root. It
+// returns an error if the run could not start or any upgrade failed.
+func RunUpgradeTUI(plugins []ToolPlugin, opts InstallOptions) error {
+	// Initialize debug logging
+	if err := InitDebugLog(); err != nil {
+		fmt.Printf("Warning: Could not initialize debug log: %v\n", err)
+	}
+	defer CloseDebugLog()
+
+	DebugLog("RunUpgradeTUI started with %d plugins", len(plugins))
+
+	var keepAlive *sudoKeepAlive
+	if opts.UserMode {
+		if _, excluded := UserSpacePlugins(plugins); len(excluded) > 0 {
+			return fmt.Errorf("%s needs root and cannot be upgraded with --user", excluded[0].Plugin.Name)
+		}
+		DebugLog("User mode: skipping sudo")
+	} else {
+		// Request sudo access upfront
+		if err := ensureSudoAccess(); err != nil {
+			DebugLog("ERROR: Failed to obtain sudo access: %v", err)
+			return fmt.Errorf("failed to obtain sudo access, upgrade cancelled: %w", err)
+		}
+		keepAlive = keepSudoAlive(sudoKeepAliveInterval)
+		defer keepAlive.Stop()
+		defer stopPrivilegedHelper()
+	}
+
+	// Plugins without an upgrade case are reinstalled, which may need inputs
+	reinstall := []ToolPlugin{}
+	for _, p := range plugins {
+		if p.InstallMethod != "apt" && !hasRouterCase(p, "upgrade") {
+			reinstall = append(reinstall, p)
+		}
+	}
+	requiredInputs, err := gatherInputs(reinstall, opts)
+	if err != nil {
+		return err
+	}
+
+	if !confirmAction("Proceed with upgrade?", fmt.Sprintf("This will upgrade %d installed tools on your system.", len(plugins))) {
+		fmt.Println("\nUpgrade cancelled.")
+		return nil
+	}
+
+	return runProgram(plugins, keepAlive, func(p *tea.Program) error {
+		return processUpgradeTUI(p, plugins, requiredInputs, opts)
+	})
+}
+
+// processUpgradeTUI orchestrates the upgrade and sends messages to the TUI. It
+// returns an error if any upgrade failed.
+func processUpgradeTUI(p *tea.Program, plugins []ToolPlugin, requiredInputs map[string]string, opts InstallOptions) error {
+	successful := []string{}
+	failed := []string{}
+	upgraded := []ToolPlugin{}
+
+	// Record versions before upgrading so the summary can show what changed
+	before := make(map[string]string, len(plugins))
+	for _, plugin := range plugins {
+		before[plugin.ID] = installedVersion(plugin)
+		DebugLog("Version before upgrade for %s: %q", plugin.ID, before[plugin.ID])
+	}
+
+	aptPlugins := []ToolPlugin{}
+	otherPlugins := []ToolPlugin{}
+	for _, plugin := range plugins {
+		if plugin.InstallMethod == "apt" {
+			aptPlugins = append(aptPlugins, plugin)
+		} else {
+			otherPlugins = append(otherPlugins, plugin)
+		}
+	}
+
+	// Phase 1: Batch upgrade APT packages
+	if len(aptPlugins) > 0 {
+		DebugLog("Phase 1: Batch upgrading %d APT packages", len(aptPlugins))
+		p.Send(PhaseStartMsg{Phase: "apt_upgrade", Count: len(aptPlugins)})
+
+		if err := refreshPackageListsTUI(p, opts.AptMaxAge); err != nil {
+			for _, plugin := range aptPlugins {
+				p.Send(PackageCompleteMsg{PackageID: plugin.ID, Success: false, Error: "Package list update failed"})
+				failed = append(failed, plugin.Name)
+			}
+		} else {
+			packages := []string{}
+			for _, plugin := range aptPlugins {
+				p.Send(PackageStartMsg{PackageID: plugin.ID, Phase: "upgrade"})
+				if plugin.PackageName != "" {
+					packages = append(packages, aptPackageSpec(plugin))
+				}
+			}
+
+			resp, err := elevate(privilegedRequest{Op: opAptInstall, Packages: packages, Upgrade: true})
+
+			if err != nil {
+				DebugLog("ERROR: Batch APT upgrade failed: %v", err)
+				p.Send(LogMsg{Level: "error", Package: "", Message: fmt.Sprintf("Batch APT upgrade failed: %v", err)})
+				p.Send(ErrorMsg{Package: "", Phase: "apt_upgrade", Message: resp.Output})
+				for _, plugin := range aptPlugins {
+					p.Send(PackageCompleteMsg{PackageID: plugin.ID, Success: false, Error: "Batch upgrade failed"})
+					failed = append(failed, plugin.Name)
+				}
+			} else {
+				for _, plugin := range aptPlugins {
+					after := installedVersion(plugin)
+					p.Send(LogMsg{Level: "info", Package: plugin.ID, Message: upgradeLabel(plugin, before[plugin.ID], after)})
+					p.Send(PackageCompleteMsg{PackageID: plugin.ID, Success: true})
+					successful = append(successful, upgradeLabel(plugin, before[plugin.ID], after))
+					upgraded = append(upgraded, plugin)
+				}
+			}
+		}
+
+		p.Send(PhaseCompleteMsg{Phase: "apt_upgrade"})
+	}
+
+	// Phase 2: Upgrade other plugins individually
+	if len(otherPlugins) > 0 {
+		DebugLog("Phase 2: Upgrading %d individual packages", len(otherPlugins))
+		p.Send(PhaseStartMsg{Phase: "individual", Count: len(otherPlugins)})
+
+		for _, plugin := range otherPlugins {
+			command := "upgrade"
+			if !hasRouterCase(plugin, "upgrade") {
+				command = "install"
+				p.Send(LogMsg{Level: "info", Package: plugin.ID, Message: "No upgrade command, reinstalling..."})
+			}
+
+			DebugLog("Upgrading %s via '%s'", plugin.Name, command)
+			p.Send(PackageStartMsg{PackageID: plugin.ID, Phase: command})
+
+			if err := executeScript(plugin, command, opts.scriptEnv(plugin, requiredInputs)); err != nil {
+				DebugLog("ERROR: Upgrade failed for %s: %v", plugin.Name, err)
+				p.Send(ErrorMsg{Package: plugin.ID, Phase: command, Message: fmt.Sprintf("Upgrade failed: %v", err)})
+				p.Send(PackageCompleteMsg{PackageID: plugin.ID, Success: false, Error: err.Error()})
+				failed = append(failed, plugin.Name)
+				continue
+			}
+
+			after := installedVersion(plugin)
+			p.Send(LogMsg{Level: "info", Package: plugin.ID, Message: upgradeLabel(plugin, before[plugin.ID], after)})
+			p.Send(PackageCompleteMsg{PackageID: plugin.ID, Success: true})
+			successful = append(successful, upgradeLabel(plugin, before[plugin.ID], after))
+			upgraded = append(upgraded, plugin)
+		}
+
+		p.Send(PhaseCompleteMsg{Phase: "individual"})
+	}
+
+	if err := recordInstalled(upgraded, nil); err != nil {
+		DebugLog("ERROR: %v", err)
+		p.Send(LogMsg{Level: "warning", Package: "", Message: fmt.Sprintf("Could not update install state: %v", err)})
+	}
+
+	DebugLog("Upgrade complete - Successful: %d, Failed: %d", len(successful), len(failed))
+	return sendSummary(p, successful, failed)
+}
@@ -0,0 +1,192 @@
+package itamae
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// messageRecorder is a headless TUI model that keeps every message it gets
+// and quits on the summary.
+type messageRecorder struct {
+	msgs []tea.Msg
+}
+
+func (m *messageRecorder) Init() tea.Cmd { return nil }
+
+func (m *messageRecorder) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m.msgs = append(m.msgs, msg)
+	if _, ok := msg.(SummaryMsg); ok {
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m *messageRecorder) View() string { return "" }
+
+// recordProgram runs work against a headless program and returns the
+// messages it sent and work's error.
+func recordProgram(t *testing.T, work func(p *tea.Program) error) ([]tea.Msg, error) {
+	t.Helper()
+	recorder := &messageRecorder{}
+	p := tea.NewProgram(recorder, tea.WithInput(nil), tea.WithOutput(io.Discard), tea.WithoutRenderer(), tea.WithoutSignalHandler())
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Run()
+		done <- err
+	}()
+
+	workErr := work(p)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("program failed: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("program did not quit after the summary")
+	}
+	return recorder.msgs, workErr
+}
+
+// summaryOf returns the summary among msgs.
+func summaryOf(t *testing.T, msgs []tea.Msg) SummaryMsg {
+	t.Helper()
+	for _, msg := range msgs {
+		if summary, ok := msg.(SummaryMsg); ok {
+			return summary
+		}
+	}
+	t.Fatalf("no summary in %v", msgs)
+	return SummaryMsg{}
+}
+
+// writeVersionedPlugin writes a plugin whose version command reads a file
+// that its install (and, with upgrade, its upgrade) command rewrites.
+func writeVersionedPlugin(t *testing.T, dir, id, before, after string, upgrade bool) ToolPlugin {
+	t.Helper()
+	versionFile := filepath.Join(dir, id+".version")
+	if err := os.WriteFile(versionFile, []byte(before+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/bash\ncase \"$1\" in\n" +
+		"    install) echo " + after + " > " + versionFile + "; echo install >> " + versionFile + ".log ;;\n"
+	if upgrade {
+		script += "    upgrade) echo " + after + " > " + versionFile + "; echo upgrade >> " + versionFile + ".log ;;\n"
+	}
+	script += "    check) exit 0 ;;\n    version) cat " + versionFile + " ;;\nesac\n"
+	path := filepath.Join(dir, id+".sh")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return ToolPlugin{ID: id, Name: id, InstallMethod: "binary", ScriptPath: path}
+}
+
+func TestUpgradeBatchesAptWithOnlyUpgrade(t *testing.T) {
+	useTempInstallState(t)
+	readLog := withMockCommands(t)
+	useInProcessHelper(t)
+
+	aptPlugins := []ToolPlugin{
+		{ID: "git", Name: "Git", InstallMethod: "apt", PackageName: "git"},
+		{ID: "fzf", Name: "fzf", InstallMethod: "apt", PackageName: "fzf"},
+	}
+	_, err := recordProgram(t, func(p *tea.Program) error {
+		return processUpgradeTUI(p, aptPlugins, nil, InstallOptions{AptMaxAge: DefaultAptMaxAge})
+	})
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+
+	log := readLog()
+	if !strings.Contains(log, "install --only-upgrade -y git fzf") {
+		t.Errorf("expected one --only-upgrade batch for both packages, got:\n%s", log)
+	}
+	if strings.Count(log, "--only-upgrade") != 1 {
+		t.Errorf("expected a single batch, got:\n%s", log)
+	}
+}
+
+func TestUpgradeFallsBackToInstall(t *testing.T) {
+	useTempInstallState(t)
+	withMockCommands(t, "bash")
+	dir := t.TempDir()
+
+	upgradable := writeVersionedPlugin(t, dir, "upgradable", "1.0.0", "1.1.0", true)
+	reinstalled := writeVersionedPlugin(t, dir, "reinstalled", "v2.0.0", "v2.1.0", false)
+	current := writeVersionedPlugin(t, dir, "current", "3.0.0", "v3.0.0", true)
+
+	msgs, err := recordProgram(t, func(p *tea.Program) error {
+		return processUpgradeTUI(p, []ToolPlugin{upgradable, reinstalled, current}, nil, InstallOptions{})
+	})
+	if err != nil {
+		t.Fatalf("upgrade failed: %v", err)
+	}
+
+	for id, want := range map[string]string{"upgradable": "upgrade", "reinstalled": "install", "current": "upgrade"} {
+		got, err := os.ReadFile(filepath.Join(dir, id+".version.log"))
+		if err != nil || strings.TrimSpace(string(got)) != want {
+			t.Errorf("expected %s to run %s, got %q %v", id, want, got, err)
+		}
+	}
+
+	// The summary labels show the versions before and after
+	want := []string{"upgradable (1.0.0 → 1.1.0)", "reinstalled (v2.0.0 → v2.1.0)", "current (v3.0.0, unchanged)"}
+	summary := summaryOf(t, msgs)
+	if len(summary.Successful) != len(want) || len(summary.Failed) != 0 {
+		t.Fatalf("expected %v, got %+v", want, summary)
+	}
+	for i := range want {
+		if summary.Successful[i] != want[i] {
+			t.Errorf("label %d: expected %q, got %q", i, want[i], summary.Successful[i])
+		}
+	}
+
+	reinstallLogged := false
+	for _, msg := range msgs {
+		if log, ok := msg.(LogMsg); ok && log.Package == "reinstalled" && strings.Contains(log.Message, "reinstalling") {
+			reinstallLogged = true
+		}
+	}
+	if !reinstallLogged {
+		t.Error("expected the fallback to reinstall to be logged")
+	}
+}
+
+func TestUpgradeReportsFailures(t *testing.T) {
+	useTempInstallState(t)
+	withMockCommands(t, "bash")
+	dir := t.TempDir()
+
+	broken := writeVersionedPlugin(t, dir, "broken", "1.0.0", "1.1.0", true)
+	if err := os.WriteFile(broken.ScriptPath, []byte("#!/bin/bash\ncase \"$1\" in\n    upgrade) exit 1 ;;\nesac\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, err := recordProgram(t, func(p *tea.Program) error {
+		return processUpgradeTUI(p, []ToolPlugin{broken}, nil, InstallOptions{})
+	})
+	if err == nil {
+		t.Error("expected the failed upgrade to be reported")
+	}
+	if summary := summaryOf(t, msgs); len(summary.Failed) != 1 || summary.Failed[0] != "broken" {
+		t.Errorf("expected broken to fail, got %+v", summary)
+	}
+}
+
+func TestUpgradeUserModeRefusesRootPlugins(t *testing.T) {
+	readLog := withMockCommands(t)
+
+	plugins := []ToolPlugin{{ID: "git", Name: "Git", InstallMethod: "apt", PackageName: "git", RequiresRoot: true}}
+	err := RunUpgradeTUI(plugins, InstallOptions{UserMode: true})
+	if err == nil || !strings.Contains(err.Error(), "cannot be upgraded with --user") {
+		t.Errorf("expected the root plugin to be refused, got %v", err)
+	}
+	if log := readLog(); strings.Contains(log, "sudo") {
+		t.Errorf("expected no sudo in user mode, got:\n%s", log)
+	}
+}
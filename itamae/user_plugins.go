@@ -0,0 +1,97 @@
+package itamae
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CategoryUser is the category of plugins loaded from the user plugin directory.
+const CategoryUser = "user"
+
+// UserPluginDir returns the directory holding the user's own plugins
+// (~/.config/itamae/plugins), overridable with ITAMAE_PLUGIN_DIR.
+func UserPluginDir() string {
+	if dir := os.Getenv("ITAMAE_PLUGIN_DIR"); dir != "" {
+		return dir
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(os.Getenv("HOME"), ".config", "itamae", "plugins")
+	}
+	return filepath.Join(dir, "itamae", "plugins")
+}
+
+// loadUserPlugins loads the .sh plugins in dir. A missing directory yields no
+// plugins, and plugins that fail to load are skipped with a warning.
+func loadUserPlugins(dir string) ([]ToolPlugin, func(), error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []ToolPlugin{}, func() {}, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read user plugin dir %s: %w", dir, err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "itamae-user-scripts-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+
+	cleanup := func() {
+		os.RemoveAll(tmpDir)
+	}
+
+	plugins := []ToolPlugin{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sh") {
+			continue
+		}
+
+		scriptPath := filepath.Join(dir, entry.Name())
+		plugin, err := loadUserPlugin(scriptPath, tmpDir)
+		if err != nil {
+			// One broken plugin shouldn't take down every command
+			Logger.Warnf("Skipping user plugin %s: %v\nRun 'itamae plugin lint %s' for details.", entry.Name(), err, scriptPath)
+			continue
+		}
+		plugins = append(plugins, plugin)
+	}
+
+	return plugins, cleanup, nil
+}
+
+// loadUserPlugin reads and parses the user plugin at scriptPath, unpacking it
+// into tmpDir.
+func loadUserPlugin(scriptPath, tmpDir string) (ToolPlugin, error) {
+	content, err := os.ReadFile(scriptPath)
+	if err != nil {
+		return ToolPlugin{}, fmt.Errorf("failed to read user plugin: %w", err)
+	}
+	manifest, err := readManifest(scriptPath)
+	if err != nil {
+		return ToolPlugin{}, err
+	}
+	return processPluginContent(filepath.Base(scriptPath), content, manifest, tmpDir, CategoryUser)
+}
+
+// mergeUserPlugins appends the user plugins to the built-in ones, replacing
+// built-in plugins that share an ID.
+func mergeUserPlugins(builtin, user []ToolPlugin) []ToolPlugin {
+	index := make(map[string]int, len(builtin))
+	for i, p := range builtin {
+		index[p.ID] = i
+	}
+
+	merged := append([]ToolPlugin{}, builtin...)
+	for _, p := range user {
+		if i, ok := index[p.ID]; ok {
+			DebugLog("User plugin %s overrides the built-in plugin", p.ID)
+			merged[i] = p
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return merged
+}
@@ -0,0 +1,199 @@
+package itamae
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// aptPackageSpec returns the package argument for apt, pinned as pkg=version
+// when the plugin has a version.
+func aptPackageSpec(plugin ToolPlugin) string {
+	if plugin.Version == "" {
+		return plugin.PackageName
+	}
+	return fmt.Sprintf("%s=%s", plugin.PackageName, plugin.Version)
+}
+
+// installedAptVersion returns the installed version of a package, or "" if unknown.
+func installedAptVersion(pkg string) string {
+	output, err := exec.Command("dpkg-query", "-W", "-f=${Version}", pkg).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// installedVersion detects the installed version of a plugin: from dpkg for APT
+// plugins, or from the script's `version` router case. Returns "" if unknown.
+func installedVersion(plugin ToolPlugin) string {
+	if plugin.InstallMethod == "apt" && plugin.PackageName != "" {
+		return installedAptVersion(plugin.PackageName)
+	}
+
+	if !hasRouterCase(plugin, "version") {
+		return ""
+	}
+	output, err := exec.Command("bash", plugin.ScriptPath, "version").Output()
+	if err != nil {
+		return ""
+	}
+	version, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	return version
+}
+
+// resolvedVersion returns the version a plugin was installed at, for the
+// summary: the detected version, or the pin when it can't be detected.
+func resolvedVersion(plugin ToolPlugin) string {
+	if version := installedVersion(plugin); version != "" {
+		return version
+	}
+	if plugin.Version != "" {
+		return plugin.Version
+	}
+	return "latest"
+}
+
+// honorsVersion reports whether installing the plugin respects a pinned
+// version: APT packages are pinned as pkg=version, scripts must read
+// ITAMAE_VERSION or download {version} artifacts.
+func honorsVersion(plugin ToolPlugin) bool {
+	if plugin.InstallMethod == "apt" && plugin.PackageName != "" {
+		return true
+	}
+	for _, artifact := range plugin.Artifacts {
+		if strings.Contains(artifact.URL, "{version}") {
+			return true
+		}
+	}
+	content, err := os.ReadFile(plugin.ScriptPath)
+	return err == nil && strings.Contains(string(content), "ITAMAE_VERSION")
+}
+
+// summaryLabel formats a plugin name with its resolved version.
+func summaryLabel(plugin ToolPlugin) string {
+	return fmt.Sprintf("%s (%s)", plugin.Name, resolvedVersion(plugin))
+}
+
+// upgradeLabel formats a plugin name with its versions before and after an upgrade.
+func upgradeLabel(plugin ToolPlugin, before, after string) string {
+	if before == "" {
+		before = "unknown"
+	}
+	if after == "" {
+		after = "unknown"
+	}
+	if sameVersion(before, after) {
+		return fmt.Sprintf("%s (%s, unchanged)", plugin.Name, after)
+	}
+	return fmt.Sprintf("%s (%s → %s)", plugin.Name, before, after)
+}
+
+// compareVersions orders two versions the way dpkg does, returning -1, 0 or
+// 1. A leading "v" is ignored so that tags like v1.31.0 match 1.31.0, and a
+// semver pre-release such as 1.0.0-rc1 sorts before 1.0.0.
+func compareVersions(a, b string) int {
+	aEpoch, aUpstream, aRevision := splitVersion(a)
+	bEpoch, bUpstream, bRevision := splitVersion(b)
+	if c := compareVersionPart(aEpoch, bEpoch); c != 0 {
+		return c
+	}
+	if c := compareVersionPart(aUpstream, bUpstream); c != 0 {
+		return c
+	}
+	return compareVersionPart(aRevision, bRevision)
+}
+
+// sameVersion reports whether two versions are equal under compareVersions.
+func sameVersion(a, b string) bool {
+	return compareVersions(a, b) == 0
+}
+
+// splitVersion splits a version into its dpkg epoch, upstream version and
+// revision.
+func splitVersion(version string) (epoch, upstream, revision string) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	epoch = "0"
+	if e, rest, ok := strings.Cut(version, ":"); ok {
+		epoch, version = e, rest
+	} else if i := strings.Index(version, "-"); i >= 0 && i+1 < len(version) && isVersionLetter(version[i+1]) {
+		// Without an epoch, -rc1 is a semver pre-release rather than a
+		// Debian revision, which dpkg spells ~rc1
+		version = version[:i] + "~" + version[i+1:]
+	}
+	if i := strings.LastIndex(version, "-"); i >= 0 {
+		return epoch, version[:i], version[i+1:]
+	}
+	return epoch, version, ""
+}
+
+// compareVersionPart compares one part of a version with dpkg's rules:
+// alternating runs of non-digits, compared by character with ~ sorting
+// before everything and letters before other symbols, and runs of digits,
+// compared numerically.
+func compareVersionPart(a, b string) int {
+	for a != "" || b != "" {
+		for (a != "" && !isVersionDigit(a[0])) || (b != "" && !isVersionDigit(b[0])) {
+			ac, bc := versionCharOrder(a), versionCharOrder(b)
+			if ac != bc {
+				if ac < bc {
+					return -1
+				}
+				return 1
+			}
+			a, b = a[1:], b[1:]
+		}
+
+		an, bn := 0, 0
+		for a != "" && isVersionDigit(a[0]) {
+			an = an*10 + int(a[0]-'0')
+			a = a[1:]
+		}
+		for b != "" && isVersionDigit(b[0]) {
+			bn = bn*10 + int(b[0]-'0')
+			b = b[1:]
+		}
+		if an != bn {
+			if an < bn {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionCharOrder ranks the first character of s for compareVersionPart. An
+// empty string or a digit ranks as the end of a non-digit run.
+func versionCharOrder(s string) int {
+	switch {
+	case s == "" || isVersionDigit(s[0]):
+		return 0
+	case s[0] == '~':
+		return -1
+	case isVersionLetter(s[0]):
+		return int(s[0])
+	default:
+		return int(s[0]) + 256
+	}
+}
+
+func isVersionDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isVersionLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// expandVersion replaces the {version} placeholder in s with the plugin version.
+func expandVersion(s string, plugin ToolPlugin) (string, error) {
+	if !strings.Contains(s, "{version}") {
+		return s, nil
+	}
+	if plugin.Version == "" {
+		return "", fmt.Errorf("%s uses {version} but %s has no VERSION", s, plugin.ID)
+	}
+	return strings.ReplaceAll(s, "{version}", plugin.Version), nil
+}